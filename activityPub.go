@@ -350,7 +350,7 @@ func (a *goBlog) apOnCreateUpdate(blog *configBlog, requestActor *ap.Actor, acti
 		if replyTarget := inReplyTo.GetLink().String(); replyTarget != "" && a.isLocalURL(replyTarget) {
 			if object.To.Contains(ap.PublicNS) || object.CC.Contains(ap.PublicNS) {
 				// Public reply - comment
-				_, _, _ = a.createComment(blog, replyTarget, content, actorName, actorLink, noteURI)
+				_, _, _ = a.createComment(blog, replyTarget, content, actorName, actorLink, noteURI, 0, false)
 				return
 			}
 			// Private reply - notification
@@ -570,6 +570,29 @@ func (a *goBlog) apUndelete(p *post) {
 	a.apPost(p)
 }
 
+func (a *goBlog) apReplyToComment(blogName string, reply, parent *comment) {
+	blogConfig := a.cfg.Blogs[blogName]
+	note := a.toAPCommentNote(blogName, reply)
+	note.InReplyTo = ap.IRI(parent.Original)
+	// Mention the author of the original note
+	var mentions []string
+	if item, err := a.apLoadRemoteIRI(blogName, ap.IRI(parent.Original)); err == nil && item != nil && item.IsObject() {
+		if obj, err := ap.ToObject(item); err == nil && obj.AttributedTo != nil && obj.AttributedTo.GetLink() != "" {
+			author := obj.AttributedTo.GetLink()
+			mentions = append(mentions, author.String())
+			note.CC.Append(author)
+			apMention := ap.ObjectNew(ap.MentionType)
+			apMention.ID = author
+			apMention.Href = author
+			note.Tag.Append(apMention)
+		}
+	}
+	c := ap.ActivityNew(ap.CreateType, a.apNewID(blogConfig), note)
+	c.Actor = a.apAPIri(blogConfig)
+	c.Published = time.Now()
+	a.apSendToAllFollowers(blogName, c, mentions...)
+}
+
 func (a *goBlog) apAccept(blogName string, blog *configBlog, follow *ap.Activity) {
 	newFollower := follow.Actor.GetLink()
 	a.info("ActivityPub: New follow request from follower", "id", newFollower.String())
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/araddon/dateparse"
 	ct "github.com/elnormous/contenttype"
@@ -121,6 +123,21 @@ func (a *goBlog) toAPNote(p *post) *ap.Note {
 			note.Updated = t
 		}
 	}
+	// Event
+	if start := a.eventStart(p); !start.IsZero() {
+		note.Type = ap.EventType
+		note.StartTime = start
+		note.EndTime = a.eventEnd(p)
+		if location := a.eventLocation(p); location != "" {
+			place := ap.ObjectNew(ap.PlaceType)
+			place.Name = ap.NaturalLanguageValues{{Lang: bc.Lang, Value: location}}
+			note.Location = place
+		} else if geoURIs := a.geoURIs(p); len(geoURIs) > 0 {
+			place := ap.ObjectNew(ap.PlaceType)
+			place.Name = ap.NaturalLanguageValues{{Lang: bc.Lang, Value: a.geoTitle(geoURIs[0], bc.Lang)}}
+			note.Location = place
+		}
+	}
 	// Reply
 	if replyLink := p.firstParameter(a.cfg.Micropub.ReplyParam); replyLink != "" {
 		if replyObject := p.firstParameter(activityPubReplyObjectParameter); replyObject != "" {
@@ -133,6 +150,20 @@ func (a *goBlog) toAPNote(p *post) *ap.Note {
 	return note
 }
 
+func (a *goBlog) toAPCommentNote(blogName string, c *comment) *ap.Note {
+	bc := a.cfg.Blogs[blogName]
+	commentAddress := a.getFullAddress(bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, c.ID)))
+	note := ap.ObjectNew(ap.NoteType)
+	note.ID = ap.IRI(commentAddress)
+	note.URL = ap.IRI(commentAddress)
+	note.AttributedTo = a.apAPIri(bc)
+	note.To.Append(ap.PublicNS, a.apGetFollowersCollectionID(blogName))
+	note.MediaType = ap.MimeType(contenttype.HTML)
+	note.Content = ap.NaturalLanguageValues{{Lang: bc.Lang, Value: "<p>" + strings.ReplaceAll(c.Comment, "\n", "<br>") + "</p>"}} // Already escaped
+	note.Published = time.Now()
+	return note
+}
+
 const activityPubVersionParam = "activitypubversion"
 
 func (a *goBlog) activityPubID(p *post) ap.IRI {
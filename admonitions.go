@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+// Admonitions (callouts) like GitHub alerts: the first line of a blockquote is [!NOTE], [!TIP], [!IMPORTANT], [!WARNING] or [!CAUTION],
+// optionally followed by a custom title
+
+var (
+	kindAdmonition        = ast.NewNodeKind("Admonition")
+	admonitionMarkerRegex = regexp.MustCompile(`(?i)^\[!(note|tip|important|warning|caution)\](?:\s+(.+))?$`)
+)
+
+type admonitionNode struct {
+	ast.BaseBlock
+	typ, title string
+}
+
+func (n *admonitionNode) Kind() ast.NodeKind {
+	return kindAdmonition
+}
+
+func (n *admonitionNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Type": n.typ, "Title": n.title}, nil)
+}
+
+type admonitionTransformer struct{}
+
+func (*admonitionTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+	var blockquotes []*ast.Blockquote
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if bq, ok := n.(*ast.Blockquote); ok && entering {
+			blockquotes = append(blockquotes, bq)
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, bq := range blockquotes {
+		para, ok := bq.FirstChild().(*ast.Paragraph)
+		if !ok || para.Lines().Len() == 0 {
+			continue
+		}
+		firstLine := para.Lines().At(0)
+		matches := admonitionMarkerRegex.FindSubmatch(bytes.TrimSpace(firstLine.Value(source)))
+		if matches == nil {
+			continue
+		}
+		typ := strings.ToLower(string(matches[1]))
+		admonition := &admonitionNode{
+			typ:   typ,
+			title: cmp.Or(strings.TrimSpace(string(matches[2])), strings.ToUpper(typ[:1])+typ[1:]),
+		}
+		// Remove the inline nodes of the marker line
+		for child := para.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			if start := inlineStart(child); start < 0 || start >= firstLine.Stop {
+				break
+			}
+			para.RemoveChild(para, child)
+			child = next
+		}
+		if !para.HasChildren() {
+			bq.RemoveChild(bq, para)
+		}
+		for child := bq.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			admonition.AppendChild(admonition, child)
+			child = next
+		}
+		bq.Parent().ReplaceChild(bq.Parent(), bq, admonition)
+	}
+}
+
+// inlineStart returns the source position of the first text of the inline node or -1
+func inlineStart(n ast.Node) int {
+	if t, ok := n.(*ast.Text); ok {
+		return t.Segment.Start
+	}
+	for child := n.FirstChild(); child != nil; child = child.NextSibling() {
+		if start := inlineStart(child); start >= 0 {
+			return start
+		}
+	}
+	return -1
+}
+
+func (c *customRenderer) renderAdmonition(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	hb := htmlbuilder.NewHTMLBuilder(w)
+	if !entering {
+		hb.WriteElementClose("div")
+		hb.WriteUnescaped("\n")
+		return ast.WalkContinue, nil
+	}
+	n := node.(*admonitionNode)
+	hb.WriteElementOpen("div", "class", "admonition admonition-"+n.typ)
+	hb.WriteUnescaped("\n")
+	// The title is bold for feed readers without the styles
+	hb.WriteElementOpen("p", "class", "admonition-title")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(c.app.renderTextSafe(n.title))
+	hb.WriteElementClose("strong")
+	hb.WriteElementClose("p")
+	hb.WriteUnescaped("\n")
+	return ast.WalkContinue, nil
+}
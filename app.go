@@ -39,9 +39,15 @@ type goBlog struct {
 	certMgrInit sync.Once
 	// Blogroll
 	blogrollCacheGroup singleflightx.Group[string, []*opml.Outline]
+	// Bulk post actions
+	bulkJobs      map[string]*bulkJob
+	bulkJobsMutex sync.Mutex
 
 	// Cache
 	cache *cache
+	// Comment subscriptions
+	csKey  []byte
+	csLoad sync.Once
 	// Config
 	cfg *config
 	// Database
@@ -86,9 +92,17 @@ type goBlog struct {
 	min minify.Minifier
 	// Plugins
 	pluginHost *plugins.PluginHost
+	// Preview links
+	plKey  []byte
+	plLoad sync.Once
 	// Profile image
 	profileImageHashString string
 	profileImageHashGroup  *sync.Once
+	// Protected posts
+	ppKey        []byte
+	ppLoad       sync.Once
+	ppFailed     *c.Cache[string, int]
+	ppFailedInit sync.Once
 	// Reactions
 	reactionsInit  sync.Once
 	reactionsCache *c.Cache[string, string]
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	ap "go.goblog.app/app/pkgs/activitypub"
+)
+
+type archiveImportConfig struct {
+	blog, section string
+	tagsTaxonomy  string // Empty to skip
+}
+
+type archiveImportResult struct {
+	notes, media, skipped int
+}
+
+// archiveNote is a status or tweet from an archive
+type archiveNote struct {
+	id, url           string // url is kept as syndication link
+	published         time.Time
+	content           string // Markdown
+	replyTo, replyURL string // replyTo is the id of the parent if it's part of a thread
+	tags              []string
+	media             []*archiveMedia
+	visibility        postVisibility
+}
+
+type archiveMedia struct {
+	file, url, description string // file in the archive or url to download
+}
+
+// importArchiveFile imports the public statuses of a Mastodon archive or the tweets of a Twitter archive (directory or zip file) as notes
+func (a *goBlog) importArchiveFile(format, file string, conf *archiveImportConfig) (*archiveImportResult, error) {
+	bc, ok := a.cfg.Blogs[conf.blog]
+	if !ok {
+		return nil, errors.New("blog not found")
+	}
+	conf.section = cmp.Or(conf.section, bc.DefaultSection)
+	if _, ok := bc.Sections[conf.section]; !ok {
+		return nil, errors.New("section not found")
+	}
+	fsys, closeArchive, err := openArchive(file)
+	if err != nil {
+		return nil, err
+	}
+	defer closeArchive()
+	var notes []*archiveNote
+	switch format {
+	case "mastodon":
+		notes, err = parseMastodonArchive(fsys)
+	case "twitter":
+		notes, err = parseTwitterArchive(fsys)
+	default:
+		err = fmt.Errorf("unknown archive format: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a.importArchiveNotes(fsys, notes, conf)
+}
+
+// openArchive opens a directory or zip file, a file in a directory (like outbox.json) opens the directory
+func openArchive(file string) (fs.FS, func() error, error) {
+	noop := func() error { return nil }
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		return os.DirFS(file), noop, nil
+	}
+	if strings.EqualFold(filepath.Ext(file), ".zip") {
+		zr, err := zip.OpenReader(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	}
+	return os.DirFS(filepath.Dir(file)), noop, nil
+}
+
+func (a *goBlog) importArchiveNotes(fsys fs.FS, notes []*archiveNote, conf *archiveImportConfig) (*archiveImportResult, error) {
+	// Import parents of threads first
+	slices.SortStableFunc(notes, func(x, y *archiveNote) int {
+		return x.published.Compare(y.published)
+	})
+	result := &archiveImportResult{}
+	paths := map[string]string{} // Old id to new path
+	for _, note := range notes {
+		// Check if already imported
+		existing, err := a.db.postPathBySyndication(note.url)
+		if err != nil {
+			return result, err
+		}
+		if existing != "" {
+			paths[note.id] = existing
+			result.skipped++
+			continue
+		}
+		slug := path.Base(note.id) // Mastodon ids are URLs
+		p := &post{
+			Blog:       conf.blog,
+			Section:    conf.section,
+			Slug:       slug,
+			Published:  note.published.Format(time.RFC3339),
+			Content:    note.content,
+			Visibility: note.visibility,
+			Parameters: map[string][]string{
+				"syndication": {note.url},
+			},
+		}
+		// Threads link to the imported parent
+		if parent, ok := paths[note.replyTo]; ok && note.replyTo != "" {
+			p.Parameters[a.cfg.Micropub.ReplyParam] = []string{a.getFullAddress(parent)}
+		} else if note.replyURL != "" {
+			p.Parameters[a.cfg.Micropub.ReplyParam] = []string{note.replyURL}
+		}
+		if conf.tagsTaxonomy != "" && len(note.tags) > 0 {
+			p.Parameters[conf.tagsTaxonomy] = note.tags
+		}
+		// Upload media
+		for _, m := range note.media {
+			mediaURL, err := a.importArchiveMedia(fsys, m)
+			if err != nil {
+				a.error("Failed to import media file", "note", note.url, "file", cmp.Or(m.file, m.url), "err", err)
+				continue
+			}
+			result.media++
+			if isImageExtension(path.Ext(mediaURL)) {
+				p.Parameters[a.cfg.Micropub.PhotoParam] = append(p.Parameters[a.cfg.Micropub.PhotoParam], mediaURL)
+				p.Parameters[a.cfg.Micropub.PhotoDescriptionParam] = append(p.Parameters[a.cfg.Micropub.PhotoDescriptionParam], m.description)
+			} else {
+				p.Content += fmt.Sprintf("\n\n<video src=\"%s\" controls></video>", html.EscapeString(mediaURL))
+			}
+		}
+		a.addImagesToContent(p)
+		p.Content = strings.TrimSpace(p.Content)
+		// Save post without triggering hooks (no webmentions or ActivityPub for old notes)
+		if err = a.checkPost(p, true, false); err != nil {
+			return result, fmt.Errorf("invalid note %s: %w", note.url, err)
+		}
+		// Use a unique path if another post already has the path
+		basePath := p.Path
+		if err = a.useUniquePostPath(p, slug); err != nil {
+			return result, fmt.Errorf("invalid note %s: %w", note.url, err)
+		}
+		if p.Path != basePath {
+			a.info("Path already used, using another path", "note", note.url, "path", p.Path)
+		}
+		if err = a.db.savePost(p, &postCreationOptions{isNew: true}); err != nil {
+			return result, fmt.Errorf("failed to save note %s: %w", note.url, err)
+		}
+		paths[note.id] = p.Path
+		result.notes++
+	}
+	if result.notes > 0 {
+		a.purgeCache()
+	}
+	return result, nil
+}
+
+// importArchiveMedia uploads the file from the archive or downloads it if it's not in the archive
+func (a *goBlog) importArchiveMedia(fsys fs.FS, m *archiveMedia) (string, error) {
+	if m.file != "" {
+		data, err := fs.ReadFile(fsys, m.file)
+		if err == nil {
+			return a.importMediaData(path.Ext(m.file), data)
+		} else if m.url == "" {
+			return "", err
+		}
+	}
+	return a.importMediaURL(m.url)
+}
+
+func (db *database) postPathBySyndication(link string) (string, error) {
+	row, err := db.QueryRow(
+		"select path from post_parameters where parameter = 'syndication' and value = @link limit 1",
+		sql.Named("link", link),
+	)
+	if err != nil {
+		return "", err
+	}
+	var p string
+	if err = row.Scan(&p); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+	return p, nil
+}
+
+// Mastodon (outbox.json)
+
+type mastodonOutbox struct {
+	OrderedItems []*struct {
+		Type   string          `json:"type"`
+		Object json.RawMessage `json:"object"`
+	} `json:"orderedItems"`
+}
+
+type mastodonStatus struct {
+	ID         string   `json:"id"`
+	URL        string   `json:"url"`
+	Published  string   `json:"published"`
+	Content    string   `json:"content"`
+	InReplyTo  string   `json:"inReplyTo"`
+	To         []string `json:"to"`
+	Cc         []string `json:"cc"`
+	Attachment []*struct {
+		URL  string `json:"url"`
+		Name string `json:"name"`
+	} `json:"attachment"`
+	Tag []*struct {
+		Type string `json:"type"`
+		Name string `json:"name"`
+	} `json:"tag"`
+}
+
+func parseMastodonArchive(fsys fs.FS) ([]*archiveNote, error) {
+	data, err := fs.ReadFile(fsys, "outbox.json")
+	if err != nil {
+		return nil, err
+	}
+	var outbox mastodonOutbox
+	if err = json.Unmarshal(data, &outbox); err != nil {
+		return nil, err
+	}
+	var notes []*archiveNote
+	for _, item := range outbox.OrderedItems {
+		// Skip boosts
+		if item.Type != "Create" {
+			continue
+		}
+		var status mastodonStatus
+		if err = json.Unmarshal(item.Object, &status); err != nil {
+			return nil, err
+		}
+		// Skip followers-only posts and direct messages
+		var visibility postVisibility
+		switch {
+		case slices.Contains(status.To, string(ap.PublicNS)):
+			visibility = visibilityPublic
+		case slices.Contains(status.Cc, string(ap.PublicNS)):
+			visibility = visibilityUnlisted
+		default:
+			continue
+		}
+		published, err := time.Parse(time.RFC3339, status.Published)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of status %s: %w", status.ID, err)
+		}
+		note := &archiveNote{
+			id:         status.ID,
+			url:        cmp.Or(status.URL, status.ID),
+			published:  published,
+			content:    htmlToMarkdown(status.Content),
+			replyTo:    status.InReplyTo,
+			replyURL:   status.InReplyTo,
+			visibility: visibility,
+		}
+		for _, tag := range status.Tag {
+			if tag.Type == "Hashtag" {
+				note.tags = append(note.tags, strings.TrimPrefix(tag.Name, "#"))
+			}
+		}
+		for _, attachment := range status.Attachment {
+			m := &archiveMedia{url: attachment.URL, description: attachment.Name}
+			// Archives contain the files with the path of the URL
+			if u, err := url.Parse(attachment.URL); err == nil {
+				m.file = strings.TrimPrefix(u.Path, "/")
+			}
+			if !strings.HasPrefix(attachment.URL, "http") {
+				m.url = ""
+			}
+			note.media = append(note.media, m)
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// Twitter (data/tweets.js)
+
+type twitterTweet struct {
+	Tweet struct {
+		ID                  string `json:"id_str"`
+		CreatedAt           string `json:"created_at"`
+		FullText            string `json:"full_text"`
+		InReplyToStatusID   string `json:"in_reply_to_status_id_str"`
+		InReplyToScreenName string `json:"in_reply_to_screen_name"`
+		Entities            struct {
+			Hashtags []*struct {
+				Text string `json:"text"`
+			} `json:"hashtags"`
+			URLs []*struct {
+				URL         string `json:"url"`
+				ExpandedURL string `json:"expanded_url"`
+			} `json:"urls"`
+		} `json:"entities"`
+		ExtendedEntities struct {
+			Media []*struct {
+				URL      string `json:"url"`
+				MediaURL string `json:"media_url_https"`
+				Type     string `json:"type"`
+			} `json:"media"`
+		} `json:"extended_entities"`
+	} `json:"tweet"`
+}
+
+type twitterAccount struct {
+	Account struct {
+		Username string `json:"username"`
+	} `json:"account"`
+}
+
+// readTwitterJS reads the JSON of a file like data/tweets.js ("window.YTD.tweets.part0 = [...]")
+func readTwitterJS(fsys fs.FS, name string, v any) error {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if i := bytes.IndexByte(data, '='); i >= 0 && i < bytes.IndexByte(data, '[') {
+		data = data[i+1:]
+	}
+	return json.Unmarshal(data, v)
+}
+
+func parseTwitterArchive(fsys fs.FS) ([]*archiveNote, error) {
+	var accounts []*twitterAccount
+	if err := readTwitterJS(fsys, "data/account.js", &accounts); err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 || accounts[0].Account.Username == "" {
+		return nil, errors.New("no account found in archive")
+	}
+	username := accounts[0].Account.Username
+	// Large archives are split in multiple files, older archives use tweet.js
+	var files []string
+	for _, pattern := range []string{"data/tweet.js", "data/tweets.js", "data/tweet-part*.js", "data/tweets-part*.js"} {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("no tweets found in archive")
+	}
+	var notes []*archiveNote
+	for _, file := range files {
+		var tweets []*twitterTweet
+		if err := readTwitterJS(fsys, file, &tweets); err != nil {
+			return nil, err
+		}
+		for _, t := range tweets {
+			tweet := &t.Tweet
+			// Skip retweets
+			if strings.HasPrefix(tweet.FullText, "RT @") {
+				continue
+			}
+			published, err := time.Parse(time.RubyDate, tweet.CreatedAt)
+			if err != nil {
+				return nil, fmt.Errorf("invalid date of tweet %s: %w", tweet.ID, err)
+			}
+			// The text is already HTML escaped, links are shortened
+			text := tweet.FullText
+			for _, u := range tweet.Entities.URLs {
+				text = strings.ReplaceAll(text, u.URL, fmt.Sprintf(`<a href="%[1]s">%[1]s</a>`, html.EscapeString(u.ExpandedURL)))
+			}
+			note := &archiveNote{
+				id:         tweet.ID,
+				url:        fmt.Sprintf("https://twitter.com/%s/status/%s", username, tweet.ID),
+				published:  published,
+				replyTo:    tweet.InReplyToStatusID,
+				visibility: visibilityPublic,
+			}
+			if tweet.InReplyToStatusID != "" {
+				note.replyURL = fmt.Sprintf("https://twitter.com/%s/status/%s", cmp.Or(tweet.InReplyToScreenName, "i"), tweet.InReplyToStatusID)
+			}
+			for _, hashtag := range tweet.Entities.Hashtags {
+				note.tags = append(note.tags, hashtag.Text)
+			}
+			for _, m := range tweet.ExtendedEntities.Media {
+				text = strings.ReplaceAll(text, m.URL, "")
+				media := &archiveMedia{file: fmt.Sprintf("data/tweets_media/%s-%s", tweet.ID, path.Base(m.MediaURL)), url: m.MediaURL}
+				if m.Type != "photo" {
+					// The archive contains the video instead of the thumbnail
+					if videos, _ := fs.Glob(fsys, fmt.Sprintf("data/tweets_media/%s-*.mp4", tweet.ID)); len(videos) > 0 {
+						media = &archiveMedia{file: videos[0]}
+					}
+				}
+				note.media = append(note.media, media)
+			}
+			note.content = htmlToMarkdown(twitterTextToHTML(strings.TrimSpace(text)))
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+// twitterTextToHTML converts the line breaks of the tweet text to paragraphs and breaks
+func twitterTextToHTML(text string) string {
+	var sb strings.Builder
+	for paragraph := range strings.SplitSeq(text, "\n\n") {
+		sb.WriteString("<p>")
+		sb.WriteString(strings.ReplaceAll(paragraph, "\n", "<br>"))
+		sb.WriteString("</p>")
+	}
+	return sb.String()
+}
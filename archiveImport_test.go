@@ -0,0 +1,211 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testMastodonOutbox = `{
+  "@context": "https://www.w3.org/ns/activitystreams",
+  "type": "OrderedCollection",
+  "orderedItems": [
+    {
+      "type": "Create",
+      "object": {
+        "id": "https://social.example/users/alice/statuses/102",
+        "url": "https://social.example/@alice/102",
+        "published": "2023-01-02T10:00:00Z",
+        "content": "<p>Second part of the thread</p>",
+        "inReplyTo": "https://social.example/users/alice/statuses/101",
+        "to": ["https://www.w3.org/ns/activitystreams#Public"],
+        "cc": []
+      }
+    },
+    {
+      "type": "Create",
+      "object": {
+        "id": "https://social.example/users/alice/statuses/101",
+        "url": "https://social.example/@alice/101",
+        "published": "2023-01-01T10:00:00Z",
+        "content": "<p>Hello <a href=\"https://social.example/tags/photo\" class=\"mention hashtag\" rel=\"tag\">#<span>Photo</span></a></p>",
+        "inReplyTo": null,
+        "to": ["https://www.w3.org/ns/activitystreams#Public"],
+        "cc": ["https://social.example/users/alice/followers"],
+        "attachment": [
+          {"type": "Document", "mediaType": "image/jpeg", "url": "/media_attachments/files/1/original/photo.jpg", "name": "A photo"}
+        ],
+        "tag": [{"type": "Hashtag", "href": "https://social.example/tags/photo", "name": "#Photo"}]
+      }
+    },
+    {
+      "type": "Create",
+      "object": {
+        "id": "https://social.example/users/alice/statuses/103",
+        "url": "https://social.example/@alice/103",
+        "published": "2023-01-03T10:00:00Z",
+        "content": "<p>Unlisted reply</p>",
+        "inReplyTo": "https://other.example/users/bob/statuses/1",
+        "to": ["https://social.example/users/alice/followers"],
+        "cc": ["https://www.w3.org/ns/activitystreams#Public"]
+      }
+    },
+    {
+      "type": "Create",
+      "object": {
+        "id": "https://social.example/users/alice/statuses/104",
+        "url": "https://social.example/@alice/104",
+        "published": "2023-01-04T10:00:00Z",
+        "content": "<p>Direct message</p>",
+        "to": ["https://other.example/users/bob"],
+        "cc": []
+      }
+    },
+    {
+      "type": "Announce",
+      "object": "https://other.example/users/bob/statuses/2"
+    }
+  ]
+}`
+
+const testTwitterAccount = `window.YTD.account.part0 = [{"account": {"username": "alice", "accountId": "1"}}]`
+
+const testTwitterTweets = `window.YTD.tweets.part0 = [
+  {"tweet": {
+    "id_str": "2001",
+    "created_at": "Mon Jan 02 10:00:00 +0000 2017",
+    "full_text": "Tweet with &amp; a link https://t.co/abc #GoBlog\n\nAnd a photo https://t.co/img",
+    "entities": {
+      "hashtags": [{"text": "GoBlog"}],
+      "urls": [{"url": "https://t.co/abc", "expanded_url": "https://example.org/page"}]
+    },
+    "extended_entities": {
+      "media": [{"url": "https://t.co/img", "media_url_https": "https://pbs.twimg.com/media/xyz.jpg", "type": "photo"}]
+    }
+  }},
+  {"tweet": {
+    "id_str": "2002",
+    "created_at": "Mon Jan 02 11:00:00 +0000 2017",
+    "full_text": "Thread continued",
+    "in_reply_to_status_id_str": "2001",
+    "in_reply_to_screen_name": "alice"
+  }},
+  {"tweet": {
+    "id_str": "2003",
+    "created_at": "Mon Jan 02 12:00:00 +0000 2017",
+    "full_text": "RT @bob: Retweeted"
+  }}
+]`
+
+func Test_archiveImport(t *testing.T) {
+	app := newAppWithStorage(t, &localMediaStorage{path: t.TempDir()})
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	require.NoError(t, app.initConfig(false))
+	_ = app.initTemplateStrings()
+
+	conf := &archiveImportConfig{blog: "default", tagsTaxonomy: "tags"}
+
+	t.Run("Mastodon", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "outbox.json"), []byte(testMastodonOutbox), 0o644))
+		mediaDir := filepath.Join(dir, "media_attachments", "files", "1", "original")
+		require.NoError(t, os.MkdirAll(mediaDir, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "photo.jpg"), []byte("photo"), 0o644))
+
+		result, err := app.importArchiveFile("mastodon", filepath.Join(dir, "outbox.json"), conf)
+		require.NoError(t, err)
+		assert.Equal(t, &archiveImportResult{notes: 3, media: 1}, result)
+
+		first, err := app.getPost("/posts/2023/01/101")
+		require.NoError(t, err)
+		assert.Equal(t, "2023-01-01T10:00:00Z", toUTCSafe(first.Published))
+		assert.Equal(t, []string{"https://social.example/@alice/101"}, first.Parameters["syndication"])
+		assert.Equal(t, []string{"Photo"}, first.Parameters["tags"])
+		assert.Equal(t, visibilityPublic, first.Visibility)
+		mediaURL := "https://example.com/m/" + importMediaFileName(".jpg", []byte("photo"))
+		assert.Equal(t, []string{mediaURL}, first.Parameters["images"])
+		assert.Equal(t, []string{"A photo"}, first.Parameters["imagealts"])
+		assert.Equal(t, "Hello [#Photo](https://social.example/tags/photo)\n\n![A photo]("+mediaURL+" \"A photo\")", first.Content)
+
+		// The thread links to the imported post
+		second, err := app.getPost("/posts/2023/01/102")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/posts/2023/01/101"}, second.Parameters["replylink"])
+
+		reply, err := app.getPost("/posts/2023/01/103")
+		require.NoError(t, err)
+		assert.Equal(t, visibilityUnlisted, reply.Visibility)
+		assert.Equal(t, []string{"https://other.example/users/bob/statuses/1"}, reply.Parameters["replylink"])
+
+		// Direct messages are skipped
+		_, err = app.getPost("/posts/2023/01/104")
+		assert.ErrorIs(t, err, errPostNotFound)
+
+		// Importing again skips the notes
+		result, err = app.importArchiveFile("mastodon", dir, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &archiveImportResult{skipped: 3}, result)
+
+		// Notes with the same slug get another path
+		result, err = app.importArchiveNotes(nil, []*archiveNote{{
+			id:         "https://other.example/users/bob/statuses/101",
+			url:        "https://other.example/@bob/101",
+			published:  time.Date(2023, 1, 5, 10, 0, 0, 0, time.UTC),
+			content:    "Same slug",
+			visibility: visibilityPublic,
+		}}, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &archiveImportResult{notes: 1}, result)
+		other, err := app.getPost("/posts/2023/01/101-2")
+		require.NoError(t, err)
+		assert.Equal(t, "Same slug", other.Content)
+		first, err = app.getPost("/posts/2023/01/101")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://social.example/@alice/101"}, first.Parameters["syndication"])
+	})
+
+	t.Run("Twitter", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "twitter.zip")
+		f, err := os.Create(file)
+		require.NoError(t, err)
+		zw := zip.NewWriter(f)
+		for name, content := range map[string]string{
+			"data/account.js":                  testTwitterAccount,
+			"data/tweets.js":                   testTwitterTweets,
+			"data/tweets_media/2001-xyz.jpg":   "tweet photo",
+			"data/tweets_media/9999-other.jpg": "other photo",
+		} {
+			w, err := zw.Create(name)
+			require.NoError(t, err)
+			_, err = w.Write([]byte(content))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+		require.NoError(t, f.Close())
+
+		result, err := app.importArchiveFile("twitter", file, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &archiveImportResult{notes: 2, media: 1}, result)
+
+		first, err := app.getPost("/posts/2017/01/2001")
+		require.NoError(t, err)
+		mediaURL := "https://example.com/m/" + importMediaFileName(".jpg", []byte("tweet photo"))
+		assert.Equal(t, "Tweet with & a link [https://example.org/page](https://example.org/page) #GoBlog\n\nAnd a photo\n\n![]("+mediaURL+")", first.Content)
+		assert.Equal(t, []string{"https://twitter.com/alice/status/2001"}, first.Parameters["syndication"])
+		assert.Equal(t, []string{"GoBlog"}, first.Parameters["tags"])
+
+		second, err := app.getPost("/posts/2017/01/2002")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"https://example.com/posts/2017/01/2001"}, second.Parameters["replylink"])
+
+		result, err = app.importArchiveFile("twitter", file, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &archiveImportResult{skipped: 2}, result)
+	})
+}
@@ -74,10 +74,11 @@ type blogStatsRow struct {
 }
 
 type blogStatsData struct {
-	Total  blogStatsRow
-	NoDate blogStatsRow
-	Years  []blogStatsRow
-	Months map[string][]blogStatsRow
+	Total     blogStatsRow
+	NoDate    blogStatsRow
+	Years     []blogStatsRow
+	Months    map[string][]blogStatsRow
+	MediaLogs []*mediaLogStatsRow
 }
 
 func (db *database) getBlogStats(blog string) (data *blogStatsData, err error) {
@@ -123,5 +124,10 @@ func (db *database) getBlogStats(blog string) (data *blogStatsData, err error) {
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
+	// Media logs per year
+	data.MediaLogs, err = db.getMediaLogStats(blog)
+	if err != nil {
+		return nil, err
+	}
 	return data, nil
 }
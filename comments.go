@@ -9,6 +9,8 @@ import (
 	"net/url"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"go.goblog.app/app/pkgs/builderpool"
@@ -16,6 +18,14 @@ import (
 
 const commentPath = "/comment"
 
+type commentStatus string
+
+const (
+	commentStatusApproved commentStatus = "approved"
+	commentStatusPending  commentStatus = "pending"
+	commentStatusSpam     commentStatus = "spam"
+)
+
 type comment struct {
 	ID       int
 	Target   string
@@ -23,6 +33,10 @@ type comment struct {
 	Website  string
 	Comment  string
 	Original string
+	Parent   int
+	Status   commentStatus
+	Created  int64
+	email    string // Only used to skip the own comment subscription
 }
 
 func (a *goBlog) serveComment(w http.ResponseWriter, r *http.Request) {
@@ -41,6 +55,10 @@ func (a *goBlog) serveComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	comment := comments[0]
+	if comment.Status != commentStatusApproved && !a.isLoggedIn(r) {
+		a.serve404(w, r)
+		return
+	}
 	_, bc := a.getBlog(r)
 	canonical := a.getFullAddress(bc.getRelativePath(path.Join(commentPath, strconv.Itoa(id))))
 	a.render(w, r, a.renderComment, &renderData{
@@ -54,24 +72,83 @@ func (a *goBlog) createCommentFromRequest(w http.ResponseWriter, r *http.Request
 	comment := r.FormValue("comment") //nolint:gosec
 	name := r.FormValue("name")       //nolint:gosec
 	website := r.FormValue("website") //nolint:gosec
-	_, bc := a.getBlog(r)
+	parent := r.FormValue("parent")   //nolint:gosec
+	email := r.FormValue("email")     //nolint:gosec
+	blog, bc := a.getBlog(r)
+	// Check email for reply notifications
+	if email != "" && a.commentSubscriptionsEnabled(bc) {
+		var err error
+		if email, err = parseSubscriptionEmail(email); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		email = ""
+	}
 	// Create comment
-	result, errStatus, err := a.createComment(bc, target, comment, name, website, "")
+	result, errStatus, err := a.createCommentWithEmail(bc, target, comment, name, website, email, "", stringToInt(parent), false)
 	if err != nil {
 		a.serveError(w, r, err.Error(), errStatus)
 		return
 	}
+	if id, ok := a.commentIDFromPath(bc, result); ok {
+		if created, err := a.db.getComments(&commentsRequestConfig{id: id}); err == nil && len(created) > 0 {
+			// Subscribe to new comments
+			if email != "" {
+				go func() {
+					if err := a.subscribeToComments(blog, created[0].Target, email); err != nil {
+						a.error("Failed to subscribe to comments", "err", err)
+					}
+				}()
+			}
+			// Show notice if comment awaits moderation
+			if created[0].Status == commentStatusPending {
+				a.render(w, r, a.renderCommentPending, &renderData{})
+				return
+			}
+		}
+	}
 	// Redirect to comment
 	http.Redirect(w, r, result, http.StatusFound)
 }
 
-func (a *goBlog) createComment(bc *configBlog, target, comment, name, website, original string) (string, int, error) {
+// createComment creates a new comment or updates an existing one with the same original,
+// trusted comments (e.g. replies by the blog author) skip the moderation
+func (a *goBlog) createComment(bc *configBlog, target, comment, name, website, original string, parent int, trusted bool) (string, int, error) {
+	return a.createCommentWithEmail(bc, target, comment, name, website, "", original, parent, trusted)
+}
+
+// createCommentWithEmail is like createComment and saves the email of the commenter to not notify them about their own comment
+func (a *goBlog) createCommentWithEmail(bc *configBlog, target, comment, name, website, email, original string, parent int, trusted bool) (string, int, error) {
 	updateID := -1
 	// Check target
 	target, status, err := a.checkCommentTarget(target)
 	if err != nil {
 		return "", status, err
 	}
+	// Check parent, a target pointing to another comment is a reply too
+	targetParent, targetIsComment := a.commentIDFromPath(bc, target)
+	if targetIsComment {
+		if parent != 0 && parent != targetParent {
+			return "", http.StatusBadRequest, errors.New("parent doesn't match the target")
+		}
+		parent = targetParent
+	}
+	if parent != 0 {
+		parents, err := a.db.getComments(&commentsRequestConfig{id: parent})
+		if err != nil {
+			return "", http.StatusInternalServerError, errors.New("failed to check the database")
+		}
+		if len(parents) < 1 || parents[0].Status != commentStatusApproved || a.blogFromPath(parents[0].Target) != bc {
+			return "", http.StatusBadRequest, errors.New("parent comment not found")
+		}
+		if targetIsComment {
+			// Reply to the comment address, so use the target of the parent
+			target = parents[0].Target
+		} else if parents[0].Target != target {
+			return "", http.StatusBadRequest, errors.New("parent doesn't match the target")
+		}
+	}
 	// Check and clean comment
 	comment = cleanHTMLText(comment)
 	if comment == "" {
@@ -92,9 +169,23 @@ func (a *goBlog) createComment(bc *configBlog, target, comment, name, website, o
 	}
 	// Insert
 	if updateID == -1 {
+		newStatus := commentStatusApproved
+		if !trusted && a.commentsModerationEnabled(bc) {
+			newStatus = commentStatusPending
+		}
+		// Check for spam
+		if !trusted {
+			switch verdict, score := a.checkSpam(comment, name, website); verdict {
+			case spamVerdictDrop:
+				a.info("Dropped comment as spam", "target", target, "score", score)
+				return "", http.StatusBadRequest, errors.New("comment rejected as spam")
+			case spamVerdictModerate:
+				newStatus = commentStatusPending
+			}
+		}
 		result, err := a.db.Exec(
-			"insert into comments (target, comment, name, website, original) values (@target, @comment, @name, @website, @original)",
-			sql.Named("target", target), sql.Named("comment", comment), sql.Named("name", name), sql.Named("website", website), sql.Named("original", original),
+			"insert into comments (target, comment, name, website, email, original, parent, status, created) values (@target, @comment, @name, @website, @email, @original, @parent, @status, @created)",
+			sql.Named("target", target), sql.Named("comment", comment), sql.Named("name", name), sql.Named("website", website), sql.Named("email", email), sql.Named("original", original), sql.Named("parent", parent), sql.Named("status", newStatus), sql.Named("created", time.Now().Unix()),
 		)
 		if err != nil {
 			return "", http.StatusInternalServerError, errors.New("failed to save comment to database")
@@ -104,19 +195,47 @@ func (a *goBlog) createComment(bc *configBlog, target, comment, name, website, o
 			return "", http.StatusInternalServerError, errors.New("failed to save comment to database")
 		}
 		commentAddress := bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, commentID))
-		// Send webmention
-		_ = a.createWebmention(a.getFullAddress(commentAddress), a.getFullAddress(target))
+		if newStatus == commentStatusPending {
+			// Announce comment for moderation
+			go a.sendNotification(fmt.Sprintf("New comment from %s on %s awaits moderation: %s", name, a.getFullAddress(target), a.getFullAddress(commentAddress)))
+		} else {
+			// Send webmention
+			a.sendCommentWebmention(bc, int(commentID), parent, target)
+			// Notify subscribers
+			go a.notifyCommentSubscribers(target, name, email, comment, a.getFullAddress(commentAddress))
+		}
 		// Return comment path
 		return commentAddress, 0, nil
 	}
 	if err := a.db.updateComment(updateID, comment, name, website); err != nil {
 		return "", http.StatusInternalServerError, errors.New("failed to update comment in database")
 	}
-	commentAddress := bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, updateID))
-	// Send webmention
-	_ = a.createWebmention(a.getFullAddress(commentAddress), a.getFullAddress(target))
+	if updated, err := a.db.getComments(&commentsRequestConfig{id: updateID}); err == nil && len(updated) > 0 && updated[0].Status == commentStatusApproved {
+		// Send webmention
+		a.sendCommentWebmention(bc, updateID, parent, target)
+	}
 	// Return comment path
-	return commentAddress, 0, nil
+	return bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, updateID)), 0, nil
+}
+
+// sendCommentWebmention mentions the target of the comment, replies mention the parent comment instead
+func (a *goBlog) sendCommentWebmention(bc *configBlog, id, parent int, target string) {
+	_ = a.createWebmention(a.commentWebmentionSourceAndTarget(bc, id, parent, target))
+}
+
+// deleteCommentWebmention removes the comment from the mentions of the target
+func (a *goBlog) deleteCommentWebmention(bc *configBlog, id, parent int, target string) error {
+	source, mentionTarget := a.commentWebmentionSourceAndTarget(bc, id, parent, target)
+	return a.db.deleteWebmention(&mention{Source: source, Target: mentionTarget})
+}
+
+func (a *goBlog) commentWebmentionSourceAndTarget(bc *configBlog, id, parent int, target string) (string, string) {
+	mentionTarget := target
+	if parent != 0 {
+		mentionTarget = bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, parent))
+	}
+	commentAddress := bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, id))
+	return a.getFullAddress(commentAddress), a.getFullAddress(mentionTarget)
 }
 
 func (a *goBlog) checkCommentTarget(target string) (string, int, error) {
@@ -132,15 +251,42 @@ func (a *goBlog) checkCommentTarget(target string) (string, int, error) {
 	return targetURL.Path, 0, nil
 }
 
+// commentIDFromAddress returns the ID of the comment if the address is the full address of a comment of the blog
+func (a *goBlog) commentIDFromAddress(bc *configBlog, address string) (int, bool) {
+	if !a.isLocalURL(address) {
+		return 0, false
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return 0, false
+	}
+	return a.commentIDFromPath(bc, u.Path)
+}
+
+// commentIDFromPath returns the ID of the comment if the path is the address of a comment of the blog
+func (a *goBlog) commentIDFromPath(bc *configBlog, p string) (int, bool) {
+	idString, found := strings.CutPrefix(p, bc.getRelativePath(commentPath)+"/")
+	if !found {
+		return 0, false
+	}
+	id, err := strconv.Atoi(idString)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
 type commentsRequestConfig struct {
 	id, offset, limit int
 	target            string
+	parent            int
+	status            commentStatus
 }
 
 func buildCommentsQuery(config *commentsRequestConfig) (query string, args []any) {
 	queryBuilder := builderpool.Get()
 	defer builderpool.Put(queryBuilder)
-	queryBuilder.WriteString("select id, target, name, website, comment, original from comments where 1")
+	queryBuilder.WriteString("select id, target, name, website, email, comment, original, parent, status, created from comments where 1")
 	if config.id != 0 {
 		queryBuilder.WriteString(" and id = @id")
 		args = append(args, sql.Named("id", config.id))
@@ -149,6 +295,14 @@ func buildCommentsQuery(config *commentsRequestConfig) (query string, args []any
 		queryBuilder.WriteString(" and target = @target")
 		args = append(args, sql.Named("target", config.target))
 	}
+	if config.parent != 0 {
+		queryBuilder.WriteString(" and parent = @parent")
+		args = append(args, sql.Named("parent", config.parent))
+	}
+	if config.status != "" {
+		queryBuilder.WriteString(" and status = @status")
+		args = append(args, sql.Named("status", config.status))
+	}
 	queryBuilder.WriteString(" order by id desc")
 	if config.limit != 0 || config.offset != 0 {
 		queryBuilder.WriteString(" limit @limit offset @offset")
@@ -167,7 +321,7 @@ func (db *database) getComments(config *commentsRequestConfig) ([]*comment, erro
 	defer rows.Close()
 	for rows.Next() {
 		c := &comment{}
-		err = rows.Scan(&c.ID, &c.Target, &c.Name, &c.Website, &c.Comment, &c.Original)
+		err = rows.Scan(&c.ID, &c.Target, &c.Name, &c.Website, &c.email, &c.Comment, &c.Original, &c.Parent, &c.Status, &c.Created)
 		if err != nil {
 			return nil, err
 		}
@@ -198,6 +352,16 @@ func (db *database) updateComment(id int, comment, name, website string) error {
 	return err
 }
 
+func (db *database) approveComment(id int) error {
+	_, err := db.Exec("update comments set status = @status where id = @id", sql.Named("status", commentStatusApproved), sql.Named("id", id))
+	return err
+}
+
+func (db *database) markCommentAsSpam(id int) error {
+	_, err := db.Exec("update comments set status = @status where id = @id", sql.Named("status", commentStatusSpam), sql.Named("id", id))
+	return err
+}
+
 func (db *database) deleteComment(id int) error {
 	_, err := db.Exec("delete from comments where id = @id", sql.Named("id", id))
 	return err
@@ -223,6 +387,10 @@ func (a *goBlog) commentsEnabled(blog *configBlog) bool {
 	return cc != nil && cc.Enabled && !wmDisabled
 }
 
+func (a *goBlog) commentsModerationEnabled(blog *configBlog) bool {
+	return blog.Comments != nil && blog.Comments.Moderation
+}
+
 const commentsPostParam = "comments"
 
 func (a *goBlog) commentsEnabledForPost(post *post) bool {
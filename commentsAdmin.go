@@ -1,10 +1,14 @@
 package main
 
 import (
+	"cmp"
 	"fmt"
 	"net/http"
+	"net/url"
+	"path"
 	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi/v5"
@@ -37,8 +41,17 @@ func (p *commentsPaginationAdapter) Slice(offset, length int, data any) error {
 
 func (a *goBlog) commentsAdmin(w http.ResponseWriter, r *http.Request) {
 	commentsPath := r.Context().Value(pathKey).(string)
+	var status commentStatus
+	switch commentStatus(r.URL.Query().Get("status")) {
+	case commentStatusPending:
+		status = commentStatusPending
+	case commentStatusApproved:
+		status = commentStatusApproved
+	case commentStatusSpam:
+		status = commentStatusSpam
+	}
 	// Adapter
-	p := paginator.New(&commentsPaginationAdapter{config: &commentsRequestConfig{}, db: a.db}, 5)
+	p := paginator.New(&commentsPaginationAdapter{config: &commentsRequestConfig{status: status}, db: a.db}, 5)
 	p.SetPage(stringToInt(chi.URLParam(r, "page")))
 	var comments []*comment
 	err := p.Results(&comments)
@@ -48,8 +61,8 @@ func (a *goBlog) commentsAdmin(w http.ResponseWriter, r *http.Request) {
 	}
 	// Navigation
 	var hasPrev, hasNext bool
-	var prevPage, nextPage int
-	var prevPath, nextPath string
+	var prevPage, currentPage, nextPage int
+	var prevPath, currentPath, nextPath string
 	hasPrev, _ = p.HasPrev()
 	if hasPrev {
 		prevPage, _ = p.PrevPage()
@@ -61,6 +74,8 @@ func (a *goBlog) commentsAdmin(w http.ResponseWriter, r *http.Request) {
 	} else {
 		prevPath = fmt.Sprintf("%s/page/%d", commentsPath, prevPage)
 	}
+	currentPage, _ = p.Page()
+	currentPath = fmt.Sprintf("%s/page/%d", commentsPath, currentPage)
 	hasNext, _ = p.HasNext()
 	if hasNext {
 		nextPage, _ = p.NextPage()
@@ -68,14 +83,21 @@ func (a *goBlog) commentsAdmin(w http.ResponseWriter, r *http.Request) {
 		nextPage, _ = p.Page()
 	}
 	nextPath = fmt.Sprintf("%s/page/%d", commentsPath, nextPage)
+	// Query
+	query := ""
+	if status != "" {
+		query = "?" + url.Values{"status": []string{string(status)}}.Encode()
+	}
 	// Render
 	a.render(w, r, a.renderCommentsAdmin, &renderData{
 		Data: &commentsRenderData{
 			comments: comments,
 			hasPrev:  hasPrev,
 			hasNext:  hasNext,
-			prev:     prevPath,
-			next:     nextPath,
+			prev:     prevPath + query,
+			current:  currentPath + query,
+			next:     nextPath + query,
+			status:   status,
 		},
 	})
 }
@@ -94,5 +116,138 @@ func (a *goBlog) commentsAdminDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	a.purgeCache()
-	http.Redirect(w, r, ".", http.StatusFound)
+	a.commentsAdminRedirect(w, r)
+}
+
+// commentsAdminRedirect redirects back to the local path from the form or to the comments admin
+func (a *goBlog) commentsAdminRedirect(w http.ResponseWriter, r *http.Request) {
+	redir := r.FormValue("redir") //nolint:gosec
+	if !strings.HasPrefix(redir, "/") || strings.HasPrefix(redir, "//") {
+		_, bc := a.getBlog(r)
+		redir = bc.getRelativePath(commentPath)
+	}
+	http.Redirect(w, r, redir, http.StatusFound)
+}
+
+const commentApproveSubPath = "/approve"
+
+func (a *goBlog) commentsAdminApprove(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("commentid")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	comments, err := a.db.getComments(&commentsRequestConfig{id: id})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(comments) < 1 {
+		a.serve404(w, r)
+		return
+	}
+	c := comments[0]
+	if c.Status != commentStatusApproved {
+		if err = a.db.approveComment(id); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Train spam filter with the published comment, comments marked as spam before are moved to the legitimate ones
+		if err := a.trainSpam(c.Comment, c.Name, c.Website, false, c.Status == commentStatusSpam); err != nil {
+			a.error("Failed to train spam filter", "err", err)
+		}
+		// Send webmention now that the comment is public
+		_, bc := a.getBlog(r)
+		a.sendCommentWebmention(bc, c.ID, c.Parent, c.Target)
+		// Notify subscribers
+		go a.notifyCommentSubscribers(c.Target, c.Name, c.email, c.Comment, a.getFullAddress(bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, c.ID))))
+		a.purgeCache()
+	}
+	a.commentsAdminRedirect(w, r)
+}
+
+const commentSpamSubPath = "/spam"
+
+func (a *goBlog) commentsAdminSpam(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.FormValue("commentid")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	comments, err := a.db.getComments(&commentsRequestConfig{id: id})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(comments) < 1 {
+		a.serve404(w, r)
+		return
+	}
+	c := comments[0]
+	if c.Status != commentStatusSpam {
+		if err = a.db.markCommentAsSpam(id); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Train spam filter, only the spam and approve actions train it
+		if err := a.trainSpam(c.Comment, c.Name, c.Website, true, false); err != nil {
+			a.error("Failed to train spam filter", "err", err)
+		}
+		if c.Status == commentStatusApproved {
+			// Remove the published comment from the mentions
+			_, bc := a.getBlog(r)
+			if err := a.deleteCommentWebmention(bc, c.ID, c.Parent, c.Target); err != nil {
+				a.error("Failed to delete comment webmention", "err", err)
+			}
+			a.purgeCache()
+		}
+	}
+	a.commentsAdminRedirect(w, r)
+}
+
+const commentReplySubPath = "/reply"
+
+func (a *goBlog) commentsAdminReply(w http.ResponseWriter, r *http.Request) {
+	parentID, err := strconv.Atoi(r.FormValue("parent")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	blog, bc := a.getBlog(r)
+	parents, err := a.db.getComments(&commentsRequestConfig{id: parentID})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(parents) < 1 {
+		a.serve404(w, r)
+		return
+	}
+	parent := parents[0]
+	if parent.Status != commentStatusApproved {
+		// The reply would be public (webmention, ActivityPub) while the parent isn't
+		a.serveError(w, r, "parent comment is not approved", http.StatusBadRequest)
+		return
+	}
+	// Reply as the blog author
+	name, website := "", a.getFullAddress(bc.getRelativePath(""))
+	if user := a.cfg.User; user != nil {
+		name, website = user.Name, cmp.Or(user.Link, website)
+	}
+	target := a.getFullAddress(bc.getRelativePath(path.Join(commentPath, strconv.Itoa(parent.ID))))
+	result, errStatus, err := a.createComment(bc, target, r.FormValue("comment"), name, website, "", parent.ID, true) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), errStatus)
+		return
+	}
+	a.purgeCache()
+	// Federate the reply if the parent is from the fediverse
+	if parent.Original != "" && a.apEnabled() {
+		if id, ok := a.commentIDFromPath(bc, result); ok {
+			if replies, err := a.db.getComments(&commentsRequestConfig{id: id}); err == nil && len(replies) > 0 {
+				go a.apReplyToComment(blog, replies[0], parent)
+			}
+		}
+	}
+	http.Redirect(w, r, result, http.StatusFound)
 }
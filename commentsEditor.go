@@ -35,8 +35,10 @@ func (a *goBlog) serveCommentsEditor(w http.ResponseWriter, r *http.Request) {
 		}
 		a.purgeCache()
 		// Resend webmention
+		if comment.Status == commentStatusApproved {
+			a.sendCommentWebmention(bc, id, comment.Parent, comment.Target)
+		}
 		commentAddress := bc.getRelativePath(path.Join(commentPath, strconv.Itoa(id)))
-		_ = a.createWebmention(a.getFullAddress(commentAddress), a.getFullAddress(comment.Target))
 		// Redirect to comment
 		http.Redirect(w, r, commentAddress, http.StatusFound)
 		return
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// importedComment is a comment from the export of another comment system
+type importedComment struct {
+	id, parent string // IDs in the exported system, used to thread replies
+	thread     string // URL of the page the comment was made on
+	name       string
+	website    string
+	text       string // HTML or text, gets cleaned before saving
+	created    time.Time
+	pending    bool
+}
+
+// Disqus
+
+type disqusExport struct {
+	Threads []*disqusThread `xml:"thread"`
+	Posts   []*disqusPost   `xml:"post"`
+}
+
+type disqusThread struct {
+	ID   string `xml:"http://disqus.com/disqus-internals id,attr"`
+	Link string `xml:"link"`
+}
+
+type disqusPost struct {
+	ID        string `xml:"http://disqus.com/disqus-internals id,attr"`
+	Message   string `xml:"message"`
+	CreatedAt string `xml:"createdAt"`
+	IsDeleted bool   `xml:"isDeleted"`
+	IsSpam    bool   `xml:"isSpam"`
+	Author    struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Thread struct {
+		ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+	} `xml:"thread"`
+	Parent struct {
+		ID string `xml:"http://disqus.com/disqus-internals id,attr"`
+	} `xml:"parent"`
+}
+
+func parseDisqusComments(r io.Reader) ([]*importedComment, error) {
+	var export disqusExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	threads := map[string]string{}
+	for _, t := range export.Threads {
+		threads[t.ID] = t.Link
+	}
+	var comments []*importedComment
+	for _, p := range export.Posts {
+		if p.IsDeleted || p.IsSpam {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, p.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of disqus comment %s: %w", p.ID, err)
+		}
+		comments = append(comments, &importedComment{
+			id:      p.ID,
+			parent:  p.Parent.ID,
+			thread:  threads[p.Thread.ID],
+			name:    p.Author.Name,
+			text:    p.Message,
+			created: created,
+		})
+	}
+	return comments, nil
+}
+
+// WordPress (WXR)
+
+type wxrExport struct {
+	Channel struct {
+		BaseSiteURL string     `xml:"base_site_url"`
+		BaseBlogURL string     `xml:"base_blog_url"`
+		Items       []*wxrItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type wxrItem struct {
+	Title         string         `xml:"title"`
+	Link          string         `xml:"link"`
+	Content       string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	ID            string         `xml:"post_id"`
+	Date          string         `xml:"post_date"`
+	DateGMT       string         `xml:"post_date_gmt"`
+	ModifiedGMT   string         `xml:"post_modified_gmt"`
+	Name          string         `xml:"post_name"`
+	Status        string         `xml:"status"`
+	Type          string         `xml:"post_type"`
+	Password      string         `xml:"post_password"`
+	AttachmentURL string         `xml:"attachment_url"`
+	Categories    []*wxrCategory `xml:"category"`
+	Meta          []*wxrPostMeta `xml:"postmeta"`
+	Comments      []*wxrComment  `xml:"comment"`
+}
+
+type wxrCategory struct {
+	Domain   string `xml:"domain,attr"`
+	Nicename string `xml:"nicename,attr"`
+	Name     string `xml:",chardata"`
+}
+
+type wxrPostMeta struct {
+	Key   string `xml:"meta_key"`
+	Value string `xml:"meta_value"`
+}
+
+type wxrComment struct {
+	ID        string `xml:"comment_id"`
+	Author    string `xml:"comment_author"`
+	AuthorURL string `xml:"comment_author_url"`
+	DateGMT   string `xml:"comment_date_gmt"`
+	Content   string `xml:"comment_content"`
+	Approved  string `xml:"comment_approved"`
+	Type      string `xml:"comment_type"`
+	Parent    string `xml:"comment_parent"`
+}
+
+const wxrDateFormat = "2006-01-02 15:04:05"
+
+func parseWordPressComments(r io.Reader) ([]*importedComment, error) {
+	var export wxrExport
+	if err := xml.NewDecoder(r).Decode(&export); err != nil {
+		return nil, err
+	}
+	var comments []*importedComment
+	for _, item := range export.Channel.Items {
+		itemComments, err := wordPressItemComments(item)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, itemComments...)
+	}
+	return comments, nil
+}
+
+func wordPressItemComments(item *wxrItem) ([]*importedComment, error) {
+	var comments []*importedComment
+	for _, c := range item.Comments {
+		// Skip pingbacks, trackbacks, spam and trash
+		if (c.Type != "" && c.Type != "comment") || (c.Approved != "1" && c.Approved != "0") {
+			continue
+		}
+		created, err := time.Parse(wxrDateFormat, c.DateGMT)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date of wordpress comment %s: %w", c.ID, err)
+		}
+		parent := c.Parent
+		if parent == "0" {
+			parent = ""
+		}
+		comments = append(comments, &importedComment{
+			id:      c.ID,
+			parent:  parent,
+			thread:  item.Link,
+			name:    c.Author,
+			website: c.AuthorURL,
+			text:    c.Content,
+			created: created,
+			pending: c.Approved == "0",
+		})
+	}
+	return comments, nil
+}
+
+// Isso (SQLite)
+
+func parseIssoComments(file string) ([]*importedComment, error) {
+	if _, err := os.Stat(file); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite3", "file:"+file+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// Mode 1 is accepted, 2 is in moderation, 4 is deleted
+	rows, err := db.Query(`
+	select c.id, ifnull(c.parent, 0), t.uri, ifnull(c.author, ''), ifnull(c.website, ''), c.text, c.created, c.mode
+	from comments c join threads t on c.tid = t.id
+	where c.mode in (1, 2)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var comments []*importedComment
+	for rows.Next() {
+		var id, parent, mode int
+		var created float64
+		c := &importedComment{}
+		if err = rows.Scan(&id, &parent, &c.thread, &c.name, &c.website, &c.text, &created, &mode); err != nil {
+			return nil, err
+		}
+		c.id = fmt.Sprint(id)
+		if parent != 0 {
+			c.parent = fmt.Sprint(parent)
+		}
+		c.created = time.Unix(int64(created), 0)
+		c.pending = mode == 2
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// importCommentsFile parses the export file in the given format and saves the comments
+func (a *goBlog) importCommentsFile(format, file string) (imported, skipped int, err error) {
+	var comments []*importedComment
+	switch format {
+	case "disqus", "wordpress":
+		f, err := os.Open(file)
+		if err != nil {
+			return 0, 0, err
+		}
+		defer f.Close()
+		if format == "disqus" {
+			comments, err = parseDisqusComments(f)
+		} else {
+			comments, err = parseWordPressComments(f)
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	case "isso":
+		if comments, err = parseIssoComments(file); err != nil {
+			return 0, 0, err
+		}
+	default:
+		return 0, 0, fmt.Errorf("unknown format %q, use disqus, wordpress or isso", format)
+	}
+	return a.importComments(comments)
+}
+
+// importComments saves the comments, keeping their dates, authors and threading,
+// comments on pages that can't be mapped to a post and already imported comments are skipped
+func (a *goBlog) importComments(comments []*importedComment) (imported, skipped int, err error) {
+	// Save in chronological order, so the IDs are ascending like for new comments
+	slices.SortStableFunc(comments, func(x, y *importedComment) int {
+		return x.created.Compare(y.created)
+	})
+	type savedComment struct {
+		*importedComment
+		id     int
+		target string
+		bc     *configBlog
+		status commentStatus
+		text   string
+		name   string
+	}
+	ids := map[string]int{}
+	saved := []*savedComment{}
+	for _, c := range comments {
+		target, err := a.commentImportTarget(c.thread)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if target == "" {
+			a.info("Skip comment on unknown page", "id", c.id, "url", c.thread)
+			skipped++
+			continue
+		}
+		p, err := a.getPost(target)
+		if err != nil {
+			return imported, skipped, err
+		}
+		bc := a.getBlogFromPost(p)
+		text := cleanHTMLText(c.text)
+		if text == "" {
+			skipped++
+			continue
+		}
+		name := cmp.Or(cleanHTMLText(c.name), "Anonymous")
+		website := cleanHTMLText(c.website)
+		// Check if comment was already imported
+		id, err := a.db.importedCommentID(target, name, text, c.created.Unix())
+		if err != nil {
+			return imported, skipped, err
+		}
+		if id != 0 {
+			ids[c.id] = id
+			skipped++
+			continue
+		}
+		// Save comment, the parent is set after all comments are saved
+		status := commentStatusApproved
+		if c.pending {
+			status = commentStatusPending
+		}
+		result, err := a.db.Exec(
+			"insert into comments (target, comment, name, website, status, created) values (@target, @comment, @name, @website, @status, @created)",
+			sql.Named("target", target), sql.Named("comment", text), sql.Named("name", name), sql.Named("website", website),
+			sql.Named("status", status), sql.Named("created", c.created.Unix()),
+		)
+		if err != nil {
+			return imported, skipped, err
+		}
+		newID, err := result.LastInsertId()
+		if err != nil {
+			return imported, skipped, err
+		}
+		ids[c.id] = int(newID)
+		saved = append(saved, &savedComment{importedComment: c, id: int(newID), target: target, bc: bc, status: status, text: text, name: name})
+		imported++
+	}
+	// Resolve the parents by their old IDs, independent of the order in the export
+	for _, c := range saved {
+		parent := ids[c.parent]
+		if parent != 0 {
+			if _, err := a.db.Exec("update comments set parent = @parent where id = @id", sql.Named("parent", parent), sql.Named("id", c.id)); err != nil {
+				return imported, skipped, err
+			}
+		}
+		if c.status != commentStatusApproved {
+			continue
+		}
+		// Save the webmention directly instead of verifying thousands of them, so the comment shows up on the post
+		mentionTarget := c.target
+		if parent != 0 {
+			mentionTarget = c.bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, parent))
+		}
+		commentAddress := a.getFullAddress(c.bc.getRelativePath(fmt.Sprintf("%s/%d", commentPath, c.id)))
+		err = a.db.insertWebmention(&mention{
+			Source:  commentAddress,
+			Target:  a.getFullAddress(mentionTarget),
+			URL:     commentAddress,
+			Created: c.created.Unix(),
+			Content: c.text,
+			Author:  c.name,
+		}, webmentionStatusApproved)
+		if err != nil {
+			return imported, skipped, err
+		}
+	}
+	if imported > 0 {
+		a.purgeCache()
+	}
+	return imported, skipped, nil
+}
+
+// commentImportTarget maps the URL of a page on the old blog to the path of the post, also checking the post aliases
+func (a *goBlog) commentImportTarget(pageURL string) (string, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil || (u.Path == "" && u.RawQuery == "") {
+		return "", nil
+	}
+	var paths []string
+	if u.RawQuery != "" {
+		// Aliases of imported posts with links like /?p=123
+		paths = append(paths, cmp.Or(u.Path, "/")+"?"+u.RawQuery)
+	}
+	if u.Path != "" {
+		paths = append(paths, u.Path)
+	}
+	if trimmed := strings.TrimSuffix(u.Path, "/"); trimmed != u.Path && trimmed != "" {
+		paths = append(paths, trimmed)
+	}
+	for _, p := range paths {
+		row, err := a.db.QueryRow(`
+		select path from posts where path = @path
+		union all
+		select path from post_parameters where parameter = 'aliases' and value = @path
+		limit 1
+		`, sql.Named("path", p))
+		if err != nil {
+			return "", err
+		}
+		var target string
+		if err = row.Scan(&target); err == nil {
+			return target, nil
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func (db *database) importedCommentID(target, name, text string, created int64) (int, error) {
+	row, err := db.QueryRow(
+		"select id from comments where target = @target and name = @name and comment = @comment and created = @created",
+		sql.Named("target", target), sql.Named("name", name), sql.Named("comment", text), sql.Named("created", created),
+	)
+	if err != nil {
+		return 0, err
+	}
+	var id int
+	if err = row.Scan(&id); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	return id, nil
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDisqusExport = `<?xml version="1.0" encoding="utf-8"?>
+<disqus xmlns="http://disqus.com" xmlns:dsq="http://disqus.com/disqus-internals">
+  <thread dsq:id="100">
+    <link>https://old.example.com/post1/</link>
+    <title>Post 1</title>
+  </thread>
+  <thread dsq:id="101">
+    <link>https://old.example.com/unknown/</link>
+  </thread>
+  <post dsq:id="1">
+    <message><![CDATA[<p>First comment</p>]]></message>
+    <createdAt>2015-03-01T10:00:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name>Alice</name></author>
+    <thread dsq:id="100" />
+  </post>
+  <post dsq:id="2">
+    <message><![CDATA[<p>Reply to Alice</p>]]></message>
+    <createdAt>2015-03-02T10:00:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name>Bob</name></author>
+    <thread dsq:id="100" />
+    <parent dsq:id="1" />
+  </post>
+  <post dsq:id="3">
+    <message><![CDATA[Buy cheap stuff]]></message>
+    <createdAt>2015-03-03T10:00:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>true</isSpam>
+    <author><name>Spammer</name></author>
+    <thread dsq:id="100" />
+  </post>
+  <post dsq:id="4">
+    <message><![CDATA[Comment on unknown page]]></message>
+    <createdAt>2015-03-04T10:00:00Z</createdAt>
+    <isDeleted>false</isDeleted>
+    <isSpam>false</isSpam>
+    <author><name>Carol</name></author>
+    <thread dsq:id="101" />
+  </post>
+</disqus>`
+
+const testWordPressExport = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+  <item>
+    <title>Post 2</title>
+    <link>https://old.example.com/2016/01/post-2/</link>
+    <wp:comment>
+      <wp:comment_id>7</wp:comment_id>
+      <wp:comment_author><![CDATA[Dave]]></wp:comment_author>
+      <wp:comment_author_url>https://dave.example.net</wp:comment_author_url>
+      <wp:comment_date_gmt>2016-01-05 08:30:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Nice post!]]></wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved>
+      <wp:comment_type>comment</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+    <wp:comment>
+      <wp:comment_id>8</wp:comment_id>
+      <wp:comment_author><![CDATA[Other blog]]></wp:comment_author>
+      <wp:comment_date_gmt>2016-01-06 08:30:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Pingback]]></wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved>
+      <wp:comment_type>pingback</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+    <wp:comment>
+      <wp:comment_id>9</wp:comment_id>
+      <wp:comment_author><![CDATA[Eve]]></wp:comment_author>
+      <wp:comment_date_gmt>2016-01-07 08:30:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Not yet approved]]></wp:comment_content>
+      <wp:comment_approved>0</wp:comment_approved>
+      <wp:comment_type></wp:comment_type>
+      <wp:comment_parent>7</wp:comment_parent>
+    </wp:comment>
+  </item>
+</channel>
+</rss>`
+
+func Test_commentsImport(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	err := app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	err = app.createPost(&post{Path: "/post1", Content: "Post 1"})
+	require.NoError(t, err)
+	err = app.createPost(&post{Path: "/post2", Content: "Post 2", Parameters: map[string][]string{
+		"aliases": {"/2016/01/post-2"},
+	}})
+	require.NoError(t, err)
+
+	t.Run("Disqus", func(t *testing.T) {
+		parsed, err := parseDisqusComments(strings.NewReader(testDisqusExport))
+		require.NoError(t, err)
+		require.Len(t, parsed, 3)
+
+		imported, skipped, err := app.importComments(parsed)
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+		assert.Equal(t, 1, skipped)
+
+		comments, err := app.db.getComments(&commentsRequestConfig{target: "/post1"})
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		reply, first := comments[0], comments[1]
+		assert.Equal(t, "Alice", first.Name)
+		assert.Equal(t, "First comment", first.Comment)
+		assert.Equal(t, time.Date(2015, 3, 1, 10, 0, 0, 0, time.UTC).Unix(), first.Created)
+		assert.Equal(t, commentStatusApproved, first.Status)
+		assert.Equal(t, "Bob", reply.Name)
+		assert.Equal(t, first.ID, reply.Parent)
+
+		// Comments show up on the post, replies nested below their parent
+		mentions := app.getWebmentionsByAddress("https://example.com/post1")
+		require.Len(t, mentions, 1)
+		assert.Equal(t, "Alice", mentions[0].Author)
+		assert.Equal(t, first.Created, mentions[0].Created)
+		require.Len(t, mentions[0].Submentions, 1)
+		assert.Equal(t, "Bob", mentions[0].Submentions[0].Author)
+
+		// Importing again skips the existing comments
+		parsed, err = parseDisqusComments(strings.NewReader(testDisqusExport))
+		require.NoError(t, err)
+		imported, skipped, err = app.importComments(parsed)
+		require.NoError(t, err)
+		assert.Equal(t, 0, imported)
+		assert.Equal(t, 3, skipped)
+	})
+
+	t.Run("WordPress", func(t *testing.T) {
+		parsed, err := parseWordPressComments(strings.NewReader(testWordPressExport))
+		require.NoError(t, err)
+		require.Len(t, parsed, 2)
+
+		imported, _, err := app.importComments(parsed)
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+
+		// The alias maps the old URL to the post
+		comments, err := app.db.getComments(&commentsRequestConfig{target: "/post2"})
+		require.NoError(t, err)
+		require.Len(t, comments, 2)
+		pending, approved := comments[0], comments[1]
+		assert.Equal(t, "Dave", approved.Name)
+		assert.Equal(t, "https://dave.example.net", approved.Website)
+		assert.Equal(t, time.Date(2016, 1, 5, 8, 30, 0, 0, time.UTC).Unix(), approved.Created)
+		assert.Equal(t, commentStatusPending, pending.Status)
+		assert.Equal(t, approved.ID, pending.Parent)
+
+		// Only the approved comment is shown
+		assert.Len(t, app.getWebmentionsByAddress("https://example.com/post2"), 1)
+	})
+
+	t.Run("Isso", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "isso.db")
+		issoDb, err := sql.Open("sqlite3", file)
+		require.NoError(t, err)
+		_, err = issoDb.Exec(`
+		create table threads (id integer primary key, uri varchar(256) unique, title varchar(256));
+		create table comments (tid references threads(id), id integer primary key, parent integer, created float not null, modified float, mode integer, remote_addr varchar, text varchar, author varchar, email varchar, website varchar, likes integer default 0, dislikes integer default 0, voters blob not null, notification integer default 0);
+		insert into threads (id, uri, title) values (1, '/post1/', 'Post 1');
+		insert into comments (tid, id, parent, created, mode, text, author, website, voters) values (1, 1, null, 1500000000.5, 1, 'From Isso', 'Frank', null, '');
+		insert into comments (tid, id, parent, created, mode, text, author, website, voters) values (1, 2, null, 1500000100.5, 4, 'Deleted', 'Grace', null, '');
+		insert into comments (tid, id, parent, created, mode, text, author, website, voters) values (1, 3, 1, 1499999990.5, 1, 'Reply with wrong clock', 'Heidi', null, '');
+		`)
+		require.NoError(t, err)
+		require.NoError(t, issoDb.Close())
+
+		parsed, err := parseIssoComments(file)
+		require.NoError(t, err)
+		require.Len(t, parsed, 2)
+
+		imported, _, err := app.importComments(parsed)
+		require.NoError(t, err)
+		assert.Equal(t, 2, imported)
+
+		comments, err := app.db.getComments(&commentsRequestConfig{target: "/post1"})
+		require.NoError(t, err)
+		require.Len(t, comments, 4)
+		frank, heidi := comments[0], comments[1]
+		assert.Equal(t, "Frank", frank.Name)
+		assert.Equal(t, int64(1500000000), frank.Created)
+		// The reply is older than its parent, but still nested below it
+		assert.Equal(t, "Heidi", heidi.Name)
+		assert.Equal(t, frank.ID, heidi.Parent)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "export.json")
+		require.NoError(t, os.WriteFile(file, []byte("{}"), 0o600))
+		_, _, err := app.importCommentsFile("blogger", file)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	netmail "net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/wneessen/go-mail"
+)
+
+const (
+	commentSubscriptionSubPath     = "/subscription"
+	commentSubscriptionConfirm     = "/confirm"
+	commentSubscriptionUnsubscribe = "/unsubscribe"
+)
+
+type commentSubscription struct {
+	ID        int
+	Blog      string
+	Target    string
+	Email     string
+	Confirmed bool
+}
+
+func (a *goBlog) commentSubscriptionsEnabled(bc *configBlog) bool {
+	cc := bc.Contact
+	return bc.Comments != nil && bc.Comments.Subscriptions && cc != nil && cc.SMTPHost != "" && cc.EmailFrom != ""
+}
+
+// parseSubscriptionEmail checks the email address entered in the comment form
+func parseSubscriptionEmail(email string) (string, error) {
+	addr, err := netmail.ParseAddress(email)
+	if err != nil {
+		return "", errors.New("invalid email address")
+	}
+	return addr.Address, nil
+}
+
+// subscribeToComments saves an unconfirmed subscription and sends the email to confirm it (double opt-in)
+func (a *goBlog) subscribeToComments(blog, target, email string) error {
+	bc := a.cfg.Blogs[blog]
+	_, err := a.db.Exec(
+		"insert or ignore into comment_subscriptions (blog, target, email) values (@blog, @target, @email)",
+		sql.Named("blog", blog), sql.Named("target", target), sql.Named("email", email),
+	)
+	if err != nil {
+		return err
+	}
+	subs, err := a.db.getCommentSubscriptions(&commentSubscription{Target: target, Email: email})
+	if err != nil {
+		return err
+	}
+	if len(subs) < 1 || subs[0].Confirmed {
+		// Nothing to confirm
+		return nil
+	}
+	message := mail.NewMsg()
+	if err := message.To(email); err != nil {
+		return err
+	}
+	message.Subject(a.ts.GetTemplateStringVariant(bc.Lang, "commentsubscriptionconfirmsubject"))
+	message.SetBodyString(mail.TypeTextPlain, fmt.Sprintf(
+		a.ts.GetTemplateStringVariant(bc.Lang, "commentsubscriptionconfirmmail"),
+		a.getFullAddress(target), a.commentSubscriptionLink(subs[0], commentSubscriptionConfirm),
+	))
+	return a.sendEmail(bc.Contact, message)
+}
+
+// notifyCommentSubscribers sends an email about a new comment or reply on the target to all confirmed subscribers except the author
+func (a *goBlog) notifyCommentSubscribers(target, author, authorEmail, content, link string) {
+	subs, err := a.db.getCommentSubscriptions(&commentSubscription{Target: target, Confirmed: true})
+	if err != nil {
+		a.error("Failed to get comment subscriptions", "err", err)
+		return
+	}
+	for _, sub := range subs {
+		bc, ok := a.cfg.Blogs[sub.Blog]
+		if !ok || !a.commentSubscriptionsEnabled(bc) {
+			continue
+		}
+		if authorEmail != "" && strings.EqualFold(sub.Email, authorEmail) {
+			// Don't notify about the own comment
+			continue
+		}
+		unsubscribeLink := a.commentSubscriptionLink(sub, commentSubscriptionUnsubscribe)
+		message := mail.NewMsg()
+		if err := message.To(sub.Email); err != nil {
+			a.error("Failed to create comment notification", "err", err)
+			continue
+		}
+		message.Subject(fmt.Sprintf(a.ts.GetTemplateStringVariant(bc.Lang, "commentsubscriptionnotifysubject"), author))
+		message.SetGenHeader(mail.HeaderListUnsubscribe, "<"+unsubscribeLink+">")
+		message.SetGenHeader(mail.HeaderListUnsubscribePost, "List-Unsubscribe=One-Click")
+		message.SetBodyString(mail.TypeTextPlain, fmt.Sprintf(
+			a.ts.GetTemplateStringVariant(bc.Lang, "commentsubscriptionnotifymail"),
+			author, a.getFullAddress(target), html.UnescapeString(content), link, unsubscribeLink,
+		))
+		if err := a.sendEmail(bc.Contact, message); err != nil {
+			a.error("Failed to send comment notification", "err", err)
+		}
+	}
+}
+
+// notifyCommentSubscribersOfMention notifies about the approved webmention,
+// mentions from local comments are skipped as the subscribers got notified about the comment already
+func (a *goBlog) notifyCommentSubscribersOfMention(id int) {
+	mentions, err := a.getWebmentions(&webmentionsRequestConfig{id: id, limit: 1})
+	if err != nil || len(mentions) < 1 {
+		return
+	}
+	m := mentions[0]
+	targetURL, err := url.Parse(m.Target)
+	if err != nil {
+		return
+	}
+	target := targetURL.Path
+	bc := a.blogFromPath(target)
+	if bc == nil {
+		return
+	}
+	if _, isComment := a.commentIDFromAddress(bc, m.Source); isComment {
+		return
+	}
+	// Mentions of a comment are replies on the post of the comment
+	if id, isComment := a.commentIDFromPath(bc, target); isComment {
+		comments, err := a.db.getComments(&commentsRequestConfig{id: id})
+		if err != nil || len(comments) < 1 {
+			return
+		}
+		target = comments[0].Target
+	}
+	a.notifyCommentSubscribers(target, m.Author, "", m.Content, m.URL)
+}
+
+// blogFromPath returns the blog with the longest path matching the beginning of the path
+func (a *goBlog) blogFromPath(p string) *configBlog {
+	var result *configBlog
+	resultLen := -1
+	for _, bc := range a.cfg.Blogs {
+		blogPath := strings.TrimSuffix(bc.getRelativePath(""), "/")
+		if (p == blogPath || strings.HasPrefix(p, blogPath+"/")) && len(blogPath) > resultLen {
+			result, resultLen = bc, len(blogPath)
+		}
+	}
+	return result
+}
+
+func (a *goBlog) commentSubscriptionLink(sub *commentSubscription, action string) string {
+	bc := a.cfg.Blogs[sub.Blog]
+	values := url.Values{}
+	values.Set("id", strconv.Itoa(sub.ID))
+	values.Set("token", a.commentSubscriptionToken(sub, action))
+	return a.getFullAddress(bc.getRelativePath(commentPath+commentSubscriptionSubPath+action)) + "?" + values.Encode()
+}
+
+// commentSubscriptionToken signs the action for the subscription, so links can't be forged for other addresses
+func (a *goBlog) commentSubscriptionToken(sub *commentSubscription, action string) string {
+	key := a.commentSubscriptionKey()
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%s\n%d\n%s\n%s", action, sub.ID, sub.Target, sub.Email)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (a *goBlog) commentSubscriptionKey() []byte {
+	a.csLoad.Do(func() {
+		// Try to load key from database
+		keyBytes, err := a.db.retrievePersistentCache("commentsubscriptionkey")
+		if err != nil {
+			a.error("Failed to retrieve comment subscription key", "err", err)
+			return
+		}
+		if keyBytes == nil {
+			// Generate random key
+			keyBytes = []byte(randomString(64))
+			// Store key in database
+			err = a.db.cachePersistently("commentsubscriptionkey", keyBytes)
+			if err != nil {
+				a.error("Failed to cache comment subscription key", "err", err)
+				return
+			}
+		}
+		a.csKey = keyBytes
+	})
+	return a.csKey
+}
+
+// checkCommentSubscriptionRequest returns the subscription if the signed link from the email is valid
+func (a *goBlog) checkCommentSubscriptionRequest(w http.ResponseWriter, r *http.Request, action string) (*commentSubscription, bool) {
+	id, err := strconv.Atoi(r.FormValue("id")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, "id missing or wrong format", http.StatusBadRequest)
+		return nil, false
+	}
+	subs, err := a.db.getCommentSubscriptions(&commentSubscription{ID: id})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if len(subs) < 1 {
+		a.serve404(w, r)
+		return nil, false
+	}
+	expected := a.commentSubscriptionToken(subs[0], action)
+	if expected == "" || !hmac.Equal([]byte(expected), []byte(r.FormValue("token"))) { //nolint:gosec
+		a.serveError(w, r, "invalid token", http.StatusForbidden)
+		return nil, false
+	}
+	return subs[0], true
+}
+
+func (a *goBlog) serveCommentSubscriptionConfirm(w http.ResponseWriter, r *http.Request) {
+	sub, ok := a.checkCommentSubscriptionRequest(w, r, commentSubscriptionConfirm)
+	if !ok {
+		return
+	}
+	if _, err := a.db.Exec("update comment_subscriptions set confirmed = 1 where id = @id", sql.Named("id", sub.ID)); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderCommentSubscription, &renderData{
+		Data: "commentsubscriptionconfirmed",
+	})
+}
+
+func (a *goBlog) serveCommentSubscriptionUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	sub, ok := a.checkCommentSubscriptionRequest(w, r, commentSubscriptionUnsubscribe)
+	if !ok {
+		return
+	}
+	if _, err := a.db.Exec("delete from comment_subscriptions where id = @id", sql.Named("id", sub.ID)); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderCommentSubscription, &renderData{
+		Data: "commentunsubscribed",
+	})
+}
+
+// getCommentSubscriptions filters by the non-empty fields of the passed subscription
+func (db *database) getCommentSubscriptions(filter *commentSubscription) ([]*commentSubscription, error) {
+	query := "select id, blog, target, email, confirmed from comment_subscriptions where 1"
+	var args []any
+	if filter.ID != 0 {
+		query += " and id = @id"
+		args = append(args, sql.Named("id", filter.ID))
+	}
+	if filter.Target != "" {
+		query += " and target = @target"
+		args = append(args, sql.Named("target", filter.Target))
+	}
+	if filter.Email != "" {
+		query += " and email = @email"
+		args = append(args, sql.Named("email", filter.Email))
+	}
+	if filter.Confirmed {
+		query += " and confirmed = 1"
+	}
+	rows, err := db.Query(query+" order by id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var subs []*commentSubscription
+	for rows.Next() {
+		s := &commentSubscription{}
+		if err = rows.Scan(&s.ID, &s.Blog, &s.Target, &s.Email, &s.Confirmed); err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
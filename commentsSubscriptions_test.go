@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+	"go.goblog.app/app/pkgs/mocksmtp"
+)
+
+func Test_commentsSubscriptions(t *testing.T) {
+	// Start the SMTP server
+	port, rd, cancel, err := mocksmtp.StartMockSMTPServer()
+	require.NoError(t, err)
+	defer cancel()
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	err = app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	bc.Comments = &configComments{
+		Enabled:       true,
+		Subscriptions: true,
+	}
+	bc.Contact = &configContact{
+		SMTPPort:     port,
+		SMTPHost:     "127.0.0.1",
+		SMTPUser:     "user",
+		SMTPPassword: "pass",
+		EmailFrom:    "from@example.org",
+	}
+	require.True(t, app.commentSubscriptionsEnabled(bc))
+
+	mux := chi.NewMux()
+	mux.Use(middleware.WithValue(blogKey, app.cfg.DefaultBlog))
+	mux.Post(commentPath, app.createCommentFromRequest)
+	mux.Get(commentPath+commentSubscriptionSubPath+commentSubscriptionConfirm, app.serveCommentSubscriptionConfirm)
+	mux.Post(commentPath+commentSubscriptionSubPath+commentSubscriptionUnsubscribe, app.serveCommentSubscriptionUnsubscribe)
+
+	postComment := func(text, email string) *httptest.ResponseRecorder {
+		data := url.Values{}
+		data.Add("target", "https://example.com/abc")
+		data.Add("comment", text)
+		data.Add("email", email)
+		req := httptest.NewRequest(http.MethodPost, commentPath, strings.NewReader(data.Encode()))
+		req.Header.Add(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	decodedMail := func(i int) string {
+		decoded, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader(rd.Datas[i])))
+		require.NoError(t, err)
+		return string(decoded)
+	}
+
+	// Invalid email addresses are rejected
+
+	rec := postComment("Invalid", "no email")
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Comment with subscription sends a confirmation email
+
+	rec = postComment("Notify me", "reader@example.net")
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	time.Sleep(500 * time.Millisecond)
+
+	subs, err := app.db.getCommentSubscriptions(&commentSubscription{Target: "/abc"})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Equal(t, "reader@example.net", subs[0].Email)
+	assert.False(t, subs[0].Confirmed)
+
+	confirmLink := app.commentSubscriptionLink(subs[0], commentSubscriptionConfirm)
+	assert.Contains(t, rd.Rcpts, "reader@example.net")
+	if assert.Len(t, rd.Datas, 1) {
+		assert.Contains(t, decodedMail(0), confirmLink)
+	}
+
+	// Unconfirmed subscriptions don't get notified
+
+	_, _, err = app.createComment(bc, "https://example.com/abc", "Not sent", "Admin", "", "", 0, true)
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+	assert.Len(t, rd.Datas, 1)
+
+	// Links with a wrong token are rejected
+
+	req := httptest.NewRequest(http.MethodGet, strings.Replace(confirmLink, "token=", "token=wrong", 1), nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// Confirm subscription
+
+	req = httptest.NewRequest(http.MethodGet, confirmLink, nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "You will be notified about new comments by email")
+
+	subs, err = app.db.getCommentSubscriptions(&commentSubscription{Target: "/abc", Confirmed: true})
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+
+	// Subscribers don't get notified about their own comments
+
+	rec = postComment("My own comment", "reader@example.net")
+	assert.Equal(t, http.StatusFound, rec.Code)
+
+	time.Sleep(500 * time.Millisecond)
+	assert.Len(t, rd.Datas, 1)
+
+	// New comments are sent to confirmed subscribers
+
+	_, _, err = app.createComment(bc, "https://example.com/abc", "This is a reply & \"quoted\"", "Admin", "", "", 0, true)
+	require.NoError(t, err)
+
+	time.Sleep(500 * time.Millisecond)
+
+	unsubscribeLink := app.commentSubscriptionLink(subs[0], commentSubscriptionUnsubscribe)
+	if assert.Len(t, rd.Datas, 2) {
+		mail := decodedMail(1)
+		// Plain text without HTML escaping
+		assert.Contains(t, mail, "This is a reply & \"quoted\"")
+		assert.Contains(t, mail, "New comment by Admin")
+		assert.Contains(t, mail, "List-Unsubscribe")
+		assert.Contains(t, mail, unsubscribeLink)
+	}
+
+	// Unsubscribe
+
+	req = httptest.NewRequest(http.MethodPost, unsubscribeLink, strings.NewReader("List-Unsubscribe=One-Click"))
+	req.Header.Add(contentType, contenttype.WWWForm)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	subs, err = app.db.getCommentSubscriptions(&commentSubscription{Target: "/abc"})
+	require.NoError(t, err)
+	assert.Len(t, subs, 0)
+}
@@ -211,7 +211,7 @@ func Test_commentsUpdateByOriginal(t *testing.T) {
 
 	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
 
-	addr, _, err := app.createComment(bc, "https://example.com/abc", "Test", "Name", "https://example.org", "https://example.org/1")
+	addr, _, err := app.createComment(bc, "https://example.com/abc", "Test", "Name", "https://example.org", "https://example.org/1", 0, false)
 	require.NoError(t, err)
 
 	splittedAddr := strings.Split(addr, "/")
@@ -229,7 +229,7 @@ func Test_commentsUpdateByOriginal(t *testing.T) {
 	assert.Equal(t, "https://example.org", comment.Website)
 	assert.Equal(t, "https://example.org/1", comment.Original)
 
-	_, _, err = app.createComment(bc, "https://example.com/abc", "Edited comment", "Edited name", "", "https://example.org/1")
+	_, _, err = app.createComment(bc, "https://example.com/abc", "Edited comment", "Edited name", "", "https://example.org/1", 0, false)
 	require.NoError(t, err)
 
 	comments, err = app.db.getComments(&commentsRequestConfig{id: id})
@@ -244,3 +244,209 @@ func Test_commentsUpdateByOriginal(t *testing.T) {
 	assert.Equal(t, "", comment.Website)
 
 }
+
+func Test_commentsReplies(t *testing.T) {
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.User.Name = "Blog Author"
+
+	err := app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+
+	parentAddr, _, err := app.createComment(bc, "https://example.com/abc", "Parent", "Name", "", "", 0, false)
+	require.NoError(t, err)
+	parentID, ok := app.commentIDFromPath(bc, parentAddr)
+	require.True(t, ok)
+
+	t.Run("Reply with parent", func(t *testing.T) {
+		addr, _, err := app.createComment(bc, "https://example.com/abc", "Reply", "Other", "", "", parentID, false)
+		require.NoError(t, err)
+		id, ok := app.commentIDFromPath(bc, addr)
+		require.True(t, ok)
+
+		comments, err := app.db.getComments(&commentsRequestConfig{id: id})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "/abc", comments[0].Target)
+		assert.Equal(t, parentID, comments[0].Parent)
+	})
+
+	t.Run("Reply to comment address", func(t *testing.T) {
+		addr, _, err := app.createComment(bc, app.getFullAddress(parentAddr), "Reply", "Other", "", "https://example.org/2", 0, false)
+		require.NoError(t, err)
+		id, ok := app.commentIDFromPath(bc, addr)
+		require.True(t, ok)
+
+		comments, err := app.db.getComments(&commentsRequestConfig{id: id})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "/abc", comments[0].Target)
+		assert.Equal(t, parentID, comments[0].Parent)
+	})
+
+	t.Run("Unknown parent", func(t *testing.T) {
+		_, status, err := app.createComment(bc, "https://example.com/abc", "Reply", "Other", "", "", 1000, false)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Parent of other target", func(t *testing.T) {
+		_, status, err := app.createComment(bc, "https://example.com/def", "Reply", "Other", "", "", parentID, false)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, status)
+	})
+
+	t.Run("Parent not approved", func(t *testing.T) {
+		require.NoError(t, app.db.markCommentAsSpam(parentID))
+		_, status, err := app.createComment(bc, "https://example.com/abc", "Reply", "Other", "", "", parentID, false)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, status)
+		require.NoError(t, app.db.approveComment(parentID))
+	})
+
+	t.Run("Admin reply", func(t *testing.T) {
+		data := url.Values{}
+		data.Add("parent", cast.ToString(parentID))
+		data.Add("comment", "Thanks!")
+
+		req := httptest.NewRequest(http.MethodPost, commentPath+commentReplySubPath, strings.NewReader(data.Encode()))
+		req.Header.Add(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+
+		app.commentsAdminReply(rec, req.WithContext(context.WithValue(req.Context(), blogKey, app.cfg.DefaultBlog)))
+
+		res := rec.Result()
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		id, ok := app.commentIDFromPath(bc, res.Header.Get("Location"))
+		require.True(t, ok)
+		_ = res.Body.Close()
+
+		comments, err := app.db.getComments(&commentsRequestConfig{id: id})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "Thanks!", comments[0].Comment)
+		assert.Equal(t, "Blog Author", comments[0].Name)
+		assert.Equal(t, "https://example.com", comments[0].Website)
+		assert.Equal(t, parentID, comments[0].Parent)
+
+		count, err := app.db.countComments(&commentsRequestConfig{parent: parentID})
+		require.NoError(t, err)
+		assert.Equal(t, 3, count)
+	})
+
+}
+
+func Test_commentsModeration(t *testing.T) {
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	err := app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	bc.Comments = &configComments{
+		Enabled:    true,
+		Moderation: true,
+	}
+
+	// Create pending comment
+
+	data := url.Values{}
+	data.Add("target", "https://example.com/abc")
+	data.Add("comment", "Please moderate me")
+
+	req := httptest.NewRequest(http.MethodPost, commentPath, strings.NewReader(data.Encode()))
+	req.Header.Add(contentType, contenttype.WWWForm)
+	rec := httptest.NewRecorder()
+
+	app.createCommentFromRequest(rec, req.WithContext(context.WithValue(req.Context(), blogKey, app.cfg.DefaultBlog)))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Your comment awaits moderation")
+
+	pending, err := app.db.getComments(&commentsRequestConfig{status: commentStatusPending})
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	id := pending[0].ID
+
+	// Pending comment is hidden from the public
+
+	mux := chi.NewMux()
+	mux.Use(middleware.WithValue(blogKey, app.cfg.DefaultBlog))
+	mux.Get("/comment/{id}", app.serveComment)
+
+	req = httptest.NewRequest(http.MethodGet, "/comment/"+cast.ToString(id), nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	cc, err := app.CountComments(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0, cc)
+
+	// Trusted comments skip the moderation
+
+	addr, _, err := app.createComment(bc, "https://example.com/abc", "Trusted", "Admin", "", "", 0, true)
+	require.NoError(t, err)
+	trustedID, ok := app.commentIDFromPath(bc, addr)
+	require.True(t, ok)
+	trusted, err := app.db.getComments(&commentsRequestConfig{id: trustedID})
+	require.NoError(t, err)
+	require.Len(t, trusted, 1)
+	assert.Equal(t, commentStatusApproved, trusted[0].Status)
+
+	// Replies to pending comments are rejected
+
+	data = url.Values{}
+	data.Add("parent", cast.ToString(id))
+	data.Add("comment", "Thanks!")
+
+	req = httptest.NewRequest(http.MethodPost, commentPath+commentReplySubPath, strings.NewReader(data.Encode()))
+	req.Header.Add(contentType, contenttype.WWWForm)
+	rec = httptest.NewRecorder()
+
+	app.commentsAdminReply(rec, req.WithContext(context.WithValue(req.Context(), blogKey, app.cfg.DefaultBlog)))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Approve comment
+
+	data = url.Values{}
+	data.Add("commentid", cast.ToString(id))
+	data.Add("redir", "//example.net/")
+
+	req = httptest.NewRequest(http.MethodPost, commentPath+commentApproveSubPath, strings.NewReader(data.Encode()))
+	req.Header.Add(contentType, contenttype.WWWForm)
+	rec = httptest.NewRecorder()
+
+	app.commentsAdminApprove(rec, req.WithContext(context.WithValue(req.Context(), blogKey, app.cfg.DefaultBlog)))
+
+	assert.Equal(t, http.StatusFound, rec.Code)
+	// No redirect to other sites
+	assert.Equal(t, "/comment", rec.Header().Get("Location"))
+
+	approved, err := app.db.getComments(&commentsRequestConfig{id: id})
+	require.NoError(t, err)
+	require.Len(t, approved, 1)
+	assert.Equal(t, commentStatusApproved, approved[0].Status)
+
+	req = httptest.NewRequest(http.MethodGet, "/comment/"+cast.ToString(id), nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	cc, err = app.CountComments(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cc)
+
+}
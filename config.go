@@ -38,6 +38,8 @@ type config struct {
 	Reactions         *configReactions         `mapstructure:"reactions"`
 	Pprof             *configPprof             `mapstructure:"pprof"`
 	RobotsTxt         *configRobotsTxt         `mapstructure:"robotstxt"`
+	Spam              *configSpam              `mapstructure:"spam"`
+	Revisions         *configRevisions         `mapstructure:"revisions"`
 	Debug             bool                     `mapstructure:"debug"`
 	initialized       bool
 }
@@ -92,6 +94,11 @@ type configBlog struct {
 	Taxonomies     []*configTaxonomy         `mapstructure:"taxonomies"`
 	Menus          map[string]*configMenu    `mapstructure:"menus"`
 	Photos         *configPhotos             `mapstructure:"photos"`
+	Series         *configSeries             `mapstructure:"series"`
+	Events         *configEvents             `mapstructure:"events"`
+	MediaLogs      *configMediaLogs          `mapstructure:"mediaLogs"`
+	RelatedPosts   *configRelatedPosts       `mapstructure:"relatedPosts"`
+	PostExpiry     *configPostExpiry         `mapstructure:"postExpiry"`
 	Search         *configSearch             `mapstructure:"search"`
 	BlogStats      *configBlogStats          `mapstructure:"blogStats"`
 	Blogroll       *configBlogroll           `mapstructure:"blogroll"`
@@ -129,6 +136,7 @@ type configSection struct {
 	PathTemplate string `mapstructure:"pathtemplate"`
 	ShowFull     bool   `mapstructure:"showFull"`
 	HideOnStart  bool   `mapstructure:"hideOnStart"`
+	TOC          bool   `mapstructure:"toc"`
 	Name         string
 }
 
@@ -152,6 +160,43 @@ type configPhotos struct {
 	Path        string `mapstructure:"path"`
 	Title       string `mapstructure:"title"`
 	Description string `mapstructure:"description"`
+	Grid        bool   `mapstructure:"grid"`
+}
+
+type configSeries struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Path        string `mapstructure:"path"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+}
+
+type configEvents struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Path        string `mapstructure:"path"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+}
+
+type configMediaLogs struct {
+	Enabled bool            `mapstructure:"enabled"`
+	Read    *configMediaLog `mapstructure:"read"`
+	Watch   *configMediaLog `mapstructure:"watch"`
+	Listen  *configMediaLog `mapstructure:"listen"`
+}
+
+type configMediaLog struct {
+	Path        string `mapstructure:"path"`
+	Title       string `mapstructure:"title"`
+	Description string `mapstructure:"description"`
+}
+
+type configRelatedPosts struct {
+	Enabled bool `mapstructure:"enabled"`
+	Count   int  `mapstructure:"count"`
+}
+
+type configPostExpiry struct {
+	Action string `mapstructure:"action"`
 }
 
 type configSearch struct {
@@ -191,7 +236,9 @@ type configOnThisDay struct {
 }
 
 type configComments struct {
-	Enabled bool `mapstructure:"enabled"`
+	Enabled       bool `mapstructure:"enabled"`
+	Moderation    bool `mapstructure:"moderation"`
+	Subscriptions bool `mapstructure:"subscriptions"`
 }
 
 type configGeoMap struct {
@@ -261,6 +308,9 @@ type configMicropub struct {
 	PhotoParam            string               `mapstructure:"photoParam"`
 	PhotoDescriptionParam string               `mapstructure:"photoDescriptionParam"`
 	LocationParam         string               `mapstructure:"locationParam"`
+	RsvpParam             string               `mapstructure:"rsvpParam"`
+	CheckinParam          string               `mapstructure:"checkinParam"`
+	CheckinURLParam       string               `mapstructure:"checkinURLParam"`
 	MediaStorage          *configMicropubMedia `mapstructure:"mediaStorage"`
 }
 
@@ -274,6 +324,9 @@ type configMicropubMedia struct {
 	FTPAddress  string `mapstructure:"ftpAddress"`
 	FTPUser     string `mapstructure:"ftpUser"`
 	FTPPassword string `mapstructure:"ftpPassword"`
+	// Metadata
+	KeepMetadata  bool     `mapstructure:"keepMetadata"`
+	StripExifTags []string `mapstructure:"stripExifTags"`
 }
 
 type configRegexRedirect struct {
@@ -374,6 +427,20 @@ type configRobotsTxt struct {
 	BlockedBots []string `mapstructure:"blockedBots"`
 }
 
+type configSpam struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	MaxLinks      int      `mapstructure:"maxLinks"`
+	Words         []string `mapstructure:"words"`
+	Domains       []string `mapstructure:"domains"`
+	ModerateScore float64  `mapstructure:"moderateScore"`
+	DropScore     float64  `mapstructure:"dropScore"`
+}
+
+type configRevisions struct {
+	Keep   int `mapstructure:"keep"`
+	MaxAge int `mapstructure:"maxAge"`
+}
+
 type configAtproto struct {
 	Enabled        bool     `mapstructure:"enabled"`
 	Pds            string   `mapstructure:"pds"`
@@ -677,6 +744,9 @@ func createDefaultConfig() *config {
 			PhotoParam:            "images",
 			PhotoDescriptionParam: "imagealts",
 			LocationParam:         "location",
+			RsvpParam:             "rsvp",
+			CheckinParam:          "checkin",
+			CheckinURLParam:       "checkinurl",
 		},
 		ActivityPub: &configActivityPub{
 			TagsTaxonomies: []string{"tags"},
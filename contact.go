@@ -35,17 +35,29 @@ func (a *goBlog) sendContactSubmission(w http.ResponseWriter, r *http.Request) {
 		a.serveError(w, r, "Message is empty", http.StatusBadRequest)
 		return
 	}
+	formName := cleanHTMLText(r.FormValue("name"))       //nolint:gosec
+	formEmail := cleanHTMLText(r.FormValue("email"))     //nolint:gosec
+	formWebsite := cleanHTMLText(r.FormValue("website")) //nolint:gosec
+	// Check for spam
+	switch verdict, score := a.checkSpam(formMessage, formName, formWebsite); verdict {
+	case spamVerdictDrop:
+		// Drop silently
+		a.info("Dropped contact submission as spam", "score", score)
+		a.render(w, r, a.renderContactSent, &renderData{})
+		return
+	case spamVerdictModerate:
+		_, _ = fmt.Fprintf(message, "Possible spam (score %.1f)\n\n", score)
+	}
 	// Name
-	if formName := cleanHTMLText(r.FormValue("name")); formName != "" { //nolint:gosec
+	if formName != "" {
 		_, _ = fmt.Fprintf(message, "Name: %s\n", formName)
 	}
 	// Email
-	formEmail := cleanHTMLText(r.FormValue("email")) //nolint:gosec
 	if formEmail != "" {
 		_, _ = fmt.Fprintf(message, "Email: %s\n", formEmail)
 	}
 	// Website
-	if formWebsite := cleanHTMLText(r.FormValue("website")); formWebsite != "" { //nolint:gosec
+	if formWebsite != "" {
 		_, _ = fmt.Fprintf(message, "Website: %s\n", formWebsite)
 	}
 	// Add line break if message is not empty
@@ -54,28 +66,26 @@ func (a *goBlog) sendContactSubmission(w http.ResponseWriter, r *http.Request) {
 	}
 	// Add message text to message
 	_, _ = message.WriteString(formMessage)
+	messageText := message.String()
 	// Send submission
 	go func() {
-		if err := a.sendContactEmail(bc.Contact, message.String(), formEmail); err != nil {
+		if err := a.sendContactEmail(bc.Contact, messageText, formEmail); err != nil {
 			a.error("Failed to send contact email", "err", err)
 		}
 	}()
 	// Send notification
-	go a.sendNotification(message.String())
+	go a.sendNotification(messageText)
 	// Give feedback
 	a.render(w, r, a.renderContactSent, &renderData{})
 }
 
-func (*goBlog) sendContactEmail(cc *configContact, body, replyTo string) error {
+func (a *goBlog) sendContactEmail(cc *configContact, body, replyTo string) error {
 	// Check required config
-	if cc == nil || cc.SMTPHost == "" || cc.EmailFrom == "" || cc.EmailTo == "" {
+	if cc == nil || cc.EmailTo == "" {
 		return fmt.Errorf("email not send as config is missing")
 	}
 	// Create mail
 	message := mail.NewMsg()
-	if err := message.From(cc.EmailFrom); err != nil {
-		return err
-	}
 	if err := message.To(cc.EmailTo); err != nil {
 		return err
 	}
@@ -84,13 +94,25 @@ func (*goBlog) sendContactEmail(cc *configContact, body, replyTo string) error {
 			return err
 		}
 	}
-	message.SetDate()
 	subject := cc.EmailSubject
 	if subject == "" {
 		subject = "New contact message"
 	}
 	message.Subject(subject)
 	message.SetBodyString(mail.TypeTextPlain, body)
+	return a.sendEmail(cc, message)
+}
+
+// sendEmail delivers the message from the configured sender using the SMTP settings of the contact config
+func (*goBlog) sendEmail(cc *configContact, message *mail.Msg) error {
+	// Check required config
+	if cc == nil || cc.SMTPHost == "" || cc.EmailFrom == "" {
+		return fmt.Errorf("email not send as config is missing")
+	}
+	if err := message.From(cc.EmailFrom); err != nil {
+		return err
+	}
+	message.SetDate()
 	// Deliver the mail via SMTP
 	port := 587
 	if cc.SMTPPort != 0 {
@@ -78,6 +78,7 @@ func (a *goBlog) openDatabase(file string, logging, dump bool) (*database, error
 				"mdtext":         a.renderTextSafe,
 				"tolocal":        toLocalSafe,
 				"toutc":          toUTCSafe,
+				"eventendutc":    eventEndUTC,
 				"wordcount":      wordCount,
 				"charcount":      charCount,
 				"urlize":         urlize,
@@ -0,0 +1,2 @@
+alter table comments add parent integer not null default 0;
+create index index_comments_parent on comments (parent);
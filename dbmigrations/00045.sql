@@ -0,0 +1,5 @@
+create table spam_tokens (
+    token text not null primary key,
+    spam integer not null default 0,
+    ham integer not null default 0
+);
@@ -0,0 +1,10 @@
+create table comment_subscriptions (
+    id integer primary key autoincrement,
+    blog text not null,
+    target text not null,
+    email text not null,
+    confirmed integer not null default 0,
+    created integer not null default (strftime('%s', 'now')),
+    unique (target, email)
+);
+alter table comments add email text not null default '';
@@ -0,0 +1 @@
+alter table comments add created integer not null default 0;
@@ -0,0 +1,11 @@
+create table post_revisions (
+    id integer primary key autoincrement,
+    path text not null,
+    content text not null default '',
+    parameters text not null default '',
+    status text not null default '',
+    visibility text not null default '',
+    created integer not null default (strftime('%s', 'now')),
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_post_revisions_path on post_revisions (path, id);
@@ -0,0 +1,5 @@
+create table post_related (
+    path text primary key,
+    related text not null default '',
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
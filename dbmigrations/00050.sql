@@ -0,0 +1,7 @@
+create table post_links (
+    source text not null,
+    target text not null,
+    primary key (source, target),
+    foreign key (source) references posts(path) on update cascade on delete cascade
+);
+create index index_post_links_target on post_links (target);
@@ -0,0 +1 @@
+alter table sections add toc boolean not null default false;
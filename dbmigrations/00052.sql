@@ -0,0 +1,10 @@
+create table scheduled_edits (
+    id integer primary key autoincrement,
+    path text not null,
+    content text not null default '',
+    noupdated boolean not null default false,
+    apply_at integer not null,
+    created integer not null default (strftime('%s', 'now')),
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_scheduled_edits_apply_at on scheduled_edits (apply_at);
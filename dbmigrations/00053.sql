@@ -0,0 +1,8 @@
+create table preview_links (
+    id integer primary key autoincrement,
+    path text not null,
+    expires integer not null,
+    created integer not null default (strftime('%s', 'now')),
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
+create index index_preview_links_path on preview_links (path, id);
@@ -0,0 +1,7 @@
+create table taxonomy_redirects (
+    blog text not null,
+    taxonomy text not null,
+    fromvalue text not null,
+    tovalue text not null,
+    primary key (blog, taxonomy, fromvalue)
+);
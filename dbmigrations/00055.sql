@@ -0,0 +1,4 @@
+create table media_exif (
+    hash text primary key,
+    exif text not null
+);
@@ -0,0 +1 @@
+alter table scheduled_edits add error text not null default '';
@@ -0,0 +1,5 @@
+create table post_passwords (
+    path text primary key,
+    hash text not null,
+    foreign key (path) references posts(path) on update cascade on delete cascade
+);
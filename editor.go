@@ -10,7 +10,9 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/araddon/dateparse"
 	"github.com/carlmjohnson/requests"
 	"go.goblog.app/app/pkgs/bodylimit"
 	"go.goblog.app/app/pkgs/bufferpool"
@@ -48,6 +50,17 @@ func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 	case "createpost", "updatepost":
 		reqBody := map[string]any{}
 		if action == "updatepost" {
+			if applyAt := r.FormValue("applyat"); applyAt != "" { //nolint:gosec
+				applyAtTime, err := dateparse.ParseLocal(applyAt)
+				if err != nil {
+					a.serveError(w, r, err.Error(), http.StatusBadRequest)
+					return
+				}
+				if applyAtTime.After(time.Now()) {
+					a.editorScheduleEdit(w, r, applyAtTime)
+					return
+				}
+			}
 			reqBody["action"] = micropub.ActionUpdate
 			reqBody["url"] = r.FormValue("url") //nolint:gosec
 			reqBody["replace"] = map[string][]string{
@@ -292,6 +305,8 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		"original",
 		a.cfg.Micropub.AudioParam,
 		a.cfg.Micropub.BookmarkParam,
+		a.cfg.Micropub.CheckinParam,
+		a.cfg.Micropub.CheckinURLParam,
 		a.cfg.Micropub.LikeParam,
 		a.cfg.Micropub.LikeTitleParam,
 		a.cfg.Micropub.LikeContextParam,
@@ -301,6 +316,7 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		a.cfg.Micropub.ReplyParam,
 		a.cfg.Micropub.ReplyTitleParam,
 		a.cfg.Micropub.ReplyContextParam,
+		a.cfg.Micropub.RsvpParam,
 		gpxParameter,
 	} {
 		if param == "" {
@@ -324,7 +340,7 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		statusBuilder.WriteByte('`')
 	}
 	for i, visibility := range []postVisibility{
-		visibilityPublic, visibilityUnlisted, visibilityPrivate,
+		visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected,
 	} {
 		if i > 0 {
 			visibilityBuilder.WriteString(", ")
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	gogeouri "git.jlel.se/jlelse/go-geouri"
+	"go.goblog.app/app/pkgs/builderpool"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+const (
+	defaultEventsPath = "/events"
+	eventsICSSuffix   = ".ics"
+
+	eventStartParam = "start"
+	eventEndParam   = "end"
+
+	eventDateTimeFormat = "2006-01-02 15:04"
+	icsDateFormat       = "20060102"
+	icsDateTimeFormat   = "20060102T150405Z"
+)
+
+func (bc *configBlog) eventsEnabled() bool {
+	return bc.Events != nil && bc.Events.Enabled
+}
+
+func (bc *configBlog) eventsPath() string {
+	return bc.getRelativePath(cmp.Or(bc.Events.Path, defaultEventsPath))
+}
+
+func (a *goBlog) eventsTitle(bc *configBlog) string {
+	return cmp.Or(bc.Events.Title, a.ts.GetTemplateStringVariant(bc.Lang, "upcomingevents"))
+}
+
+// isEvent returns true if the post has a valid start date
+func (a *goBlog) isEvent(p *post) bool {
+	return !a.eventStart(p).IsZero()
+}
+
+func (a *goBlog) eventStart(p *post) time.Time {
+	return toLocalTime(p.firstParameter(eventStartParam))
+}
+
+func (a *goBlog) eventEnd(p *post) time.Time {
+	return toLocalTime(p.firstParameter(eventEndParam))
+}
+
+// eventLocation returns the location of the event if it's not a geo URI (those are rendered like the locations of other posts)
+func (a *goBlog) eventLocation(p *post) string {
+	location := strings.TrimSpace(p.firstParameter(a.cfg.Micropub.LocationParam))
+	if g, _ := gogeouri.Parse(location); g != nil {
+		return ""
+	}
+	return location
+}
+
+// isDateOnly checks if the date parameter has no time (for all-day events)
+func isDateOnly(date string) bool {
+	_, err := time.Parse(isoDateFormat, strings.TrimSpace(date))
+	return err == nil
+}
+
+// eventEndUTC returns the date parameter in UTC, dates without time (all-day events) end with the end of the day
+func eventEndUTC(date string) string {
+	if isDateOnly(date) {
+		if t := toLocalTime(date); !t.IsZero() {
+			return t.AddDate(0, 0, 1).UTC().Format(time.RFC3339)
+		}
+	}
+	return toUTCSafe(date)
+}
+
+func formatEventTime(date string, t time.Time) string {
+	if isDateOnly(date) {
+		return t.Format(isoDateFormat)
+	}
+	return t.Format(eventDateTimeFormat)
+}
+
+func (a *goBlog) serveEventsICS(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	status, visibility := a.getDefaultPostStates(r)
+	posts, err := a.getPosts(&postsRequestConfig{
+		blogs:      []string{blog},
+		parameter:  eventStartParam,
+		status:     status,
+		visibility: visibility,
+	})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ics := builderpool.Get()
+	defer builderpool.Put(ics)
+	a.writeICS(ics, bc, posts)
+	w.Header().Set(contentType, contenttype.ICSUTF8)
+	_, _ = io.WriteString(w, ics.String())
+}
+
+func (a *goBlog) writeICS(w *strings.Builder, bc *configBlog, posts []*post) {
+	line := func(name, value string) {
+		writeICSLine(w, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//GoBlog//Events//"+strings.ToUpper(bc.Lang))
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", escapeICSText(a.renderMdTitle(bc.Title)+": "+a.eventsTitle(bc)))
+	for _, p := range posts {
+		start := a.eventStart(p)
+		if start.IsZero() {
+			continue
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", a.fullPostURL(p))
+		line("DTSTAMP", toLocalTime(cmp.Or(p.Updated, p.Published)).UTC().Format(icsDateTimeFormat))
+		startParam, endParam := p.firstParameter(eventStartParam), p.firstParameter(eventEndParam)
+		if isDateOnly(startParam) {
+			line("DTSTART;VALUE=DATE", start.Format(icsDateFormat))
+		} else {
+			line("DTSTART", start.UTC().Format(icsDateTimeFormat))
+		}
+		if end := a.eventEnd(p); !end.IsZero() {
+			if isDateOnly(endParam) {
+				// The end date is exclusive
+				line("DTEND;VALUE=DATE", end.AddDate(0, 0, 1).Format(icsDateFormat))
+			} else {
+				line("DTEND", end.UTC().Format(icsDateTimeFormat))
+			}
+		}
+		line("SUMMARY", escapeICSText(a.titleOrFallback(p)))
+		if summary := a.postSummary(p); summary != "" {
+			line("DESCRIPTION", escapeICSText(summary))
+		}
+		if location := a.eventLocation(p); location != "" {
+			line("LOCATION", escapeICSText(location))
+		} else if geoURIs := a.geoURIs(p); len(geoURIs) > 0 {
+			line("LOCATION", escapeICSText(a.geoTitle(geoURIs[0], bc.Lang)))
+			line("GEO", fmt.Sprintf("%f;%f", geoURIs[0].Latitude, geoURIs[0].Longitude))
+		}
+		line("URL", a.fullPostURL(p))
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+}
+
+var icsTextReplacer = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escapeICSText(s string) string {
+	return icsTextReplacer.Replace(s)
+}
+
+// writeICSLine writes the content line and folds it after 75 octets (RFC 5545, without splitting UTF-8 characters)
+func writeICSLine(w *strings.Builder, s string) {
+	for length := 75; len(s) > length; length = 74 {
+		cut := length
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		w.WriteString(s[:cut])
+		w.WriteString("\r\n ")
+		s = s[cut:]
+	}
+	w.WriteString(s)
+	w.WriteString("\r\n")
+}
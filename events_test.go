@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ap "go.goblog.app/app/pkgs/activitypub"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_events(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.cfg.Blogs["default"].Events = &configEvents{Enabled: true}
+
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:    "/past",
+		Section: "posts",
+		Content: "Past event",
+		Parameters: map[string][]string{
+			"title": {"Past"},
+			"start": {"2020-01-01 10:00"},
+			"end":   {"2020-01-01 12:00"},
+		},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/later",
+		Section: "posts",
+		Content: "Later event, " + strings.Repeat("with a long description ", 5),
+		Parameters: map[string][]string{
+			"title":    {"Later"},
+			"start":    {"2099-06-01"},
+			"location": {"Café Central, Berlin"},
+		},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/sooner",
+		Section: "posts",
+		Content: "Sooner event",
+		Parameters: map[string][]string{
+			"title": {"Sooner"},
+			"start": {"2099-05-01T10:00:00Z"},
+			"end":   {"2099-05-01T12:00:00Z"},
+		},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/no-event",
+		Section: "posts",
+		Content: "No event",
+	}))
+
+	t.Run("Upcoming events", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+
+		assert.NotContains(t, body, "/past")
+		assert.NotContains(t, body, "/no-event")
+		sooner, later := strings.Index(body, "/sooner"), strings.Index(body, "/later")
+		require.NotEqual(t, -1, sooner)
+		require.NotEqual(t, -1, later)
+		assert.Less(t, sooner, later, "events should be ordered by start")
+		assert.Contains(t, body, "h-event")
+		assert.Contains(t, body, "/events.ics")
+	})
+
+	t.Run("iCalendar", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events.ics", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, contenttype.ICSUTF8, rec.Header().Get(contentType))
+		body := rec.Body.String()
+
+		assert.True(t, strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n"))
+		assert.True(t, strings.HasSuffix(body, "END:VCALENDAR\r\n"))
+		// The calendar includes past events
+		assert.Equal(t, 3, strings.Count(body, "BEGIN:VEVENT"))
+		assert.Contains(t, body, "DTSTART:20990501T100000Z\r\n")
+		assert.Contains(t, body, "DTEND:20990501T120000Z\r\n")
+		assert.Contains(t, body, "DTSTART;VALUE=DATE:20990601\r\n")
+		assert.Contains(t, body, "LOCATION:Café Central\\, Berlin\r\n")
+		assert.Contains(t, body, "URL:http://localhost:8080/sooner\r\n")
+		for line := range strings.SplitSeq(body, "\r\n") {
+			assert.LessOrEqual(t, len(line), 75)
+		}
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/later", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, "h-entry h-event")
+		assert.Contains(t, body, "dt-start")
+		assert.Contains(t, body, "p-location")
+		assert.Contains(t, body, "Café Central, Berlin")
+	})
+
+	t.Run("ActivityPub", func(t *testing.T) {
+		p, err := app.getPost("/later")
+		require.NoError(t, err)
+		note := app.toAPNote(p)
+		assert.Equal(t, ap.EventType, note.Type)
+		assert.Equal(t, 2099, note.StartTime.Year())
+		location, err := ap.ToObject(note.Location)
+		require.NoError(t, err)
+		assert.Equal(t, ap.PlaceType, location.Type)
+		assert.Equal(t, "Café Central, Berlin", location.Name.First().String())
+
+		p, err = app.getPost("/no-event")
+		require.NoError(t, err)
+		assert.Equal(t, ap.NoteType, app.toAPNote(p).Type)
+	})
+
+	t.Run("All-day events", func(t *testing.T) {
+		now := time.Now()
+		require.NoError(t, app.createPost(&post{
+			Path:       "/today",
+			Section:    "posts",
+			Content:    "All-day event today",
+			Parameters: map[string][]string{"start": {now.Format(isoDateFormat)}},
+		}))
+		require.NoError(t, app.createPost(&post{
+			Path:       "/yesterday",
+			Section:    "posts",
+			Content:    "All-day event yesterday",
+			Parameters: map[string][]string{"start": {now.AddDate(0, 0, -1).Format(isoDateFormat)}},
+		}))
+
+		// All-day events are upcoming until the end of the day
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "/today")
+		assert.NotContains(t, body, "/yesterday")
+
+		assert.Equal(t, toLocalTime("2099-06-02").UTC().Format(time.RFC3339), eventEndUTC("2099-06-01"))
+		assert.Equal(t, "2099-05-01T12:00:00Z", eventEndUTC("2099-05-01T12:00:00Z"))
+	})
+
+	t.Run("Folding", func(t *testing.T) {
+		var sb strings.Builder
+		writeICSLine(&sb, "DESCRIPTION:"+strings.Repeat("ä", 60))
+		lines := strings.Split(strings.TrimSuffix(sb.String(), "\r\n"), "\r\n")
+		require.Len(t, lines, 2)
+		assert.LessOrEqual(t, len(lines[0]), 75)
+		assert.True(t, strings.HasPrefix(lines[1], " "))
+		assert.Equal(t, "DESCRIPTION:"+strings.Repeat("ä", 60), lines[0]+strings.TrimPrefix(lines[1], " "))
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testExifEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+func testExifASCII(tag uint16, s string) testExifEntry {
+	return testExifEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func testExifRationals(tag uint16, values ...uint32) testExifEntry {
+	b := make([]byte, 0, len(values)*4)
+	for _, v := range values {
+		b = binary.LittleEndian.AppendUint32(b, v)
+	}
+	return testExifEntry{tag: tag, typ: 5, count: uint32(len(values) / 2), value: b}
+}
+
+func testExifShort(tag, v uint16) testExifEntry {
+	return testExifEntry{tag: tag, typ: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func testExifLong(tag uint16, v uint32) testExifEntry {
+	return testExifEntry{tag: tag, typ: 4, count: 1, value: binary.LittleEndian.AppendUint32(nil, v)}
+}
+
+// testExifIFD encodes the (sorted) entries as little-endian TIFF IFD starting at the offset
+func testExifIFD(entries []testExifEntry, offset uint32) []byte {
+	dataOffset := offset + 2 + uint32(len(entries))*12 + 4
+	ifd := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	var data []byte
+	for _, e := range entries {
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.tag)
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.typ)
+		ifd = binary.LittleEndian.AppendUint32(ifd, e.count)
+		if len(e.value) <= 4 {
+			ifd = append(ifd, append(e.value, make([]byte, 4-len(e.value))...)...)
+			continue
+		}
+		ifd = binary.LittleEndian.AppendUint32(ifd, dataOffset+uint32(len(data)))
+		data = append(data, e.value...)
+		if len(data)%2 == 1 {
+			data = append(data, 0)
+		}
+	}
+	ifd = binary.LittleEndian.AppendUint32(ifd, 0)
+	return append(ifd, data...)
+}
+
+// testExifTIFF returns TIFF structured EXIF data with camera, exposure and (optionally) GPS information and orientation
+func testExifTIFF(withGPS bool, orientation uint16) []byte {
+	exifIFD := []testExifEntry{
+		testExifRationals(0x829A, 1, 250),               // ExposureTime
+		testExifRationals(0x829D, 28, 10),               // FNumber
+		testExifShort(0x8827, 400),                      // ISOSpeedRatings
+		testExifASCII(0x9003, "2024:05:01 14:03:00"),    // DateTimeOriginal
+		testExifRationals(0x920A, 50, 1),                // FocalLength
+		testExifASCII(0xA431, "SERIAL123456"),           // BodySerialNumber
+		testExifASCII(0xA434, "RF24-105mm F4 L IS USM"), // LensModel
+	}
+	gpsIFD := []testExifEntry{
+		testExifASCII(0x0001, "N"),                     // GPSLatitudeRef
+		testExifRationals(0x0002, 52, 1, 31, 1, 12, 1), // GPSLatitude
+		testExifASCII(0x0003, "E"),                     // GPSLongitudeRef
+		testExifRationals(0x0004, 13, 1, 24, 1, 36, 1), // GPSLongitude
+	}
+	ifd0 := func(exifOffset, gpsOffset uint32) []testExifEntry {
+		entries := []testExifEntry{
+			testExifASCII(0x010F, "Canon"),        // Make
+			testExifASCII(0x0110, "Canon EOS R5"), // Model
+		}
+		if orientation != 0 {
+			entries = append(entries, testExifShort(0x0112, orientation)) // Orientation
+		}
+		entries = append(entries, testExifLong(0x8769, exifOffset)) // ExifIFDPointer
+		if withGPS {
+			entries = append(entries, testExifLong(0x8825, gpsOffset)) // GPSInfoIFDPointer
+		}
+		return entries
+	}
+
+	const headerLength = 8
+	ifd0Length := uint32(len(testExifIFD(ifd0(0, 0), headerLength)))
+	exifOffset := headerLength + ifd0Length
+	exifData := testExifIFD(exifIFD, exifOffset)
+	gpsOffset := exifOffset + uint32(len(exifData))
+
+	tiff := []byte{'I', 'I', 0x2A, 0x00}
+	tiff = binary.LittleEndian.AppendUint32(tiff, headerLength)
+	tiff = append(tiff, testExifIFD(ifd0(exifOffset, gpsOffset), headerLength)...)
+	tiff = append(tiff, exifData...)
+	if withGPS {
+		tiff = append(tiff, testExifIFD(gpsIFD, gpsOffset)...)
+	}
+	return tiff
+}
+
+// testJPEGWithExif returns a small JPEG image with camera, exposure and (optionally) GPS information
+func testJPEGWithExif(t *testing.T, withGPS bool) []byte {
+	t.Helper()
+
+	return testJPEGWithExifData(t, testExifTIFF(withGPS, 0), 8, 8)
+}
+
+// testJPEGWithExifData returns a JPEG image of the size with the EXIF data
+func testJPEGWithExifData(t *testing.T, tiff []byte, width, height int) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+	img := buf.Bytes()
+
+	// Insert the APP1 segment with the EXIF data after the SOI marker
+	app1 := []byte{0xFF, 0xE1}
+	app1 = binary.BigEndian.AppendUint16(app1, uint16(2+6+len(tiff)))
+	app1 = append(app1, "Exif\x00\x00"...)
+	app1 = append(app1, tiff...)
+	return append(append(append([]byte{}, img[:2]...), app1...), img[2:]...)
+}
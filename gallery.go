@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+const (
+	galleryParam   = "gallery"
+	imageExifParam = "imageexif"
+
+	exifDateFormat = "2006-01-02 15:04"
+)
+
+// isGallery returns true if the images of the post should be shown as gallery
+func (a *goBlog) isGallery(p *post) bool {
+	return p.firstParameter(galleryParam) == "true"
+}
+
+// galleryImages returns the images of the post which aren't already in the content
+func (a *goBlog) galleryImages(p *post) []string {
+	return lo.Reject(a.photoLinks(p), func(image string, _ int) bool {
+		return strings.Contains(p.Content, image)
+	})
+}
+
+// stripMediaExif strips the metadata of the image like when storing it and saves the EXIF summary of the stripped image for galleries,
+// so tags configured to be stripped aren't shown. It returns the stripped image.
+func (a *goBlog) stripMediaExif(hash, fileName string, f io.Reader) (io.Reader, error) {
+	stripped, err := a.stripMediaFileMetadata(fileName, f)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(stripped)
+	if err != nil {
+		return nil, err
+	}
+	if exifSummary := extractExif(bytes.NewReader(data)); exifSummary != "" {
+		if err = a.db.saveMediaExif(hash, exifSummary); err != nil {
+			return nil, err
+		}
+	}
+	return bytes.NewReader(data), nil
+}
+
+// extractExif returns a summary of the EXIF data of the image (camera, lens, exposure and date) or an empty string
+func extractExif(r io.Reader) string {
+	x, err := exif.Decode(r)
+	if err != nil {
+		return ""
+	}
+	stringTag := func(name exif.FieldName) string {
+		tag, err := x.Get(name)
+		if err != nil {
+			return ""
+		}
+		s, _ := tag.StringVal()
+		return strings.TrimSpace(strings.Trim(s, "\x00"))
+	}
+	floatTag := func(name exif.FieldName) float64 {
+		tag, err := x.Get(name)
+		if err != nil {
+			return 0
+		}
+		rat, err := tag.Rat(0)
+		if err != nil {
+			return 0
+		}
+		f, _ := rat.Float64()
+		return f
+	}
+	var parts []string
+	// Camera, without repeating the make if the model already contains it
+	cameraMake, cameraModel := stringTag(exif.Make), stringTag(exif.Model)
+	if strings.HasPrefix(strings.ToLower(cameraModel), strings.ToLower(cameraMake)) {
+		parts = append(parts, cameraModel)
+	} else {
+		parts = append(parts, strings.TrimSpace(cameraMake+" "+cameraModel))
+	}
+	parts = append(parts, stringTag(exif.LensModel))
+	// Exposure
+	if focalLength := floatTag(exif.FocalLength); focalLength > 0 {
+		parts = append(parts, fmt.Sprintf("%g mm", focalLength))
+	}
+	if fNumber := floatTag(exif.FNumber); fNumber > 0 {
+		parts = append(parts, fmt.Sprintf("f/%g", fNumber))
+	}
+	if exposureTime := floatTag(exif.ExposureTime); exposureTime > 0 && exposureTime < 1 {
+		parts = append(parts, fmt.Sprintf("1/%g s", math.Round(1/exposureTime)))
+	} else if exposureTime >= 1 {
+		parts = append(parts, fmt.Sprintf("%g s", exposureTime))
+	}
+	if tag, err := x.Get(exif.ISOSpeedRatings); err == nil {
+		if iso, err := tag.Int(0); err == nil && iso > 0 {
+			parts = append(parts, fmt.Sprintf("ISO %d", iso))
+		}
+	}
+	// Date
+	if date, err := x.DateTime(); err == nil {
+		parts = append(parts, date.Format(exifDateFormat))
+	}
+	return strings.Join(lo.Compact(parts), " · ")
+}
+
+func (db *database) saveMediaExif(hash, summary string) error {
+	_, err := db.Exec("insert or replace into media_exif (hash, exif) values (?, ?)", hash, summary)
+	return err
+}
+
+func (db *database) mediaExif(hash string) (string, error) {
+	row, err := db.QueryRow("select exif from media_exif where hash = ?", hash)
+	if err != nil {
+		return "", err
+	}
+	var summary string
+	if err = row.Scan(&summary); errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return summary, err
+}
+
+// addImageExif adds the EXIF summaries (extracted when the images were uploaded) of the images to the post parameters,
+// if the post doesn't already have one summary per image and the summaries of all images are known
+// (empty parameter values aren't saved, so partial summaries couldn't be matched to the images later)
+func (a *goBlog) addImageExif(p *post) {
+	images := p.Parameters[a.cfg.Micropub.PhotoParam]
+	if len(images) == 0 || len(p.Parameters[imageExifParam]) == len(images) {
+		return
+	}
+	summaries := make([]string, len(images))
+	for i, image := range images {
+		if hash := a.extractMediaHashFromURL(image); hash != "" {
+			summaries[i], _ = a.db.mediaExif(hash)
+		}
+		if summaries[i] == "" {
+			return
+		}
+	}
+	p.Parameters[imageExifParam] = summaries
+}
+
+// renderGallery renders the images of the post (which aren't already in the content) as grid, each linking to the lightbox
+func (a *goBlog) renderGallery(hb *htmlbuilder.HTMLBuilder, p *post, absolute, simpleImages bool) {
+	images := a.galleryImages(p)
+	if len(images) == 0 {
+		return
+	}
+	allImages := a.photoLinks(p)
+	alts, exifs := p.Parameters[a.cfg.Micropub.PhotoDescriptionParam], p.Parameters[imageExifParam]
+	hb.WriteElementOpen("div", "class", "gallery")
+	for _, image := range images {
+		i := lo.IndexOf(allImages, image)
+		alt, exifSummary := "", ""
+		if len(alts) == len(allImages) {
+			alt = alts[i]
+		}
+		if len(exifs) == len(allImages) {
+			exifSummary = exifs[i]
+		}
+		hb.WriteElementOpen("figure")
+		imageURL := image
+		if absolute {
+			imageURL = a.getFullAddress(image)
+		}
+		a.writePictureElement(hb, imageURL, alt, alt, "u-photo", p.Path, simpleImages)
+		if alt != "" || exifSummary != "" {
+			hb.WriteElementOpen("figcaption")
+			if alt != "" {
+				hb.WriteElementOpen("span")
+				hb.WriteEscaped(alt)
+				hb.WriteElementClose("span")
+			}
+			if exifSummary != "" {
+				hb.WriteElementOpen("small", "class", "exif")
+				hb.WriteEscaped(exifSummary)
+				hb.WriteElementClose("small")
+			}
+			hb.WriteElementClose("figcaption")
+		}
+		hb.WriteElementClose("figure")
+	}
+	hb.WriteElementClose("div")
+}
+
+// renderGalleryLightbox renders the dialog to show the gallery images in full size
+func (a *goBlog) renderGalleryLightbox(hb *htmlbuilder.HTMLBuilder, b *configBlog) {
+	hb.WriteElementOpen("dialog", "id", "lightbox", "tabindex", "-1", "aria-label", a.ts.GetTemplateStringVariant(b.Lang, "lightbox"))
+	hb.WriteElementOpen("div", "id", "lightboxHeader")
+	hb.WriteElement("span", "id", "lightboxCounter")
+	hb.WriteElementOpen("button", "type", "button", "class", "share-modal-close", "id", "lightboxClose", "aria-label", a.ts.GetTemplateStringVariant(b.Lang, "lightboxclose"))
+	hb.WriteEscaped("×")
+	hb.WriteElementsClose("button", "div")
+	hb.WriteElement("img", "id", "lightboxImage", "alt", "")
+	hb.WriteElement("p", "id", "lightboxCaption")
+	hb.WriteElementOpen("div", "id", "lightboxNav")
+	hb.WriteElementOpen("button", "type", "button", "class", "button", "id", "lightboxPrev")
+	hb.WriteEscaped("← " + a.ts.GetTemplateStringVariant(b.Lang, "lightboxprev"))
+	hb.WriteElementClose("button")
+	hb.WriteElementOpen("button", "type", "button", "class", "button", "id", "lightboxNext")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(b.Lang, "lightboxnext") + " →")
+	hb.WriteElementsClose("button", "div", "dialog")
+	hb.WriteElement("script", "defer", "", "src", a.assetFileName("js/gallery.js"), "integrity", a.assetFileHash("js/gallery.js"))
+}
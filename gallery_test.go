@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.hacdias.com/indielib/micropub"
+)
+
+func Test_extractExif(t *testing.T) {
+	assert.Equal(t,
+		"Canon EOS R5 · RF24-105mm F4 L IS USM · 50 mm · f/2.8 · 1/250 s · ISO 400 · 2024-05-01 14:03",
+		extractExif(bytes.NewReader(testJPEGWithExif(t, false))),
+	)
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+	assert.Equal(t, "", extractExif(&buf))
+	assert.Equal(t, "", extractExif(strings.NewReader("no image")))
+}
+
+func Test_gallery(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.cfg.Blogs["default"].Photos = &configPhotos{Enabled: true, Grid: true}
+
+	app.d = app.buildRouter()
+
+	hash1, hash2 := strings.Repeat("a", 64), strings.Repeat("b", 64)
+	require.NoError(t, app.db.saveMediaExif(hash1, "Canon EOS R5 · f/2.8"))
+	require.NoError(t, app.db.saveMediaExif(hash2, "Nikon Z 6 · f/8"))
+
+	summary, err := app.db.mediaExif(hash1)
+	require.NoError(t, err)
+	assert.Equal(t, "Canon EOS R5 · f/2.8", summary)
+	summary, err = app.db.mediaExif(strings.Repeat("c", 64))
+	require.NoError(t, err)
+	assert.Equal(t, "", summary)
+
+	// The summary is extracted from the stripped image
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{StripExifTags: []string{"LensModel"}}
+	hash3 := strings.Repeat("d", 64)
+	_, err = app.stripMediaExif(hash3, hash3+".jpg", bytes.NewReader(testJPEGWithExif(t, true)))
+	require.NoError(t, err)
+	app.cfg.Micropub.MediaStorage = nil
+	summary, err = app.db.mediaExif(hash3)
+	require.NoError(t, err)
+	assert.Equal(t, "Canon EOS R5 · 50 mm · f/2.8 · 1/250 s · ISO 400 · 2024-05-01 14:03", summary)
+
+	image1, image2 := app.cfg.Server.PublicAddress+"/m/"+hash1+".jpg", app.cfg.Server.PublicAddress+"/m/"+hash2+".jpg"
+
+	location, err := app.getMicropubImplementation().Create(&micropub.Request{
+		Type: "h-entry",
+		Properties: map[string][]any{
+			"content": {"Holiday pictures"},
+			"gallery": {"true"},
+			"photo": {
+				map[string]any{"value": image1, "alt": "Beach"},
+				map[string]any{"value": image2, "alt": "Mountains"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	postPath := strings.TrimPrefix(location, app.cfg.Server.PublicAddress)
+
+	t.Run("Parameters", func(t *testing.T) {
+		p, err := app.getPost(postPath)
+		require.NoError(t, err)
+		assert.True(t, app.isGallery(p))
+		assert.Equal(t, []string{"Canon EOS R5 · f/2.8", "Nikon Z 6 · f/8"}, p.Parameters[imageExifParam])
+		// Images aren't added to the content in gallery mode
+		assert.NotContains(t, p.Content, hash1)
+		assert.Equal(t, []string{image1, image2}, app.galleryImages(p))
+
+		// Summaries are only added if they are known for all images
+		p = &post{Parameters: map[string][]string{"images": {image1, "https://example.com/photo.jpg"}}}
+		app.addImageExif(p)
+		assert.Empty(t, p.Parameters[imageExifParam])
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, postPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, "class=gallery")
+		assert.Contains(t, body, "<figcaption><span>Beach</span><small class=exif>Canon EOS R5 · f/2.8</small></figcaption>")
+		assert.Contains(t, body, "<figcaption><span>Mountains</span><small class=exif>Nikon Z 6 · f/8</small></figcaption>")
+		assert.Contains(t, body, "id=lightbox")
+	})
+
+	t.Run("Photos grid", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/photos", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, "gallery photogrid")
+		assert.Contains(t, body, hash1)
+		assert.NotContains(t, body, "id=lightbox")
+	})
+}
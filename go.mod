@@ -41,6 +41,7 @@ require (
 	github.com/mmcdole/gofeed v1.4.0
 	github.com/paulmach/go.geojson v1.5.0
 	github.com/pquerna/otp v1.5.0
+	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd
 	github.com/samber/go-singleflightx v0.3.2
 	github.com/samber/lo v1.53.0
 	github.com/schollz/sqlite3dump v1.3.1
@@ -101,7 +102,6 @@ require (
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
 	github.com/rs/zerolog v1.35.1 // indirect
-	github.com/rwcarlsen/goexif v0.0.0-20190401172101-9e8deecbddbd // indirect
 	github.com/sagikazarmark/locafero v0.12.0 // indirect
 	github.com/spf13/afero v1.15.0 // indirect
 	github.com/spf13/pflag v1.0.10 // indirect
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Placeholder for line breaks, so they can be trimmed at the start and end of paragraphs
+const markdownLineBreak = "\x00br\x00"
+
+var (
+	markdownEscaper       = strings.NewReplacer(`\`, `\\`, "`", "\\`", `*`, `\*`, `_`, `\_`, `[`, `\[`, `]`, `\]`, `<`, `\<`)
+	markdownWhitespaceRe  = regexp.MustCompile(`[ \t\r\n]+`)
+	markdownMultiSpacesRe = regexp.MustCompile(` {2,}`)
+	markdownLineBreakRe   = regexp.MustCompile(` *` + markdownLineBreak + ` *`)
+)
+
+// Elements that are converted to Markdown blocks
+var markdownBlockElements = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true, atom.Header: true, atom.Footer: true,
+	atom.Main: true, atom.Aside: true, atom.Figure: true, atom.Figcaption: true, atom.Center: true,
+	atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Hr: true, atom.Blockquote: true, atom.Ul: true, atom.Ol: true, atom.Pre: true,
+}
+
+// Elements that are kept as HTML blocks (or removed)
+var markdownHTMLBlockElements = map[atom.Atom]bool{
+	atom.Table: true, atom.Iframe: true, atom.Video: true, atom.Audio: true, atom.Dl: true, atom.Details: true,
+	atom.Form: true, atom.Object: true, atom.Embed: true, atom.Script: true, atom.Style: true, atom.Noscript: true,
+}
+
+// htmlToMarkdown converts HTML (e.g. from other blog systems) to Markdown,
+// elements without Markdown equivalent (like tables or iframes) are kept as HTML
+func htmlToMarkdown(s string) string {
+	nodes, err := html.ParseFragment(strings.NewReader(s), &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body})
+	if err != nil {
+		return s
+	}
+	return strings.Join(markdownBlocks(nodes), "\n\n")
+}
+
+func markdownChildNodes(n *html.Node) (nodes []*html.Node) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		nodes = append(nodes, c)
+	}
+	return nodes
+}
+
+func isMarkdownBlock(n *html.Node) bool {
+	return n.Type == html.ElementNode && (markdownBlockElements[n.DataAtom] || markdownHTMLBlockElements[n.DataAtom])
+}
+
+// markdownBlocks converts the nodes to Markdown blocks, inline nodes between blocks become paragraphs
+func markdownBlocks(nodes []*html.Node) []string {
+	var blocks []string
+	var inline strings.Builder
+	flush := func() {
+		text := strings.TrimSpace(markdownMultiSpacesRe.ReplaceAllString(inline.String(), " "))
+		for strings.HasPrefix(text, markdownLineBreak) || strings.HasSuffix(text, markdownLineBreak) {
+			text = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(text, markdownLineBreak), markdownLineBreak))
+		}
+		if text != "" {
+			blocks = append(blocks, markdownLineBreakRe.ReplaceAllString(text, "\\\n"))
+		}
+		inline.Reset()
+	}
+	for _, n := range nodes {
+		if isMarkdownBlock(n) {
+			flush()
+			blocks = append(blocks, markdownBlock(n)...)
+		} else {
+			inline.WriteString(markdownInline(n))
+		}
+	}
+	flush()
+	return blocks
+}
+
+func markdownBlock(n *html.Node) []string {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		text := strings.Join(markdownBlocks(markdownChildNodes(n)), " ")
+		if text == "" {
+			return nil
+		}
+		level, _ := strconv.Atoi(n.Data[1:])
+		return []string{strings.Repeat("#", level) + " " + strings.ReplaceAll(text, "\\\n", " ")}
+	case atom.Hr:
+		return []string{"---"}
+	case atom.Blockquote:
+		lines := strings.Split(strings.Join(markdownBlocks(markdownChildNodes(n)), "\n\n"), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimSpace("> " + line)
+		}
+		return []string{strings.Join(lines, "\n")}
+	case atom.Ul, atom.Ol:
+		return markdownList(n)
+	case atom.Pre:
+		return []string{markdownCodeBlock(n)}
+	case atom.Script, atom.Style, atom.Noscript:
+		return nil
+	}
+	if markdownHTMLBlockElements[n.DataAtom] {
+		var sb strings.Builder
+		if err := html.Render(&sb, n); err != nil {
+			return nil
+		}
+		return []string{sb.String()}
+	}
+	return markdownBlocks(markdownChildNodes(n))
+}
+
+func markdownList(n *html.Node) []string {
+	number, _ := strconv.Atoi(markdownAttr(n, "start"))
+	number = max(number, 1)
+	var items []string
+	for _, li := range markdownChildNodes(n) {
+		if li.DataAtom != atom.Li {
+			continue
+		}
+		marker := "- "
+		if n.DataAtom == atom.Ol {
+			marker = fmt.Sprintf("%d. ", number)
+			number++
+		}
+		lines := strings.Split(strings.Join(markdownBlocks(markdownChildNodes(li)), "\n"), "\n")
+		for i := 1; i < len(lines); i++ {
+			if lines[i] != "" {
+				lines[i] = strings.Repeat(" ", len(marker)) + lines[i]
+			}
+		}
+		items = append(items, marker+strings.Join(lines, "\n"))
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return []string{strings.Join(items, "\n")}
+}
+
+func markdownCodeBlock(n *html.Node) string {
+	language := ""
+	if code := n.FirstChild; code != nil && code.DataAtom == atom.Code {
+		for class := range strings.FieldsSeq(markdownAttr(code, "class")) {
+			if lang, ok := strings.CutPrefix(class, "language-"); ok {
+				language = lang
+			} else if lang, ok := strings.CutPrefix(class, "lang-"); ok {
+				language = lang
+			}
+		}
+	}
+	text := strings.TrimSuffix(markdownText(n), "\n")
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	return fence + language + "\n" + text + "\n" + fence
+}
+
+func markdownInline(n *html.Node) string {
+	switch n.Type {
+	case html.TextNode:
+		return markdownEscaper.Replace(markdownWhitespaceRe.ReplaceAllString(n.Data, " "))
+	case html.ElementNode:
+	default:
+		// Comments etc.
+		return ""
+	}
+	inner := func() string {
+		var sb strings.Builder
+		for _, c := range markdownChildNodes(n) {
+			sb.WriteString(markdownInline(c))
+		}
+		return sb.String()
+	}
+	switch n.DataAtom {
+	case atom.Strong, atom.B:
+		return markdownWrap(inner(), "**")
+	case atom.Em, atom.I:
+		return markdownWrap(inner(), "*")
+	case atom.Del, atom.S, atom.Strike:
+		return markdownWrap(inner(), "~~")
+	case atom.Code, atom.Kbd:
+		code := markdownWhitespaceRe.ReplaceAllString(markdownText(n), " ")
+		if code == "" {
+			return ""
+		}
+		fence := "`"
+		for strings.Contains(code, fence) {
+			fence += "`"
+		}
+		if strings.HasPrefix(code, "`") || strings.HasSuffix(code, "`") {
+			code = " " + code + " "
+		}
+		return fence + code + fence
+	case atom.A:
+		text, href := inner(), markdownAttr(n, "href")
+		if href == "" || strings.TrimSpace(text) == "" {
+			return text
+		}
+		return "[" + strings.TrimSpace(text) + "](" + markdownDestination(href) + ")"
+	case atom.Img:
+		src := markdownAttr(n, "src")
+		if src == "" {
+			return ""
+		}
+		alt := markdownEscaper.Replace(markdownWhitespaceRe.ReplaceAllString(markdownAttr(n, "alt"), " "))
+		return "![" + alt + "](" + markdownDestination(src) + ")"
+	case atom.Br:
+		return markdownLineBreak
+	case atom.Span, atom.Font, atom.Li:
+		return inner()
+	}
+	if isMarkdownBlock(n) {
+		// Blocks inside inline elements can't be represented in Markdown
+		return " " + inner() + " "
+	}
+	// Keep other inline elements (like sup or abbr) as HTML
+	var sb strings.Builder
+	if err := html.Render(&sb, n); err != nil {
+		return inner()
+	}
+	return sb.String()
+}
+
+// markdownWrap wraps the text with the emphasis marker, keeping surrounding spaces outside
+func markdownWrap(text, marker string) string {
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return text
+	}
+	start := strings.Index(text, trimmed)
+	return text[:start] + marker + trimmed + marker + text[start+len(trimmed):]
+}
+
+func markdownDestination(url string) string {
+	if strings.ContainsAny(url, " ()") {
+		return "<" + url + ">"
+	}
+	return url
+}
+
+func markdownAttr(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// markdownText returns the text content of the node
+func markdownText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for _, c := range markdownChildNodes(n) {
+		sb.WriteString(markdownText(c))
+	}
+	return sb.String()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_htmlToMarkdown(t *testing.T) {
+	for _, tt := range []struct {
+		name, html, markdown string
+	}{
+		{"Paragraphs", "<p>First paragraph</p>\n<p>Second\nparagraph</p>", "First paragraph\n\nSecond paragraph"},
+		{"Text without paragraph", "Just text <b>bold</b>", "Just text **bold**"},
+		{"Emphasis", "<p><strong>Bold</strong>, <em>italic </em>and <del>deleted</del></p>", "**Bold**, *italic* and ~~deleted~~"},
+		{"Line breaks", "<p>Line 1<br>\nLine 2<br></p>", "Line 1\\\nLine 2"},
+		{"Headings", "<h2>Heading <i>2</i></h2><h3>Heading 3</h3>", "## Heading *2*\n\n### Heading 3"},
+		{"Links and images", `<p><a href="https://example.com/a(b)">Link</a> <img src="/image.jpg" alt="An image"></p>`, "[Link](<https://example.com/a(b)>) ![An image](/image.jpg)"},
+		{"Linked image", `<a href="/big.jpg"><img src="/small.jpg" alt=""></a>`, "[![](/small.jpg)](/big.jpg)"},
+		{"Lists", "<ul><li>One</li><li>Two<ul><li>Nested</li></ul></li></ul><ol start=\"3\"><li>Three</li></ol>", "- One\n- Two\n  - Nested\n\n3. Three"},
+		{"Blockquote", "<blockquote><p>Quote</p><p>Second</p></blockquote>", "> Quote\n>\n> Second"},
+		{"Code", `<p>Use <code>go *test*</code></p><pre><code class="language-go">func main() {
+	fmt.Println("&lt;hi&gt;")
+}
+</code></pre>`, "Use `go *test*`\n\n```go\nfunc main() {\n\tfmt.Println(\"<hi>\")\n}\n```"},
+		{"Escaping", "<p>2 * 3 = 6, [not a link] and &lt;not html&gt;</p>", "2 \\* 3 = 6, \\[not a link\\] and \\<not html>"},
+		{"HTML blocks", `<p>Video:</p><iframe src="https://example.com/embed"></iframe><script>alert(1)</script><hr>`, "Video:\n\n<iframe src=\"https://example.com/embed\"></iframe>\n\n---"},
+		{"Other inline elements", "<p>E = mc<sup>2</sup></p>", "E = mc<sup>2</sup>"},
+		{"Figure", `<figure><img src="/a.jpg" alt="A"><figcaption>Caption</figcaption></figure>`, "![A](/a.jpg)\n\nCaption"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.markdown, htmlToMarkdown(tt.html))
+		})
+	}
+}
@@ -319,6 +319,8 @@ func (a *goBlog) servePostsAliasesRedirects() http.HandlerFunc {
 					switch postVisibility(value2) {
 					case visibilityPublic, visibilityUnlisted:
 						alicePrivate.Append(a.checkActivityStreamsRequest, a.cacheMiddleware).ThenFunc(a.servePost).ServeHTTP(w, r)
+					case visibilityProtected:
+						alicePrivate.Append(a.protectedPostMiddleware).ThenFunc(a.servePost).ServeHTTP(w, r)
 					default: // private, etc.
 						alice.New(a.authMiddleware).ThenFunc(a.servePost).ServeHTTP(w, r)
 					}
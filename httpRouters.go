@@ -144,6 +144,12 @@ func (a *goBlog) otherRoutesRouter(r chi.Router) {
 		r.With(bodylimit.BodyLimit(10*bodylimit.KB)).Post("/reactions", a.postReaction)
 	}
 
+	// Preview links
+	r.With(noIndexHeader).Get(previewLinkSubPath+"/{token}", a.servePreviewLink)
+
+	// Protected posts
+	r.With(bodylimit.BodyLimit(10*bodylimit.KB)).Post("/protected", a.serveProtectedPostUnlock)
+
 	// Reload router
 	r.With(a.authMiddleware).Get("/reload", a.serveReloadRouter)
 }
@@ -170,6 +176,15 @@ func (a *goBlog) blogRouter(blog string, conf *configBlog) func(r chi.Router) {
 		// Photos
 		r.Group(a.blogPhotosRouter(conf))
 
+		// Series
+		r.Group(a.blogSeriesRouter(conf))
+
+		// Events
+		r.Group(a.blogEventsRouter(conf))
+
+		// Media logs
+		r.Group(a.blogMediaLogsRouter(conf))
+
 		// Search
 		r.Group(a.blogSearchRouter(conf))
 
@@ -285,11 +300,78 @@ func (a *goBlog) dateRoutes(conf *configBlog, pathPrefix string) func(r chi.Rout
 	}
 }
 
+// Blog - Series
+func (a *goBlog) blogSeriesRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		if conf.seriesEnabled() {
+			r.Use(
+				a.privateModeHandler,
+				a.cacheMiddleware,
+			)
+			seriesPath := conf.seriesPath()
+			r.Get(seriesPath, a.serveSeriesList)
+			registerIndexRoutes(r, seriesPath+"/{series}", a.serveSeries)
+		}
+	}
+}
+
+// Blog - Events
+func (a *goBlog) blogEventsRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		if conf.eventsEnabled() {
+			eventsPath := conf.eventsPath()
+			r.Use(
+				a.privateModeHandler,
+				middleware.WithValue(indexConfigKey, &indexConfig{
+					path:           eventsPath,
+					parameter:      eventStartParam,
+					title:          a.eventsTitle(conf),
+					description:    conf.Events.Description,
+					upcomingEvents: true,
+				}),
+			)
+			// Only the calendar is cached, the list of upcoming events changes when events end
+			r.With(a.cacheMiddleware).Get(eventsPath+eventsICSSuffix, a.serveEventsICS)
+			registerIndexRoutes(r, eventsPath, a.serveIndex)
+		}
+	}
+}
+
+// Blog - Media logs
+func (a *goBlog) blogMediaLogsRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		if conf.mediaLogsEnabled() {
+			r.Use(
+				a.privateModeHandler,
+				a.cacheMiddleware,
+			)
+			for _, kind := range mediaLogKinds {
+				r.Group(func(r chi.Router) {
+					mlPath := conf.mediaLogPath(kind)
+					r.Use(middleware.WithValue(indexConfigKey, &indexConfig{
+						path:        mlPath,
+						parameter:   kind.param,
+						title:       a.mediaLogTitle(conf, kind),
+						description: conf.mediaLogConfig(kind).Description,
+						mediaLog:    kind,
+					}))
+					registerIndexRoutes(r, mlPath, a.serveIndex)
+					r.Group(a.dateRoutes(conf, cmp.Or(conf.mediaLogConfig(kind).Path, kind.defaultPath)))
+				})
+			}
+		}
+	}
+}
+
 // Blog - Photos
 func (a *goBlog) blogPhotosRouter(conf *configBlog) func(r chi.Router) {
 	return func(r chi.Router) {
 		if pc := conf.Photos; pc != nil && pc.Enabled {
 			photoPath := conf.getRelativePath(cmp.Or(pc.Path, defaultPhotosPath))
+			summaryTemplate := photoSummary
+			if pc.Grid {
+				summaryTemplate = photoGridSummary
+			}
 			r.Use(
 				a.privateModeHandler,
 				a.cacheMiddleware,
@@ -298,7 +380,7 @@ func (a *goBlog) blogPhotosRouter(conf *configBlog) func(r chi.Router) {
 					parameter:       a.cfg.Micropub.PhotoParam,
 					title:           pc.Title,
 					description:     pc.Description,
-					summaryTemplate: photoSummary,
+					summaryTemplate: summaryTemplate,
 				}),
 			)
 			registerIndexRoutes(r, photoPath, a.serveIndex)
@@ -366,9 +448,19 @@ func (a *goBlog) blogEditorRouter(_ *configBlog) func(r chi.Router) {
 		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorFileOptimizePath, a.serveEditorFilesOptimize)
 		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorFileVariantsPath, a.serveEditorFilesVariants)
 		r.Get(editorLinksPath, a.serveEditorLinks)
+		r.Get(editorRevisionsPath, a.serveEditorRevisions)
+		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorRevisionsPath, a.serveEditorRevisionRestore)
+		r.Get(editorBulkPath, a.serveEditorBulk)
+		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorBulkPath, a.serveEditorBulkPost)
+		r.Get(editorTaxonomiesPath, a.serveEditorTaxonomies)
+		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorTaxonomiesPath, a.serveEditorTaxonomiesPost)
+		r.Get(editorPreviewLinksPath, a.serveEditorPreviewLinks)
+		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorPreviewLinksPath, a.serveEditorPreviewLinksPost)
+		r.With(bodylimit.BodyLimit(100*bodylimit.KB)).Post(editorScheduledEditsPath, a.serveEditorScheduledEditDelete)
 		registerIndexRoutes(r, "/drafts", a.serveDrafts)
 		registerIndexRoutes(r, "/private", a.servePrivate)
 		registerIndexRoutes(r, "/unlisted", a.serveUnlisted)
+		registerIndexRoutes(r, "/protected", a.serveProtected)
 		registerIndexRoutes(r, "/scheduled", a.serveScheduled)
 		registerIndexRoutes(r, "/deleted", a.serveDeleted)
 		r.HandleFunc("/ws", a.serveEditorWebsocket)
@@ -387,12 +479,20 @@ func (a *goBlog) blogCommentsRouter(conf *configBlog) func(r chi.Router) {
 				)
 				r.With(a.cacheMiddleware, noIndexHeader).Get("/{id:[0-9]+}", a.serveComment)
 				r.With(a.captchaMiddleware, bodylimit.BodyLimit(bodylimit.MB)).Post("/", a.createCommentFromRequest)
+				if a.commentSubscriptionsEnabled(conf) {
+					r.Get(commentSubscriptionSubPath+commentSubscriptionConfirm, a.serveCommentSubscriptionConfirm)
+					r.Get(commentSubscriptionSubPath+commentSubscriptionUnsubscribe, a.serveCommentSubscriptionUnsubscribe)
+					r.With(bodylimit.BodyLimit(bodylimit.KB)).Post(commentSubscriptionSubPath+commentSubscriptionUnsubscribe, a.serveCommentSubscriptionUnsubscribe)
+				}
 				r.Group(func(r chi.Router) {
 					// Admin
 					r.Use(a.authMiddleware)
 					r.Get("/", a.commentsAdmin)
 					r.Get(paginationPath, a.commentsAdmin)
 					r.With(bodylimit.BodyLimit(bodylimit.MB)).Post(commentDeleteSubPath, a.commentsAdminDelete)
+					r.With(bodylimit.BodyLimit(bodylimit.MB)).Post(commentApproveSubPath, a.commentsAdminApprove)
+					r.With(bodylimit.BodyLimit(bodylimit.MB)).Post(commentSpamSubPath, a.commentsAdminSpam)
+					r.With(bodylimit.BodyLimit(bodylimit.MB)).Post(commentReplySubPath, a.commentsAdminReply)
 					r.Get(commentEditSubPath, a.serveCommentsEditor)
 					r.With(bodylimit.BodyLimit(bodylimit.MB)).Post(commentEditSubPath, a.serveCommentsEditor)
 				})
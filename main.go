@@ -570,8 +570,272 @@ Examples:
 	checkFormatsCmd.Flags().Bool("has-variants", false, "only show images that have at least one variant")
 	mediaCmd.AddCommand(checkFormatsCmd)
 
+	stripMetadataCmd := &cobra.Command{
+		Use:   "stripMetadata",
+		Short: "Strip GPS and personal metadata from stored images",
+		Long: `Strip GPS information and other personal metadata from images already in the media storage.
+
+New uploads are stripped automatically (unless keepMetadata is configured). This command removes
+the GPS information, the configured EXIF tags, XMP, IPTC and comments from all stored JPEG, PNG,
+WebP and HEIC images and overwrites the changed files. JPEG and PNG images get rotated according
+to their EXIF orientation, WebP and HEIC images keep the orientation tag instead.
+
+Examples:
+  ./GoBlog media stripMetadata --dry-run
+  ./GoBlog media stripMetadata`,
+		Run: func(cmd *cobra.Command, _ []string) {
+			app := initializeApp(cmd)
+
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+			changed, err := app.stripStoredMediaMetadata(dryRun)
+			if err != nil {
+				app.logErrAndQuit("Failed to strip media metadata", "err", err)
+				return
+			}
+			app.info("Done stripping media metadata", "files", changed, "dryRun", dryRun)
+
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	stripMetadataCmd.Flags().Bool("dry-run", false, "only show which files would be changed")
+	mediaCmd.AddCommand(stripMetadataCmd)
+
 	rootCmd.AddCommand(mediaCmd)
 
+	commentsCmd := &cobra.Command{
+		Use:   "comments",
+		Short: "Comment management commands",
+		Long:  `Comment management commands, e.g. for migrating discussions from other comment systems.`,
+	}
+
+	commentsCmd.AddCommand(&cobra.Command{
+		Use:   "import <disqus|wordpress|isso> <file>",
+		Short: "Import comments from Disqus, WordPress or Isso",
+		Long: `Import comments from a Disqus XML export, a WordPress WXR export or an Isso SQLite database.
+
+The URLs of the commented pages are mapped to the paths of the posts, also using the "aliases" of the posts, so set aliases for posts that moved. Comments on pages that can't be mapped are skipped. The original dates, authors and reply threads are kept. Comments that were pending moderation are imported as pending. Running the import again skips comments that were already imported.
+
+Examples:
+  ./GoBlog comments import disqus ./disqus-export.xml
+  ./GoBlog comments import wordpress ./wordpress.xml
+  ./GoBlog comments import isso ./comments.db`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			imported, skipped, err := app.importCommentsFile(args[0], args[1])
+			if err != nil {
+				app.logErrAndQuit("Failed to import comments", "err", err)
+				return
+			}
+			app.info("Imported comments", "imported", imported, "skipped", skipped)
+			app.shutdown.ShutdownAndWait()
+		},
+	})
+
+	rootCmd.AddCommand(commentsCmd)
+
+	importCmd := &cobra.Command{
+		Use:   "import",
+		Short: "Import posts from other blog systems",
+		Long:  `Import posts from other blog systems. Posts are saved without sending webmentions or ActivityPub updates.`,
+	}
+	importCmd.PersistentFlags().String("blog", "", "blog to import the posts into (default blog if empty)")
+
+	importWordPressCmd := &cobra.Command{
+		Use:   "wordpress <file>",
+		Short: "Import a WordPress WXR export",
+		Long: `Import posts, pages, attachments and approved comments from a WordPress WXR export (Tools > Export).
+
+Posts and pages are converted to Markdown. Slugs, dates, categories and tags are kept, pages keep their path and posts get the old permalink as alias. Images and other files from the WordPress uploads are downloaded into the media storage and their URLs rewritten. Password protected posts are imported as protected posts with their password. If another post already has the path, a number is appended to the slug. Posts that were already imported (found by their old URL) are skipped, so multiple WordPress sites can be merged into one blog.
+
+Examples:
+  ./GoBlog import wordpress ./wordpress.xml
+  ./GoBlog import wordpress --blog=en --section=posts --tags=tags --categories=categories ./wordpress.xml`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			conf := &wordPressImportConfig{}
+			conf.blog, _ = cmd.Flags().GetString("blog")
+			if conf.blog == "" {
+				conf.blog = app.cfg.DefaultBlog
+			}
+			conf.section, _ = cmd.Flags().GetString("section")
+			conf.categoriesTaxonomy, _ = cmd.Flags().GetString("categories")
+			conf.tagsTaxonomy, _ = cmd.Flags().GetString("tags")
+			result, err := app.importWordPressFile(args[0], conf)
+			if err != nil {
+				app.logErrAndQuit("Failed to import WordPress export", "err", err)
+				return
+			}
+			app.info("Imported WordPress export",
+				"posts", result.posts, "pages", result.pages, "media", result.media,
+				"comments", result.comments, "skipped", result.skipped)
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	importWordPressCmd.Flags().String("section", "", "section for the posts (default section of the blog if empty)")
+	importWordPressCmd.Flags().String("categories", "categories", "taxonomy for the categories (empty to skip)")
+	importWordPressCmd.Flags().String("tags", "tags", "taxonomy for the tags (empty to skip)")
+	importCmd.AddCommand(importWordPressCmd)
+
+	importMarkdownCmd := &cobra.Command{
+		Use:   "markdown <directory>",
+		Short: "Import a directory of Markdown files",
+		Long: `Create or update posts from a directory of Markdown files with front matter, like written by the export command or used in the editor.
+
+The path is taken from the front matter or otherwise from the location of the file in the directory. Existing posts with the same path are updated, unchanged posts are skipped, so the import can run again after every change (e.g. to restore posts versioned in git). Files referenced relative to a Markdown file (like ![](photo.jpg)) are uploaded to the media storage and the references rewritten. Hidden directories like .git are ignored.
+
+Examples:
+  ./GoBlog import markdown --dry-run ./export
+  ./GoBlog import markdown ./export`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			conf := &markdownImportConfig{}
+			conf.blog, _ = cmd.Flags().GetString("blog")
+			conf.dryRun, _ = cmd.Flags().GetBool("dry-run")
+			result, err := app.importMarkdownFiles(args[0], conf)
+			if err != nil {
+				app.logErrAndQuit("Failed to import Markdown files", "err", err)
+				return
+			}
+			app.info("Imported Markdown files",
+				"created", result.created, "updated", result.updated, "unchanged", result.unchanged,
+				"media", result.media, "dryRun", conf.dryRun)
+			app.shutdown.ShutdownAndWait()
+		},
+	}
+	importMarkdownCmd.Flags().Bool("dry-run", false, "only show which posts would be created or updated")
+	importCmd.AddCommand(importMarkdownCmd)
+
+	// archiveImportCommand imports a Mastodon or Twitter archive
+	archiveImportCommand := func(format string) func(cmd *cobra.Command, args []string) {
+		return func(cmd *cobra.Command, args []string) {
+			app := initializeApp(cmd)
+			conf := &archiveImportConfig{}
+			conf.blog, _ = cmd.Flags().GetString("blog")
+			if conf.blog == "" {
+				conf.blog = app.cfg.DefaultBlog
+			}
+			conf.section, _ = cmd.Flags().GetString("section")
+			conf.tagsTaxonomy, _ = cmd.Flags().GetString("tags")
+			result, err := app.importArchiveFile(format, args[0], conf)
+			if err != nil {
+				app.logErrAndQuit("Failed to import archive", "format", format, "err", err)
+				return
+			}
+			app.info("Imported archive", "format", format, "notes", result.notes, "media", result.media, "skipped", result.skipped)
+			app.shutdown.ShutdownAndWait()
+		}
+	}
+
+	importMastodonCmd := &cobra.Command{
+		Use:   "mastodon <archive>",
+		Short: "Import a Mastodon archive as notes",
+		Long: `Import the public and unlisted posts of a Mastodon archive (the zip file, the extracted directory or its outbox.json) as notes.
+
+The original dates are kept, the original URLs are saved as syndication links, media attachments are uploaded to the media storage, hashtags are saved to the taxonomy given by --tags and replies get a reply link. Replies to own posts link to the imported post, so threads are kept. Boosts, followers-only posts and direct messages are skipped. Notes are saved without sending webmentions or ActivityPub updates. Posts that were already imported are skipped.
+
+Examples:
+  ./GoBlog import mastodon ./archive.zip
+  ./GoBlog import mastodon --section=notes ./archive`,
+		Args: cobra.ExactArgs(1),
+		Run:  archiveImportCommand("mastodon"),
+	}
+	importTwitterCmd := &cobra.Command{
+		Use:   "twitter <archive>",
+		Short: "Import a Twitter archive as notes",
+		Long: `Import the tweets of a Twitter/X archive (the zip file or the extracted directory) as notes.
+
+The original dates are kept, the original URLs are saved as syndication links, shortened links are expanded, photos and videos are uploaded to the media storage, hashtags are saved to the taxonomy given by --tags and replies get a reply link. Replies to own tweets link to the imported post, so threads are kept. Retweets are skipped. Notes are saved without sending webmentions or ActivityPub updates. Tweets that were already imported are skipped.
+
+Examples:
+  ./GoBlog import twitter ./twitter-archive.zip
+  ./GoBlog import twitter --section=notes ./twitter-archive`,
+		Args: cobra.ExactArgs(1),
+		Run:  archiveImportCommand("twitter"),
+	}
+	for _, c := range []*cobra.Command{importMastodonCmd, importTwitterCmd} {
+		c.Flags().String("section", "", "section for the notes (default section of the blog if empty)")
+		c.Flags().String("tags", "tags", "taxonomy for the hashtags (empty to skip)")
+		importCmd.AddCommand(c)
+	}
+
+	rootCmd.AddCommand(importCmd)
+
+	taxonomyCmd := &cobra.Command{
+		Use:   "taxonomy",
+		Short: "Taxonomy management commands",
+		Long:  `Taxonomy management commands to rename, merge and delete taxonomy values like tags across all posts of a blog.`,
+	}
+	taxonomyCmd.PersistentFlags().Bool("federate", false, "send ActivityPub updates for the changed posts")
+
+	// taxonomyCommand runs the replacement and sets up ActivityPub sending if requested
+	taxonomyCommand := func(cmd *cobra.Command, blog, taxonomy string, oldValues []string, newValue string) {
+		app := initializeApp(cmd)
+		federate, _ := cmd.Flags().GetBool("federate")
+		if federate && app.apEnabled() {
+			if err := app.initTemplateStrings(); err != nil {
+				app.logErrAndQuit("Failed to init template strings", "err", err)
+				return
+			}
+			if err := app.initActivityPubBase(); err != nil {
+				app.logErrAndQuit("Failed to init ActivityPub base", "err", err)
+				return
+			}
+			app.initAPSendQueue()
+		}
+		changed, err := app.replaceTaxonomyValues(blog, taxonomy, oldValues, newValue, federate)
+		if err != nil {
+			app.logErrAndQuit("Failed to change taxonomy values", "blog", blog, "taxonomy", taxonomy, "err", err)
+			return
+		}
+		app.info("Changed posts", "changed", changed)
+		app.shutdown.ShutdownAndWait()
+	}
+
+	taxonomyCmd.AddCommand(&cobra.Command{
+		Use:   "rename <blog> <taxonomy> <old> <new>",
+		Short: "Rename a taxonomy value",
+		Long: `Rename a taxonomy value in all posts of a blog. The old URL redirects to the new one.
+
+Example:
+  ./GoBlog taxonomy rename default tags golang Go`,
+		Args: cobra.ExactArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			taxonomyCommand(cmd, args[0], args[1], []string{args[2]}, args[3])
+		},
+	})
+
+	taxonomyCmd.AddCommand(&cobra.Command{
+		Use:   "merge <blog> <taxonomy> <target> <value>...",
+		Short: "Merge taxonomy values into one",
+		Long: `Merge one or more taxonomy values into the target value in all posts of a blog. The old URLs redirect to the target.
+
+Example:
+  ./GoBlog taxonomy merge default tags Go golang go-lang`,
+		Args: cobra.MinimumNArgs(4),
+		Run: func(cmd *cobra.Command, args []string) {
+			taxonomyCommand(cmd, args[0], args[1], args[3:], args[2])
+		},
+	})
+
+	taxonomyCmd.AddCommand(&cobra.Command{
+		Use:   "delete <blog> <taxonomy> <value>",
+		Short: "Delete a taxonomy value",
+		Long: `Remove a taxonomy value from all posts of a blog.
+
+Example:
+  ./GoBlog taxonomy delete default tags misc`,
+		Args: cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			taxonomyCommand(cmd, args[0], args[1], []string{args[2]}, "")
+		},
+	})
+
+	rootCmd.AddCommand(taxonomyCmd)
+
 	// Execute the root command
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -610,7 +874,8 @@ func initializeComponents(app *goBlog) {
 	for _, f := range []func(){
 		app.initMediaOptimization, app.initWebmention, app.initTelegram, app.initAtproto,
 		app.initTTS, app.initSessions, app.startPostsScheduler, app.initPostsDeleter,
-		app.initIndexNow,
+		app.initPostRevisions, app.initRelatedPosts, app.initPostLinks, app.initIndexNow,
+		app.initPreviewLinks,
 	} {
 		f()
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"io"
+	"strings"
 
 	marktag "git.jlel.se/jlelse/goldmark-mark"
 	"github.com/yuin/goldmark"
@@ -15,6 +16,7 @@ import (
 	"go.goblog.app/app/pkgs/builderpool"
 	"go.goblog.app/app/pkgs/highlighting"
 	"go.goblog.app/app/pkgs/htmlbuilder"
+	"go.goblog.app/app/pkgs/mathml"
 )
 
 func (a *goBlog) initMarkdown() {
@@ -55,12 +57,12 @@ func (a *goBlog) defaultGoldmarkOptions() []goldmark.Option {
 		goldmark.WithExtensions(
 			extension.Table,
 			extension.Strikethrough,
-			extension.Footnote,
 			extension.Typographer,
 			extension.Linkify,
 			marktag.Mark,
 			emoji.Emoji,
 			highlighting.Highlighting,
+			mathml.Math,
 		),
 	}
 }
@@ -108,7 +110,8 @@ func (a *goBlog) renderMdTitle(s string) string {
 	return text
 }
 
-func (a *goBlog) renderPostMarkdownToWriter(w io.Writer, source string, absoluteLinks bool, postPath string, simpleImages bool) (err error) {
+// renderPostMarkdownToWriter renders the post content, a table of contents is added if tocTitle isn't empty
+func (a *goBlog) renderPostMarkdownToWriter(w io.Writer, source string, absoluteLinks bool, postPath string, simpleImages bool, tocTitle string) (err error) {
 	a.initMarkdown()
 	publicAddress := ""
 	if srv := a.cfg.Server; srv != nil {
@@ -120,6 +123,7 @@ func (a *goBlog) renderPostMarkdownToWriter(w io.Writer, source string, absolute
 		app:           a,
 		postPath:      postPath,
 		simpleImages:  simpleImages,
+		tocTitle:      tocTitle,
 	}))...)
 	return md.Convert([]byte(source), w)
 }
@@ -133,9 +137,26 @@ type customExtension struct {
 	app           *goBlog
 	postPath      string
 	simpleImages  bool
+	tocTitle      string
 }
 
 func (l *customExtension) Extend(m goldmark.Markdown) {
+	// Footnotes with IDs unique per post, so they also work on index pages with full posts
+	extension.NewFootnote(extension.WithFootnoteIDPrefix(footnoteIDPrefix(l.postPath))).Extend(m)
+	m.Parser().AddOptions(
+		parser.WithInlineParsers(
+			// Before the link parser
+			util.Prioritized(&wikiLinkParser{}, 199),
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(&admonitionTransformer{}, 500),
+		),
+	)
+	if l.tocTitle != "" {
+		m.Parser().AddOptions(parser.WithASTTransformers(
+			util.Prioritized(&tocTransformer{title: l.tocTitle}, 999),
+		))
+	}
 	m.Renderer().AddOptions(renderer.WithNodeRenderers(
 		util.Prioritized(&customRenderer{
 			absoluteLinks: l.absoluteLinks,
@@ -147,6 +168,17 @@ func (l *customExtension) Extend(m goldmark.Markdown) {
 	))
 }
 
+// footnoteIDPrefix returns the prefix for footnote IDs of the post, like "posts-my-post-" for "/posts/my-post"
+func footnoteIDPrefix(postPath string) string {
+	parts := strings.FieldsFunc(strings.ToLower(postPath), func(r rune) bool {
+		return (r < 'a' || r > 'z') && (r < '0' || r > '9')
+	})
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "-") + "-"
+}
+
 type customRenderer struct {
 	publicAddress string
 	absoluteLinks bool
@@ -158,6 +190,10 @@ type customRenderer struct {
 func (c *customRenderer) RegisterFuncs(r renderer.NodeRendererFuncRegisterer) {
 	r.Register(ast.KindLink, c.renderLink)
 	r.Register(ast.KindImage, c.renderImage)
+	r.Register(kindWikiLink, c.renderWikiLink)
+	r.Register(ast.KindHeading, c.renderHeading)
+	r.Register(kindTableOfContents, c.renderTableOfContents)
+	r.Register(kindAdmonition, c.renderAdmonition)
 }
 
 func (c *customRenderer) renderLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+type markdownImportConfig struct {
+	blog   string
+	dryRun bool
+}
+
+type markdownImportResult struct {
+	created, updated, unchanged, media int
+}
+
+var (
+	// Destinations of Markdown links and images and of HTML src and href attributes
+	markdownImportLinkRe = regexp.MustCompile(`(!?\[[^\]]*\]\(\s*)(<[^>\n]+>|[^)\s]+)`)
+	markdownImportAttrRe = regexp.MustCompile(`(\s(?:src|href)=["'])([^"']+)`)
+)
+
+// importMarkdownFiles creates or updates the posts from the Markdown files (with front matter like exported or used in the editor),
+// files referenced relative to the Markdown file are uploaded to the media storage
+func (a *goBlog) importMarkdownFiles(dir string, conf *markdownImportConfig) (*markdownImportResult, error) {
+	result := &markdownImportResult{}
+	uploaded := map[string]bool{}
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if file != dir && strings.HasPrefix(d.Name(), ".") {
+				// Skip hidden directories like .git
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(file), ".md") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		// Parse the front matter the same way as the editor
+		p := &post{Blog: conf.blog, Content: string(content)}
+		if err = a.processContentAndParameters(p); err != nil {
+			return fmt.Errorf("invalid front matter in %s: %w", rel, err)
+		}
+		if p.Path == "" {
+			// Path from the location of the file (like exported), so it stays the same when importing again
+			p.Path = "/" + filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+		}
+		// Replace relative file references with the (future) URLs in the media storage
+		uploads := map[string]string{} // File to media storage name
+		mediaURL := func(ref string) string {
+			localFile, ok := markdownImportLocalFile(dir, filepath.Dir(file), ref)
+			if !ok || !a.mediaStorageEnabled() {
+				return ref
+			}
+			data, err := os.ReadFile(localFile)
+			if err != nil {
+				return ref
+			}
+			name := importMediaFileName(filepath.Ext(localFile), data)
+			uploads[localFile] = name
+			return a.getFullAddress(a.mediaFileLocation(name))
+		}
+		replaceDestinations := func(re *regexp.Regexp) {
+			p.Content = re.ReplaceAllStringFunc(p.Content, func(s string) string {
+				m := re.FindStringSubmatch(s)
+				return m[1] + mediaURL(m[2])
+			})
+		}
+		for param, values := range p.Parameters {
+			for i, value := range values {
+				if newValue := mediaURL(value); newValue != value {
+					p.Parameters[param][i] = newValue
+					// Images from the parameters are also added to the content
+					p.Content = strings.ReplaceAll(p.Content, "]("+value, "]("+newValue)
+				}
+			}
+		}
+		replaceDestinations(markdownImportLinkRe)
+		replaceDestinations(markdownImportAttrRe)
+		// Create or update post
+		existing, err := a.getPost(p.Path)
+		isNew := errors.Is(err, errPostNotFound)
+		if err != nil && !isNew {
+			return err
+		}
+		if err = a.checkPost(p, isNew, true); err != nil {
+			return fmt.Errorf("invalid post in %s: %w", rel, err)
+		}
+		action := "Create post"
+		if !isNew {
+			if existing.contentWithParams() == p.contentWithParams() {
+				result.unchanged++
+				return nil
+			}
+			action = "Update post"
+		}
+		a.info(action, "file", rel, "path", p.Path, "dryRun", conf.dryRun)
+		for localFile, name := range uploads {
+			if uploaded[name] {
+				continue
+			}
+			uploaded[name] = true
+			result.media++
+			if conf.dryRun {
+				a.info("Upload file", "file", localFile, "name", name, "dryRun", conf.dryRun)
+				continue
+			}
+			data, err := os.ReadFile(localFile)
+			if err != nil {
+				return err
+			}
+			if _, err = a.importMediaData(filepath.Ext(localFile), data); err != nil {
+				return err
+			}
+		}
+		if isNew {
+			result.created++
+		} else {
+			result.updated++
+		}
+		if conf.dryRun {
+			return nil
+		}
+		// Save post without triggering hooks (no webmentions or ActivityPub for restored posts)
+		o := &postCreationOptions{isNew: isNew, noUpdated: true}
+		if !isNew {
+			o.oldPath, o.oldStatus, o.oldVisibility = existing.Path, existing.Status, existing.Visibility
+		}
+		return a.db.savePost(p, o)
+	})
+	if result.created+result.updated > 0 && !conf.dryRun {
+		a.purgeCache()
+	}
+	return result, err
+}
+
+// markdownImportLocalFile returns the file if the reference is a relative path to an existing file (except Markdown files)
+// inside the imported root directory
+func markdownImportLocalFile(root, dir, ref string) (string, bool) {
+	ref = strings.TrimSuffix(strings.TrimPrefix(ref, "<"), ">")
+	if ref == "" || strings.Contains(ref, ":") || strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "?") {
+		return "", false
+	}
+	unescaped, err := url.PathUnescape(ref)
+	if err != nil {
+		return "", false
+	}
+	file := filepath.Join(dir, filepath.FromSlash(unescaped))
+	if rel, err := filepath.Rel(root, file); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if ext := filepath.Ext(file); ext == "" || strings.EqualFold(ext, ".md") {
+		return "", false
+	}
+	if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+		return "", false
+	}
+	return file, true
+}
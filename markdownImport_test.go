@@ -0,0 +1,124 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_markdownImport(t *testing.T) {
+	storagePath := t.TempDir()
+	app := newAppWithStorage(t, &localMediaStorage{path: storagePath})
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	require.NoError(t, app.initConfig(false))
+	_ = app.initTemplateStrings()
+
+	err := app.createPost(&post{
+		Path:      "/one",
+		Section:   "posts",
+		Published: "2023-04-05T10:00:00Z",
+		Content:   "First post",
+		Parameters: map[string][]string{
+			"title": {"One"},
+			"tags":  {"a", "b"},
+		},
+	})
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	require.NoError(t, app.exportMarkdownFiles(dir))
+	conf := &markdownImportConfig{}
+
+	t.Run("Unchanged export", func(t *testing.T) {
+		result, err := app.importMarkdownFiles(dir, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{unchanged: 1}, result)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		file := filepath.Join(dir, "one.md")
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(file, []byte(strings.Replace(string(content), "First post", "Changed post", 1)), 0o644))
+
+		result, err := app.importMarkdownFiles(dir, &markdownImportConfig{dryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{updated: 1}, result)
+		p, err := app.getPost("/one")
+		require.NoError(t, err)
+		assert.Equal(t, "First post", p.Content)
+
+		result, err = app.importMarkdownFiles(dir, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{updated: 1}, result)
+		p, err = app.getPost("/one")
+		require.NoError(t, err)
+		assert.Equal(t, "Changed post", p.Content)
+		assert.Equal(t, []string{"a", "b"}, p.Parameters["tags"])
+		assert.Equal(t, "2023-04-05T10:00:00Z", toUTCSafe(p.Published))
+		assert.Empty(t, p.Updated)
+	})
+
+	t.Run("Create with media", func(t *testing.T) {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "notes", "img"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "img", "photo.txt"), []byte("photo"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes", "new.md"), []byte(`---
+title: New
+images: img/photo.txt
+---
+A [link](https://example.org), a [missing file](missing.jpg) and a ![photo](img/photo.txt) <img src="img/photo.txt">.`), 0o644))
+		// Hidden directories are ignored
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "hidden.md"), []byte("Hidden"), 0o644))
+
+		result, err := app.importMarkdownFiles(dir, &markdownImportConfig{dryRun: true})
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{created: 1, unchanged: 1, media: 1}, result)
+		_, err = app.getPost("/notes/new")
+		assert.ErrorIs(t, err, errPostNotFound)
+
+		result, err = app.importMarkdownFiles(dir, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{created: 1, unchanged: 1, media: 1}, result)
+
+		p, err := app.getPost("/notes/new")
+		require.NoError(t, err)
+		name := importMediaFileName(".txt", []byte("photo"))
+		mediaURL := "https://example.com/m/" + name
+		assert.Equal(t, "New", p.Title())
+		assert.Equal(t, []string{mediaURL}, p.Parameters["images"])
+		assert.Equal(t, "A [link](https://example.org), a [missing file](missing.jpg) and a ![photo]("+mediaURL+") <img src=\""+mediaURL+"\">.", p.Content)
+		stored, err := os.ReadFile(filepath.Join(storagePath, name))
+		require.NoError(t, err)
+		assert.Equal(t, "photo", string(stored))
+
+		// Importing again doesn't change anything
+		result, err = app.importMarkdownFiles(dir, conf)
+		require.NoError(t, err)
+		assert.Equal(t, &markdownImportResult{unchanged: 2}, result)
+	})
+}
+
+func Test_markdownImportLocalFile(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "import")
+	dir := filepath.Join(root, "notes")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "photo.jpg"), []byte("photo"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.md"), []byte("Other"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(parent, "secret.txt"), []byte("secret"), 0o644))
+
+	file, ok := markdownImportLocalFile(root, dir, "../photo.jpg")
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "photo.jpg"), file)
+
+	for _, ref := range []string{"other.md", "missing.jpg", "../../secret.txt", "..%2F..%2Fsecret.txt", "/etc/hosts", "https://example.org/photo.jpg"} {
+		_, ok = markdownImportLocalFile(root, dir, ref)
+		assert.False(t, ok, ref)
+	}
+}
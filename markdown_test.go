@@ -96,14 +96,14 @@ func Test_markdown(t *testing.T) {
 			var buf1, buf2 bytes.Buffer
 			err := app.renderMarkdownToWriter(&buf1, source)
 			require.NoError(t, err)
-			err = app.renderPostMarkdownToWriter(&buf2, source, false, "", false)
+			err = app.renderPostMarkdownToWriter(&buf2, source, false, "", false, "")
 			require.NoError(t, err)
 			assert.Equal(t, buf1.String(), buf2.String())
 		})
 
 		t.Run("absolute link prefixing", func(t *testing.T) {
 			var buf bytes.Buffer
-			err := app.renderPostMarkdownToWriter(&buf, "[Relative](/relative)", true, "", false)
+			err := app.renderPostMarkdownToWriter(&buf, "[Relative](/relative)", true, "", false, "")
 			require.NoError(t, err)
 			output := buf.String()
 			assert.Contains(t, output, `href="https://example.com/relative"`)
@@ -111,7 +111,7 @@ func Test_markdown(t *testing.T) {
 
 		t.Run("relative links without absoluteLinks", func(t *testing.T) {
 			var buf bytes.Buffer
-			err := app.renderPostMarkdownToWriter(&buf, "[Relative](/relative)", false, "", false)
+			err := app.renderPostMarkdownToWriter(&buf, "[Relative](/relative)", false, "", false, "")
 			require.NoError(t, err)
 			output := buf.String()
 			assert.Contains(t, output, `href="/relative"`)
@@ -119,7 +119,7 @@ func Test_markdown(t *testing.T) {
 
 		t.Run("image rendered through writePictureElement", func(t *testing.T) {
 			var buf bytes.Buffer
-			err := app.renderPostMarkdownToWriter(&buf, "![Alt](/m/pic.jpg)", false, "", false)
+			err := app.renderPostMarkdownToWriter(&buf, "![Alt](/m/pic.jpg)", false, "", false, "")
 			require.NoError(t, err)
 			output := buf.String()
 			assert.Contains(t, output, `<a href=`)
@@ -131,9 +131,9 @@ func Test_markdown(t *testing.T) {
 		t.Run("postPath does not affect output without plugins", func(t *testing.T) {
 			source := "Text with ![image](/m/pic.jpg)"
 			var buf1, buf2 bytes.Buffer
-			err := app.renderPostMarkdownToWriter(&buf1, source, false, "", false)
+			err := app.renderPostMarkdownToWriter(&buf1, source, false, "", false, "")
 			require.NoError(t, err)
-			err = app.renderPostMarkdownToWriter(&buf2, source, false, "/blog/my-post", false)
+			err = app.renderPostMarkdownToWriter(&buf2, source, false, "/blog/my-post", false, "")
 			require.NoError(t, err)
 			assert.Equal(t, buf1.String(), buf2.String())
 		})
@@ -141,7 +141,7 @@ func Test_markdown(t *testing.T) {
 		t.Run("handles complex markdown", func(t *testing.T) {
 			source := "# Heading\n\nParagraph with **bold** and `code`.\n\n- List item 1\n- List item 2\n\n> Blockquote"
 			var buf bytes.Buffer
-			err := app.renderPostMarkdownToWriter(&buf, source, false, "", false)
+			err := app.renderPostMarkdownToWriter(&buf, source, false, "", false, "")
 			require.NoError(t, err)
 			output := buf.String()
 			assert.Contains(t, output, "Heading")
@@ -150,6 +150,36 @@ func Test_markdown(t *testing.T) {
 			assert.Contains(t, output, "<ul>")
 			assert.Contains(t, output, "<blockquote>")
 		})
+
+		t.Run("admonitions", func(t *testing.T) {
+			var buf bytes.Buffer
+			err := app.renderPostMarkdownToWriter(&buf, "> [!WARNING] Be *careful*\n> Hot **stuff**.\n\n> [!tip]\n>\n> A tip.\n\n> Normal quote", false, "", false, "")
+			require.NoError(t, err)
+			output := buf.String()
+			assert.Contains(t, output, "<div class=\"admonition admonition-warning\">\n<p class=\"admonition-title\"><strong>Be careful</strong></p>\n<p>Hot <strong>stuff</strong>.</p>\n</div>")
+			assert.Contains(t, output, "<div class=\"admonition admonition-tip\">\n<p class=\"admonition-title\"><strong>Tip</strong></p>\n<p>A tip.</p>\n</div>")
+			assert.Contains(t, output, "<blockquote>\n<p>Normal quote</p>")
+		})
+
+		t.Run("footnotes with post prefix", func(t *testing.T) {
+			var buf bytes.Buffer
+			err := app.renderPostMarkdownToWriter(&buf, "Text[^1]\n\n[^1]: Note", true, "/blog/my-post", true, "")
+			require.NoError(t, err)
+			output := buf.String()
+			assert.Contains(t, output, `<a href="#blog-my-post-fn:1"`)
+			assert.Contains(t, output, `<li id="blog-my-post-fn:1">`)
+			assert.Contains(t, output, `<a href="#blog-my-post-fnref:1" class="footnote-backref"`)
+		})
+
+		t.Run("math", func(t *testing.T) {
+			var buf bytes.Buffer
+			err := app.renderPostMarkdownToWriter(&buf, "Inline $x^2$\n\n$$\n\\frac{1}{2}\n$$", true, "/test", true, "")
+			require.NoError(t, err)
+			output := buf.String()
+			assert.Contains(t, output, `<math xmlns="http://www.w3.org/1998/Math/MathML" alttext="x^2"><msup><mi>x</mi><mn>2</mn></msup></math>`)
+			assert.Contains(t, output, `display="block"`)
+			assert.Contains(t, output, `<mfrac><mn>1</mn><mn>2</mn></mfrac>`)
+		})
 	})
 }
 
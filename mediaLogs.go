@@ -0,0 +1,185 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cast"
+)
+
+const (
+	mediaLogAuthorParam = "mediaauthor"
+	mediaLogISBNParam   = "isbn"
+	mediaLogRatingParam = "rating"
+	mediaLogURLParam    = "medialink"
+
+	maxMediaLogRating = 5
+)
+
+// mediaLogKind is a kind of media log posts, like books read
+type mediaLogKind struct {
+	name        string // Used for the config and the strings
+	property    string // Microformats and Micropub property
+	param       string // Post parameter with the title of the book, film or music
+	defaultPath string
+	emoji       string
+}
+
+var mediaLogKinds = []*mediaLogKind{
+	{name: "read", property: "read-of", param: "readof", defaultPath: "/reads", emoji: "📖"},
+	{name: "watch", property: "watch-of", param: "watchof", defaultPath: "/watches", emoji: "🎬"},
+	{name: "listen", property: "listen-of", param: "listenof", defaultPath: "/listens", emoji: "🎧"},
+}
+
+func (bc *configBlog) mediaLogsEnabled() bool {
+	return bc.MediaLogs != nil && bc.MediaLogs.Enabled
+}
+
+func (bc *configBlog) mediaLogConfig(kind *mediaLogKind) *configMediaLog {
+	var mlc *configMediaLog
+	if bc.MediaLogs != nil {
+		switch kind.name {
+		case "read":
+			mlc = bc.MediaLogs.Read
+		case "watch":
+			mlc = bc.MediaLogs.Watch
+		case "listen":
+			mlc = bc.MediaLogs.Listen
+		}
+	}
+	if mlc == nil {
+		mlc = &configMediaLog{}
+	}
+	return mlc
+}
+
+func (bc *configBlog) mediaLogPath(kind *mediaLogKind) string {
+	return bc.getRelativePath(cmp.Or(bc.mediaLogConfig(kind).Path, kind.defaultPath))
+}
+
+func (a *goBlog) mediaLogTitle(bc *configBlog, kind *mediaLogKind) string {
+	return cmp.Or(bc.mediaLogConfig(kind).Title, a.ts.GetTemplateStringVariant(bc.Lang, "medialog"+kind.name))
+}
+
+// postMediaLogKind returns the media log kind of the post or nil if it's no media log post
+func postMediaLogKind(p *post) *mediaLogKind {
+	kind, _ := lo.Find(mediaLogKinds, func(kind *mediaLogKind) bool {
+		return p.firstParameter(kind.param) != ""
+	})
+	return kind
+}
+
+// mediaLogRating returns the rating of the post between 0 (not rated) and maxMediaLogRating
+func mediaLogRating(p *post) int {
+	return min(max(cast.ToInt(p.firstParameter(mediaLogRatingParam)), 0), maxMediaLogRating)
+}
+
+// mediaLogCite returns the cited book, film or music of the post as microformats h-cite
+func mediaLogCite(p *post, kind *mediaLogKind) map[string]any {
+	properties := map[string]any{
+		"name": []any{p.firstParameter(kind.param)},
+	}
+	if author := p.firstParameter(mediaLogAuthorParam); author != "" {
+		properties["author"] = []any{author}
+	}
+	if url := p.firstParameter(mediaLogURLParam); url != "" {
+		properties["url"] = []any{url}
+	}
+	if isbn := p.firstParameter(mediaLogISBNParam); isbn != "" {
+		properties["uid"] = []any{"isbn:" + isbn}
+	}
+	return map[string]any{
+		"type":       []any{"h-cite"},
+		"properties": properties,
+	}
+}
+
+// setMediaLog sets the media log parameters from a Micropub value, which is an h-cite or just a title or URL
+func (s *micropubImplementation) setMediaLog(p *post, kind *mediaLogKind, value any) {
+	for _, param := range []string{kind.param, mediaLogAuthorParam, mediaLogISBNParam, mediaLogURLParam} {
+		delete(p.Parameters, param)
+	}
+	setIfNotEmpty := func(param, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			p.Parameters[param] = []string{value}
+		}
+	}
+	cite, isCite := value.(map[string]any)
+	if !isCite {
+		value := cast.ToString(value)
+		if isAbsoluteURL(value) {
+			setIfNotEmpty(mediaLogURLParam, value)
+		}
+		setIfNotEmpty(kind.param, value)
+		return
+	}
+	url := micropubObjectProperty(cite, "url")
+	setIfNotEmpty(kind.param, cmp.Or(micropubObjectProperty(cite, "name"), url))
+	setIfNotEmpty(mediaLogURLParam, url)
+	// The author can be a string or an h-card
+	author := micropubObjectProperty(cite, "author")
+	if properties, _ := cite["properties"].(map[string]any); author == "" && properties != nil {
+		if card, isCard := lo.FirstOrEmpty(cast.ToSlice(properties["author"])).(map[string]any); isCard {
+			author = micropubObjectProperty(card, "name")
+		}
+	}
+	setIfNotEmpty(mediaLogAuthorParam, author)
+	if uid := micropubObjectProperty(cite, "uid"); uid != "" {
+		if isbn, isISBN := strings.CutPrefix(strings.TrimPrefix(uid, "urn:"), "isbn:"); isISBN {
+			setIfNotEmpty(mediaLogISBNParam, isbn)
+		}
+	}
+	setIfNotEmpty(mediaLogISBNParam, micropubObjectProperty(cite, "isbn"))
+}
+
+type mediaLogStatsRow struct {
+	Year   string
+	Counts map[string]int // Number of posts per media log kind name
+}
+
+const mediaLogStatsSQL = `
+select substr(tolocal(p.published), 1, 4) as year, pp.parameter, count(distinct p.path)
+from posts p join post_parameters pp on p.path = pp.path
+where p.blog = @blog and p.status = @status and p.visibility = @visibility and coalesce(p.published, '') != ''
+and pp.parameter in (%s) and length(coalesce(pp.value, '')) > 0
+group by year, pp.parameter
+order by year desc;
+`
+
+// getMediaLogStats returns the number of published media log posts per year (newest first)
+func (db *database) getMediaLogStats(blog string) ([]*mediaLogStatsRow, error) {
+	args := []any{sql.Named("blog", blog), sql.Named("status", statusPublished), sql.Named("visibility", visibilityPublic)}
+	var paramNames []string
+	for i, kind := range mediaLogKinds {
+		name := "param" + strconv.Itoa(i)
+		paramNames = append(paramNames, "@"+name)
+		args = append(args, sql.Named(name, kind.param))
+	}
+	rows, err := db.Query(fmt.Sprintf(mediaLogStatsSQL, strings.Join(paramNames, ", ")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stats []*mediaLogStatsRow
+	var year, param string
+	var count int
+	for rows.Next() {
+		if err = rows.Scan(&year, &param, &count); err != nil {
+			return nil, err
+		}
+		if len(stats) == 0 || stats[len(stats)-1].Year != year {
+			stats = append(stats, &mediaLogStatsRow{Year: year, Counts: map[string]int{}})
+		}
+		if kind, ok := lo.Find(mediaLogKinds, func(kind *mediaLogKind) bool { return kind.param == param }); ok {
+			stats[len(stats)-1].Counts[kind.name] = count
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.hacdias.com/indielib/micropub"
+)
+
+func Test_mediaLogs(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.cfg.Blogs["default"].MediaLogs = &configMediaLogs{
+		Enabled: true,
+		Read:    &configMediaLog{Path: "/books"},
+	}
+
+	app.d = app.buildRouter()
+
+	mp := app.getMicropubImplementation()
+
+	// Book as h-cite with author as h-card
+	bookLocation, err := mp.Create(&micropub.Request{
+		Type: "h-entry",
+		Properties: map[string][]any{
+			"content":   {"Great book"},
+			"published": {"2024-03-01T10:00:00Z"},
+			"rating":    {"4"},
+			"read-of": {map[string]any{
+				"type": []any{"h-cite"},
+				"properties": map[string]any{
+					"name": []any{"The Hobbit"},
+					"author": []any{map[string]any{
+						"type":       []any{"h-card"},
+						"properties": map[string]any{"name": []any{"J. R. R. Tolkien"}},
+					}},
+					"uid": []any{"isbn:9780261102217"},
+				},
+			}},
+		},
+	})
+	require.NoError(t, err)
+
+	// Film as plain title
+	_, err = mp.Create(&micropub.Request{
+		Type: "h-entry",
+		Properties: map[string][]any{
+			"content":   {"Nice film"},
+			"published": {"2025-01-01T10:00:00Z"},
+			"watch-of":  {"Spirited Away"},
+		},
+	})
+	require.NoError(t, err)
+
+	// Second book in another year, via front matter
+	require.NoError(t, app.createPost(&post{
+		Path:      "/book2",
+		Section:   "posts",
+		Published: "2025-02-01T10:00:00Z",
+		Content:   "Another book",
+		Parameters: map[string][]string{
+			"readof":      {"Dune"},
+			"mediaauthor": {"Frank Herbert"},
+		},
+	}))
+
+	bookPath := strings.TrimPrefix(bookLocation, app.cfg.Server.PublicAddress)
+
+	t.Run("Parameters", func(t *testing.T) {
+		p, err := app.getPost(bookPath)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"The Hobbit"}, p.Parameters["readof"])
+		assert.Equal(t, []string{"J. R. R. Tolkien"}, p.Parameters["mediaauthor"])
+		assert.Equal(t, []string{"9780261102217"}, p.Parameters["isbn"])
+		assert.Equal(t, []string{"4"}, p.Parameters["rating"])
+		assert.Equal(t, 4, mediaLogRating(p))
+
+		// Micropub source returns the h-cite
+		source, err := mp.Source(bookLocation)
+		require.NoError(t, err)
+		properties := source["properties"].(map[string][]any)
+		require.Len(t, properties["read-of"], 1)
+		cite := properties["read-of"][0].(map[string]any)
+		assert.Equal(t, "The Hobbit", micropubObjectProperty(cite, "name"))
+		assert.Equal(t, "isbn:9780261102217", micropubObjectProperty(cite, "uid"))
+	})
+
+	t.Run("Post", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, bookPath, nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+
+		assert.Contains(t, body, "h-cite u-read-of")
+		assert.Contains(t, body, "The Hobbit")
+		assert.Contains(t, body, "J. R. R. Tolkien")
+		assert.Contains(t, body, "isbn:9780261102217")
+		assert.Contains(t, body, "★★★★☆")
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "The Hobbit")
+		assert.Contains(t, body, "Dune")
+		assert.NotContains(t, body, "Spirited Away")
+		assert.Contains(t, body, "/books/2024")
+		assert.Contains(t, body, "/books/2025")
+
+		rec = httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/books/2025", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		body = rec.Body.String()
+		assert.Contains(t, body, "Dune")
+		assert.NotContains(t, body, "The Hobbit")
+
+		rec = httptest.NewRecorder()
+		app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/watches", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "Spirited Away")
+		assert.NotContains(t, rec.Body.String(), "/watches/2024")
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		stats, err := app.db.getMediaLogStats("default")
+		require.NoError(t, err)
+		require.Len(t, stats, 2)
+		assert.Equal(t, "2025", stats[0].Year)
+		assert.Equal(t, map[string]int{"read": 1, "watch": 1}, stats[0].Counts)
+		assert.Equal(t, "2024", stats[1].Year)
+		assert.Equal(t, map[string]int{"read": 1}, stats[1].Counts)
+	})
+}
@@ -0,0 +1,553 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kovidgoyal/imaging"
+	"go.goblog.app/app/pkgs/webpenc"
+)
+
+// EXIF tags which are removed from uploaded images by default, in addition to the GPS information
+var defaultStripExifTags = []string{
+	"Artist", "HostComputer", "MakerNote", "UserComment", "ImageUniqueID", "CameraOwnerName", "BodySerialNumber", "LensSerialNumber",
+}
+
+// EXIF tags which can be configured by name, other tags can be configured by their ID (e.g. 0x9286)
+var exifTagIDs = map[string]uint16{
+	"ImageDescription":  0x010E,
+	"Make":              0x010F,
+	"Model":             0x0110,
+	"Software":          0x0131,
+	"DateTime":          0x0132,
+	"Artist":            0x013B,
+	"HostComputer":      0x013C,
+	"Copyright":         0x8298,
+	"DateTimeOriginal":  0x9003,
+	"DateTimeDigitized": 0x9004,
+	"MakerNote":         0x927C,
+	"UserComment":       0x9286,
+	"ImageUniqueID":     0xA420,
+	"CameraOwnerName":   0xA430,
+	"BodySerialNumber":  0xA431,
+	"LensMake":          0xA433,
+	"LensModel":         0xA434,
+	"LensSerialNumber":  0xA435,
+}
+
+const (
+	exifTagOrientation = 0x0112
+	exifTagExifIFD     = 0x8769
+	exifTagGPSIFD      = 0x8825
+	exifTagInteropIFD  = 0xA005
+)
+
+// Size in bytes of the EXIF value types
+var exifTypeSizes = map[uint16]int64{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8, 13: 4}
+
+var (
+	errInvalidExif  = errors.New("invalid EXIF data")
+	errInvalidImage = errors.New("invalid image data")
+)
+
+func (a *goBlog) mediaMetadataStrippingEnabled() bool {
+	ms := a.cfg.Micropub.MediaStorage
+	return ms == nil || !ms.KeepMetadata
+}
+
+// mediaStripExifTags returns the IDs of the EXIF tags to remove (the GPS information is always removed)
+func (a *goBlog) mediaStripExifTags() map[uint16]bool {
+	names := defaultStripExifTags
+	if ms := a.cfg.Micropub.MediaStorage; ms != nil && ms.StripExifTags != nil {
+		names = ms.StripExifTags
+	}
+	tags := map[uint16]bool{}
+	for _, name := range names {
+		if id, ok := exifTagIDs[name]; ok {
+			tags[id] = true
+		} else if id, err := strconv.ParseUint(name, 0, 16); err == nil {
+			tags[uint16(id)] = true
+		} else {
+			a.error("Unknown EXIF tag configured to strip", "tag", name)
+		}
+	}
+	return tags
+}
+
+func isMetadataStrippableExtension(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".jpg", ".jpeg", ".png", ".webp", ".heic", ".heif":
+		return true
+	default:
+		return false
+	}
+}
+
+// stripMediaFileMetadata returns a reader for the file without GPS information and other personal metadata, if it's an image
+func (a *goBlog) stripMediaFileMetadata(filename string, f io.Reader) (io.Reader, error) {
+	if !a.mediaMetadataStrippingEnabled() || !isMetadataStrippableExtension(filepath.Ext(filename)) {
+		return f, nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	stripped, err := stripImageMetadata(data, a.mediaStripExifTags())
+	if err != nil {
+		return nil, fmt.Errorf("failed to strip metadata of %s: %w", filename, err)
+	}
+	return bytes.NewReader(stripped), nil
+}
+
+// stripStoredMediaMetadata strips the metadata of all images already in the media storage and returns the number of changed files
+func (a *goBlog) stripStoredMediaMetadata(dryRun bool) (int, error) {
+	files, err := a.mediaFiles()
+	if err != nil {
+		return 0, fmt.Errorf("failed to list media files: %w", err)
+	}
+	tags := a.mediaStripExifTags()
+	changed := 0
+	for _, f := range files {
+		if !isMetadataStrippableExtension(filepath.Ext(f.Name)) {
+			continue
+		}
+		rc, err := a.mediaStorage.open(f.Name)
+		if err != nil {
+			return changed, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		data, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return changed, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		stripped, err := stripImageMetadata(data, tags)
+		if err != nil {
+			a.error("Failed to strip metadata", "file", f.Name, "err", err)
+			continue
+		}
+		if bytes.Equal(stripped, data) {
+			continue
+		}
+		changed++
+		a.info("Stripping metadata", "file", f.Name, "dryRun", dryRun)
+		if dryRun {
+			continue
+		}
+		if _, err = a.mediaStorage.save(f.Name, bytes.NewReader(stripped)); err != nil {
+			return changed, fmt.Errorf("failed to save %s: %w", f.Name, err)
+		}
+	}
+	return changed, nil
+}
+
+// stripImageMetadata removes the GPS information, the given EXIF tags and other personal metadata (XMP, IPTC, comments)
+// from JPEG, PNG, WebP and HEIC images. Other data is returned unchanged.
+// JPEG, PNG and still WebP images get rotated according to their EXIF orientation, because the orientation is reset.
+// HEIC images get the rotation and mirroring properties of HEIF instead.
+func stripImageMetadata(data []byte, tags map[uint16]bool) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		return stripJPEGMetadata(data, tags)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNGMetadata(data, tags)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return stripWebPMetadata(data, tags)
+	case len(data) >= 12 && string(data[4:8]) == "ftyp":
+		return stripHEIFMetadata(data, tags)
+	default:
+		return data, nil
+	}
+}
+
+// scrubExif removes the GPS information and the given tags from the TIFF structured EXIF data in place, keeping its length,
+// and returns the orientation. If resetOrientation is true, the orientation is set to normal.
+func scrubExif(data []byte, tags map[uint16]bool, resetOrientation bool) (int, error) {
+	if len(data) < 8 {
+		return 0, errInvalidExif
+	}
+	s := &exifScrubber{data: data, tags: tags, resetOrientation: resetOrientation, visited: map[uint32]bool{}}
+	switch string(data[:2]) {
+	case "II":
+		s.order = binary.LittleEndian
+	case "MM":
+		s.order = binary.BigEndian
+	default:
+		return 0, errInvalidExif
+	}
+	// IFD0 and the following IFDs (e.g. the thumbnail)
+	for offset := s.order.Uint32(data[4:]); offset != 0; {
+		next, err := s.scrubIFD(offset, false)
+		if err != nil {
+			return 0, err
+		}
+		offset = next
+	}
+	return s.orientation, nil
+}
+
+type exifScrubber struct {
+	data             []byte
+	order            binary.ByteOrder
+	tags             map[uint16]bool
+	resetOrientation bool
+	orientation      int
+	visited          map[uint32]bool
+}
+
+// scrubIFD removes the tags from the IFD at the offset by moving the remaining entries together
+// and zeroing the freed space and values. If clearAll is true, the whole IFD is zeroed.
+func (s *exifScrubber) scrubIFD(offset uint32, clearAll bool) (uint32, error) {
+	if s.visited[offset] {
+		return 0, errInvalidExif
+	}
+	s.visited[offset] = true
+	start := int64(offset)
+	if start+2 > int64(len(s.data)) {
+		return 0, errInvalidExif
+	}
+	count := int64(s.order.Uint16(s.data[start:]))
+	end := start + 2 + count*12
+	if end+4 > int64(len(s.data)) {
+		return 0, errInvalidExif
+	}
+	next := s.order.Uint32(s.data[end:])
+	var kept int64
+	for i := range count {
+		entry := s.data[start+2+i*12 : start+2+(i+1)*12]
+		tag := s.order.Uint16(entry)
+		remove := clearAll || s.tags[tag] || tag == exifTagGPSIFD
+		switch tag {
+		case exifTagExifIFD, exifTagGPSIFD, exifTagInteropIFD:
+			if _, err := s.scrubIFD(s.order.Uint32(entry[8:]), remove); err != nil {
+				return 0, err
+			}
+		case exifTagOrientation:
+			if !remove {
+				s.orientation = int(s.order.Uint16(entry[8:]))
+				if s.resetOrientation {
+					s.order.PutUint16(entry[8:], 1)
+				}
+			}
+		}
+		if remove {
+			s.clearValue(entry)
+			continue
+		}
+		copy(s.data[start+2+kept*12:], entry)
+		kept++
+	}
+	if clearAll {
+		clear(s.data[start : end+4])
+		return next, nil
+	}
+	newEnd := start + 2 + kept*12
+	s.order.PutUint16(s.data[start:], uint16(kept))
+	s.order.PutUint32(s.data[newEnd:], next)
+	clear(s.data[newEnd+4 : end+4])
+	return next, nil
+}
+
+// clearValue zeroes the value of the IFD entry if it's stored outside of the entry
+func (s *exifScrubber) clearValue(entry []byte) {
+	size := exifTypeSizes[s.order.Uint16(entry[2:])] * int64(s.order.Uint32(entry[4:]))
+	if size <= 4 {
+		return
+	}
+	if offset := int64(s.order.Uint32(entry[8:])); offset+size <= int64(len(s.data)) {
+		clear(s.data[offset : offset+size])
+	}
+}
+
+// applyExifOrientation transforms the image so it's shown correctly without the EXIF orientation
+func applyExifOrientation(img image.Image, orientation int) image.Image {
+	switch orientation {
+	case 2:
+		return imaging.FlipH(img)
+	case 3:
+		return imaging.Rotate180(img)
+	case 4:
+		return imaging.FlipV(img)
+	case 5:
+		return imaging.Transpose(img)
+	case 6:
+		return imaging.Rotate270(img)
+	case 7:
+		return imaging.Transverse(img)
+	case 8:
+		return imaging.Rotate90(img)
+	default:
+		return img
+	}
+}
+
+// decodeOriented decodes the image and rotates it according to the orientation,
+// the colors aren't converted, so a kept color profile still applies
+func decodeOriented(data []byte, orientation int) (image.Image, error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(false), imaging.ColorSpace(imaging.NO_CHANGE_OF_COLORSPACE))
+	if err != nil {
+		return nil, err
+	}
+	return applyExifOrientation(img, orientation), nil
+}
+
+// reencodeOriented decodes the image, rotates it according to the orientation and encodes it again (without any metadata)
+func reencodeOriented(data []byte, orientation int, format imaging.Format) ([]byte, error) {
+	img, err := decodeOriented(data, orientation)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err = imaging.Encode(&buf, img, format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var (
+	jpegExifPrefix = []byte("Exif\x00\x00")
+	jpegXMPPrefix  = []byte("http://ns.adobe.com/") // XMP and extended XMP
+	jpegMPFPrefix  = []byte("MPF\x00")
+)
+
+const (
+	jpegMarkerSOS   = 0xDA
+	jpegMarkerEOI   = 0xD9
+	jpegMarkerAPP0  = 0xE0
+	jpegMarkerAPP1  = 0xE1
+	jpegMarkerAPP2  = 0xE2
+	jpegMarkerIPTC  = 0xED // APP13
+	jpegMarkerAdobe = 0xEE // APP14
+	jpegMarkerAPP15 = 0xEF
+	jpegMarkerCOM   = 0xFE
+)
+
+// jpegKeepWhenReencoding checks if the kept application segment still applies to the rotated and re-encoded image,
+// the Adobe segment describes the old color encoding and the MPF segment points to images after the old image data
+func jpegKeepWhenReencoding(marker byte, payload []byte) bool {
+	if marker < jpegMarkerAPP0 || marker > jpegMarkerAPP15 || marker == jpegMarkerAdobe {
+		return false
+	}
+	return marker != jpegMarkerAPP2 || !bytes.HasPrefix(payload, jpegMPFPrefix)
+}
+
+func stripJPEGMetadata(data []byte, tags map[uint16]bool) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:2]...)
+	var appSegments [][]byte
+	orientation := 0
+	for pos := 2; ; {
+		if pos+2 > len(data) || data[pos] != 0xFF {
+			return nil, errInvalidImage
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			// Image data
+			out = append(out, data[pos:]...)
+			break
+		}
+		if pos+4 > len(data) {
+			return nil, errInvalidImage
+		}
+		end := pos + 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+		if end < pos+4 || end > len(data) {
+			return nil, errInvalidImage
+		}
+		segment, payload := data[pos:end], data[pos+4:end]
+		pos = end
+		switch {
+		case marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, jpegExifPrefix):
+			segment = bytes.Clone(segment)
+			o, err := scrubExif(segment[4+len(jpegExifPrefix):], tags, true)
+			if err != nil {
+				// Drop EXIF data that can't be parsed
+				continue
+			}
+			orientation = o
+		case marker == jpegMarkerAPP1 && bytes.HasPrefix(payload, jpegXMPPrefix), marker == jpegMarkerIPTC, marker == jpegMarkerCOM:
+			continue
+		}
+		if jpegKeepWhenReencoding(marker, payload) {
+			appSegments = append(appSegments, segment)
+		}
+		out = append(out, segment...)
+	}
+	if orientation <= 1 {
+		return out, nil
+	}
+	// Rotate the pixels and add the kept application segments (like the EXIF data with reset orientation and the ICC profile) again
+	reencoded, err := reencodeOriented(data, orientation, imaging.JPEG)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Join(slices.Concat([][]byte{reencoded[:2]}, appSegments, [][]byte{reencoded[2:]}), nil), nil
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// PNG chunks which still apply to the rotated and re-encoded image (color information and pixel size)
+var pngReencodeChunks = []string{"cHRM", "cICP", "gAMA", "iCCP", "sBIT", "sRGB", "pHYs"}
+
+func stripPNGMetadata(data []byte, tags map[uint16]bool) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	var keptChunks [][]byte
+	orientation := 0
+	for pos := len(pngSignature); pos < len(data); {
+		if pos+12 > len(data) {
+			return nil, errInvalidImage
+		}
+		length := int64(binary.BigEndian.Uint32(data[pos:]))
+		end := int64(pos) + 12 + length
+		if end > int64(len(data)) {
+			return nil, errInvalidImage
+		}
+		chunk := data[pos:end]
+		pos = int(end)
+		chunkType := string(chunk[4:8])
+		switch chunkType {
+		case "eXIf":
+			chunk = bytes.Clone(chunk)
+			o, err := scrubExif(chunk[8:8+length], tags, true)
+			if err != nil {
+				continue
+			}
+			binary.BigEndian.PutUint32(chunk[8+length:], crc32.ChecksumIEEE(chunk[4:8+length]))
+			orientation = o
+			keptChunks = append(keptChunks, chunk)
+		case "tEXt", "zTXt", "iTXt":
+			// Text chunks (including XMP)
+			continue
+		}
+		if slices.Contains(pngReencodeChunks, chunkType) {
+			keptChunks = append(keptChunks, chunk)
+		}
+		out = append(out, chunk...)
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	if orientation <= 1 {
+		return out, nil
+	}
+	// Rotate the pixels and add the kept chunks (like the EXIF data with reset orientation and the color profile) after the header again
+	reencoded, err := reencodeOriented(data, orientation, imaging.PNG)
+	if err != nil {
+		return nil, err
+	}
+	headerEnd := len(pngSignature) + 12 + 13 // IHDR chunk has 13 bytes of data
+	return bytes.Join(slices.Concat([][]byte{reencoded[:headerEnd]}, keptChunks, [][]byte{reencoded[headerEnd:]}), nil), nil
+}
+
+const (
+	webpFlagICC   = 0x20
+	webpFlagAlpha = 0x10
+	webpFlagEXIF  = 0x08
+	webpFlagXMP   = 0x04
+)
+
+// stripWebPMetadata removes the metadata of WebP images. Still images get rotated according to their EXIF orientation
+// and re-encoded losslessly, animated images keep the orientation.
+func stripWebPMetadata(data []byte, tags map[uint16]bool) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:12]...)
+	flagsPos, removedFlags := -1, byte(0)
+	var iccChunk, exifChunk, exifData []byte
+	orientation, animated := 0, false
+	for pos := 12; pos < len(data); {
+		if pos+8 > len(data) {
+			return nil, errInvalidImage
+		}
+		size := int64(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := min(int64(pos)+8+size+size%2, int64(len(data)))
+		if int64(pos)+8+size > int64(len(data)) {
+			return nil, errInvalidImage
+		}
+		chunk := data[pos:end]
+		pos = int(end)
+		switch string(chunk[:4]) {
+		case "VP8X":
+			flagsPos = len(out) + 8
+		case "ICCP":
+			iccChunk = chunk
+		case "ANIM", "ANMF":
+			animated = true
+		case "EXIF":
+			chunk = bytes.Clone(chunk)
+			exifData = chunk[8 : 8+size]
+			if bytes.HasPrefix(exifData, jpegExifPrefix) {
+				exifData = exifData[len(jpegExifPrefix):]
+			}
+			var err error
+			if orientation, err = scrubExif(exifData, tags, false); err != nil {
+				removedFlags |= webpFlagEXIF
+				exifData, orientation = nil, 0
+				continue
+			}
+			exifChunk = chunk
+		case "XMP ":
+			removedFlags |= webpFlagXMP
+			continue
+		}
+		out = append(out, chunk...)
+	}
+	if flagsPos >= 0 && flagsPos < len(out) {
+		out[flagsPos] &^= removedFlags
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	if orientation <= 1 || animated {
+		return out, nil
+	}
+	return rotateWebP(data, orientation, iccChunk, exifChunk, exifData, tags)
+}
+
+// rotateWebP rotates the still WebP image according to the orientation and encodes it losslessly
+// with the kept color profile and EXIF chunk, whose orientation gets reset
+func rotateWebP(data []byte, orientation int, iccChunk, exifChunk, exifData []byte, tags map[uint16]bool) ([]byte, error) {
+	img, err := decodeOriented(data, orientation)
+	if err != nil {
+		return nil, err
+	}
+	bitstream, err := webpenc.EncodeBitstream(img)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = scrubExif(exifData, tags, true); err != nil {
+		return nil, err
+	}
+	flags := byte(webpFlagEXIF)
+	if iccChunk != nil {
+		flags |= webpFlagICC
+	}
+	if bitstream[4]&0x10 != 0 {
+		// The alpha hint of the lossless header
+		flags |= webpFlagAlpha
+	}
+	vp8x := []byte("VP8X\x0a\x00\x00\x00")
+	vp8x = append(vp8x, flags, 0, 0, 0)
+	size := img.Bounds().Size()
+	for _, v := range []int{size.X - 1, size.Y - 1} {
+		vp8x = append(vp8x, byte(v), byte(v>>8), byte(v>>16))
+	}
+	vp8l := binary.LittleEndian.AppendUint32([]byte("VP8L"), uint32(len(bitstream)))
+	vp8l = append(vp8l, bitstream...)
+	if len(bitstream)%2 == 1 {
+		vp8l = append(vp8l, 0)
+	}
+	out := slices.Concat([]byte("RIFF\x00\x00\x00\x00WEBP"), vp8x, iccChunk, vp8l, exifChunk)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"strings"
+)
+
+var errUnsupportedHEIF = errors.New("unsupported HEIF structure")
+
+// stripHEIFMetadata removes the GPS information and tags from the EXIF items and clears the XMP and IPTC items of HEIF images,
+// the EXIF orientation is replaced with the rotation and mirroring properties of the primary image (the pixels stay untouched)
+func stripHEIFMetadata(data []byte, tags map[uint16]bool) ([]byte, error) {
+	data = bytes.Clone(data)
+	orientation, err := scrubHEIFMetadata(data, tags, false)
+	if err != nil {
+		return nil, err
+	}
+	if orientation <= 1 {
+		return data, nil
+	}
+	rotated, err := heifAddOrientation(data, orientation)
+	if errors.Is(err, errUnsupportedHEIF) {
+		// Keep the EXIF orientation if the properties can't be added
+		return data, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err = scrubHEIFMetadata(rotated, tags, true); err != nil {
+		return nil, err
+	}
+	return rotated, nil
+}
+
+// scrubHEIFMetadata strips the metadata items in place and returns the orientation from the EXIF item
+func scrubHEIFMetadata(data []byte, tags map[uint16]bool, resetOrientation bool) (int, error) {
+	meta := findHEIFBox(data, "meta")
+	if len(meta) < 4 {
+		return 0, nil
+	}
+	children := meta[4:] // Skip version and flags
+	iinf, iloc := findHEIFBox(children, "iinf"), findHEIFBox(children, "iloc")
+	if iinf == nil || iloc == nil {
+		return 0, nil
+	}
+	exifItems, otherItems := heifMetadataItems(iinf)
+	if len(exifItems) == 0 && len(otherItems) == 0 {
+		return 0, nil
+	}
+	locations, err := heifItemLocations(iloc)
+	if err != nil {
+		return 0, err
+	}
+	orientation := 0
+	for id, extents := range locations {
+		if !exifItems[id] && !otherItems[id] {
+			continue
+		}
+		for _, e := range extents {
+			if e.offset > uint64(len(data)) || e.length > uint64(len(data))-e.offset {
+				return 0, errInvalidImage
+			}
+		}
+		if otherItems[id] || len(extents) != 1 {
+			// XMP, IPTC and EXIF items with multiple extents aren't parsed, just cleared
+			for _, e := range extents {
+				clear(data[e.offset : e.offset+e.length])
+			}
+			continue
+		}
+		item := data[extents[0].offset : extents[0].offset+extents[0].length]
+		if len(item) < 4 {
+			continue
+		}
+		// The item starts with the offset of the TIFF header
+		tiffStart := 4 + uint64(binary.BigEndian.Uint32(item))
+		if tiffStart > uint64(len(item)) {
+			clear(item[4:])
+			continue
+		}
+		itemOrientation, err := scrubExif(item[tiffStart:], tags, resetOrientation)
+		if err != nil {
+			clear(item[4:])
+			continue
+		}
+		if orientation == 0 {
+			orientation = itemOrientation
+		}
+	}
+	return orientation, nil
+}
+
+// heifOrientationTransforms maps the EXIF orientations to the rotation (irot, anti-clockwise in steps of 90 degrees)
+// and the mirroring (imir, 0 flips top to bottom and 1 left to right like libheif, -1 doesn't mirror),
+// the mirroring is applied after the rotation
+var heifOrientationTransforms = map[int][2]int{
+	2: {0, 1},
+	3: {2, -1},
+	4: {0, 0},
+	5: {1, 0},
+	6: {3, -1},
+	7: {3, 0},
+	8: {1, -1},
+}
+
+// heifAddOrientation adds the rotation and mirroring properties for the EXIF orientation to the primary image and returns the new file,
+// the file stays unchanged if the primary image already has such properties
+func heifAddOrientation(data []byte, orientation int) ([]byte, error) {
+	transform, ok := heifOrientationTransforms[orientation]
+	if !ok {
+		return data, nil
+	}
+	metaStart, metaEnd, meta := heifBoxRange(data, "meta")
+	if len(meta) < 4 {
+		return nil, errUnsupportedHEIF
+	}
+	children := meta[4:]
+	pitm, iprp := findHEIFBox(children, "pitm"), findHEIFBox(children, "iprp")
+	if len(pitm) < 6 || iprp == nil {
+		return nil, errUnsupportedHEIF
+	}
+	primary := uint32(binary.BigEndian.Uint16(pitm[4:]))
+	if pitm[0] != 0 {
+		if len(pitm) < 8 {
+			return nil, errUnsupportedHEIF
+		}
+		primary = binary.BigEndian.Uint32(pitm[4:])
+	}
+	ipco := findHEIFBox(iprp, "ipco")
+	if ipco == nil {
+		return nil, errUnsupportedHEIF
+	}
+	var propertyTypes []string
+	eachHEIFBox(ipco, func(boxType string, _ []byte, _, _ int) bool {
+		propertyTypes = append(propertyTypes, boxType)
+		return true
+	})
+	// New properties, the rotation has to come before the mirroring
+	newProperties := [][]byte{ipco}
+	var newIndexes []int
+	if transform[0] != 0 {
+		newProperties = append(newProperties, heifBox("irot", []byte{byte(transform[0])}))
+		newIndexes = append(newIndexes, len(propertyTypes)+len(newIndexes)+1)
+	}
+	if transform[1] >= 0 {
+		newProperties = append(newProperties, heifBox("imir", []byte{byte(transform[1])}))
+		newIndexes = append(newIndexes, len(propertyTypes)+len(newIndexes)+1)
+	}
+	// Rebuild the item properties with the new associations
+	var iprpChildren [][]byte
+	associated, transformed := false, false
+	var assocErr error
+	eachHEIFBox(iprp, func(boxType string, content []byte, start, end int) bool {
+		switch boxType {
+		case "ipco":
+			iprpChildren = append(iprpChildren, heifBox("ipco", newProperties...))
+		case "ipma":
+			newIPMA, found, alreadyTransformed, err := heifAssociate(content, primary, propertyTypes, newIndexes)
+			if err != nil {
+				assocErr = err
+				return false
+			}
+			associated, transformed = associated || found, transformed || alreadyTransformed
+			iprpChildren = append(iprpChildren, heifBox("ipma", newIPMA))
+		default:
+			iprpChildren = append(iprpChildren, iprp[start:end])
+		}
+		return true
+	})
+	if assocErr != nil {
+		return nil, assocErr
+	}
+	if transformed {
+		return data, nil
+	}
+	if !associated {
+		return nil, errUnsupportedHEIF
+	}
+	// Rebuild the meta box
+	metaChildren := [][]byte{meta[:4]}
+	var iloc []byte
+	ilocIndex := 0
+	eachHEIFBox(children, func(boxType string, content []byte, start, end int) bool {
+		switch boxType {
+		case "iprp":
+			metaChildren = append(metaChildren, heifBox("iprp", iprpChildren...))
+		case "iloc":
+			iloc, ilocIndex = bytes.Clone(content), len(metaChildren)
+			metaChildren = append(metaChildren, heifBox("iloc", iloc))
+		default:
+			metaChildren = append(metaChildren, children[start:end])
+		}
+		return true
+	})
+	if iloc == nil {
+		return nil, errUnsupportedHEIF
+	}
+	// The data after the meta box moves, so the item locations have to be shifted
+	delta := uint64(len(heifBox("meta", metaChildren...)) - (metaEnd - metaStart))
+	if err := heifShiftItemLocations(iloc, uint64(metaStart), uint64(metaEnd), delta); err != nil {
+		return nil, err
+	}
+	metaChildren[ilocIndex] = heifBox("iloc", iloc)
+	return slices.Concat(data[:metaStart], heifBox("meta", metaChildren...), data[metaEnd:]), nil
+}
+
+// heifAssociate adds the essential property associations to the item in the item property association box and returns the new content,
+// whether the item was found and whether it already has rotation or mirroring properties
+func heifAssociate(ipma []byte, item uint32, propertyTypes []string, indexes []int) (newIPMA []byte, found, transformed bool, err error) {
+	if len(ipma) < 8 {
+		return nil, false, false, errUnsupportedHEIF
+	}
+	idSize, associationSize := 2, 1
+	if ipma[0] >= 1 {
+		idSize = 4
+	}
+	if ipma[3]&1 != 0 {
+		associationSize = 2
+	}
+	pos := 8
+	for range binary.BigEndian.Uint32(ipma[4:]) {
+		if pos+idSize+1 > len(ipma) {
+			return nil, false, false, errUnsupportedHEIF
+		}
+		id := uint32(binary.BigEndian.Uint16(ipma[pos:]))
+		if idSize == 4 {
+			id = binary.BigEndian.Uint32(ipma[pos:])
+		}
+		countPos := pos + idSize
+		end := countPos + 1 + int(ipma[countPos])*associationSize
+		if end > len(ipma) {
+			return nil, false, false, errUnsupportedHEIF
+		}
+		if id != item {
+			pos = end
+			continue
+		}
+		for i := countPos + 1; i < end; i += associationSize {
+			index := int(ipma[i] & 0x7F)
+			if associationSize == 2 {
+				index = int(binary.BigEndian.Uint16(ipma[i:]) & 0x7FFF)
+			}
+			if index > 0 && index <= len(propertyTypes) && (propertyTypes[index-1] == "irot" || propertyTypes[index-1] == "imir") {
+				return ipma, true, true, nil
+			}
+		}
+		if int(ipma[countPos])+len(indexes) > 0xFF {
+			return nil, false, false, errUnsupportedHEIF
+		}
+		var associations []byte
+		for _, index := range indexes {
+			switch {
+			case associationSize == 1 && index <= 0x7F:
+				associations = append(associations, 0x80|byte(index))
+			case associationSize == 2 && index <= 0x7FFF:
+				associations = binary.BigEndian.AppendUint16(associations, 0x8000|uint16(index))
+			default:
+				return nil, false, false, errUnsupportedHEIF
+			}
+		}
+		count := []byte{ipma[countPos] + byte(len(indexes))}
+		return slices.Concat(ipma[:countPos], count, ipma[countPos+1:end], associations, ipma[end:]), true, false, nil
+	}
+	return ipma, false, false, nil
+}
+
+// heifBox returns a box of the type with the concatenated content
+func heifBox(boxType string, content ...[]byte) []byte {
+	size := 8
+	for _, c := range content {
+		size += len(c)
+	}
+	box := make([]byte, 0, size)
+	box = binary.BigEndian.AppendUint32(box, uint32(size))
+	box = append(box, boxType...)
+	for _, c := range content {
+		box = append(box, c...)
+	}
+	return box
+}
+
+// findHEIFBox returns the content of the first box of the type
+func findHEIFBox(data []byte, boxType string) []byte {
+	_, _, content := heifBoxRange(data, boxType)
+	return content
+}
+
+// heifBoxRange returns the start, end and content of the first box of the type
+func heifBoxRange(data []byte, boxType string) (start, end int, content []byte) {
+	eachHEIFBox(data, func(t string, c []byte, s, e int) bool {
+		if t == boxType {
+			start, end, content = s, e, c
+			return false
+		}
+		return true
+	})
+	return
+}
+
+// eachHEIFBox calls f with the type, content, start and end of each box until f returns false
+func eachHEIFBox(data []byte, f func(boxType string, content []byte, start, end int) bool) {
+	for pos := uint64(0); pos+8 <= uint64(len(data)); {
+		size, header := uint64(binary.BigEndian.Uint32(data[pos:])), uint64(8)
+		switch size {
+		case 0:
+			// Box extends to the end
+			size = uint64(len(data)) - pos
+		case 1:
+			if pos+16 > uint64(len(data)) {
+				return
+			}
+			size, header = binary.BigEndian.Uint64(data[pos+8:]), 16
+		}
+		if size < header || size > uint64(len(data))-pos {
+			return
+		}
+		if !f(string(data[pos+4:pos+8]), data[pos+header:pos+size], int(pos), int(pos+size)) {
+			return
+		}
+		pos += size
+	}
+}
+
+// heifMetadataItems returns the IDs of the EXIF items and of the other metadata items (XMP and IPTC) from the item information box
+func heifMetadataItems(iinf []byte) (exifItems, otherItems map[uint32]bool) {
+	exifItems, otherItems = map[uint32]bool{}, map[uint32]bool{}
+	if len(iinf) < 6 {
+		return
+	}
+	entriesStart := 6
+	if iinf[0] != 0 {
+		entriesStart = 8
+	}
+	if entriesStart > len(iinf) {
+		return
+	}
+	eachHEIFBox(iinf[entriesStart:], func(boxType string, infe []byte, _, _ int) bool {
+		// Only item info entries version 2 and 3 contain the item type
+		if boxType != "infe" || len(infe) < 4 || infe[0] < 2 {
+			return true
+		}
+		var id uint32
+		typeStart := 8
+		if infe[0] == 2 && len(infe) >= 12 {
+			id = uint32(binary.BigEndian.Uint16(infe[4:]))
+		} else if infe[0] == 3 && len(infe) >= 14 {
+			id, typeStart = binary.BigEndian.Uint32(infe[4:]), 10
+		} else {
+			return true
+		}
+		switch string(infe[typeStart : typeStart+4]) {
+		case "Exif":
+			exifItems[id] = true
+		case "iptc":
+			otherItems[id] = true
+		case "mime":
+			// Item name and content type follow as null-terminated strings
+			fields := bytes.SplitN(infe[typeStart+4:], []byte{0}, 3)
+			if len(fields) < 2 {
+				return true
+			}
+			contentType := strings.ToLower(string(fields[1]))
+			if strings.Contains(contentType, "rdf+xml") || strings.Contains(contentType, "xmp") || strings.Contains(contentType, "iptc") {
+				otherItems[id] = true
+			}
+		}
+		return true
+	})
+	return
+}
+
+type heifExtent struct {
+	offset, length uint64
+}
+
+// heifField is a number in the item location box
+type heifField struct {
+	pos, size int
+	value     uint64
+}
+
+// walkHEIFItemLocations calls f for each item of the item location box
+func walkHEIFItemLocations(iloc []byte, f func(id uint32, constructionMethod uint64, baseOffset heifField, offsets, lengths []heifField)) error {
+	if len(iloc) < 6 {
+		return errInvalidImage
+	}
+	version := iloc[0]
+	offsetSize, lengthSize := int(iloc[4]>>4), int(iloc[4]&0x0F)
+	baseOffsetSize, indexSize := int(iloc[5]>>4), 0
+	if version == 1 || version == 2 {
+		indexSize = int(iloc[5] & 0x0F)
+	}
+	pos := 6
+	var readErr error
+	read := func(n int) heifField {
+		if pos+n > len(iloc) {
+			readErr = errInvalidImage
+			return heifField{}
+		}
+		field := heifField{pos: pos, size: n}
+		for _, b := range iloc[pos : pos+n] {
+			field.value = field.value<<8 | uint64(b)
+		}
+		pos += n
+		return field
+	}
+	idSize := 2
+	if version == 2 {
+		idSize = 4
+	}
+	itemCount := read(idSize).value
+	for i := uint64(0); i < itemCount && readErr == nil; i++ {
+		id := uint32(read(idSize).value)
+		constructionMethod := uint64(0)
+		if version == 1 || version == 2 {
+			constructionMethod = read(2).value & 0x0F
+		}
+		read(2) // Data reference index
+		baseOffset := read(baseOffsetSize)
+		extentCount := read(2).value
+		var offsets, lengths []heifField
+		for j := uint64(0); j < extentCount && readErr == nil; j++ {
+			read(indexSize)
+			offsets, lengths = append(offsets, read(offsetSize)), append(lengths, read(lengthSize))
+		}
+		if readErr == nil {
+			f(id, constructionMethod, baseOffset, offsets, lengths)
+		}
+	}
+	return readErr
+}
+
+// heifItemLocations returns the file extents of the items from the item location box,
+// items stored in other ways (e.g. in the item data box) are skipped
+func heifItemLocations(iloc []byte) (map[uint32][]heifExtent, error) {
+	locations := map[uint32][]heifExtent{}
+	err := walkHEIFItemLocations(iloc, func(id uint32, constructionMethod uint64, baseOffset heifField, offsets, lengths []heifField) {
+		if constructionMethod != 0 {
+			return
+		}
+		extents := make([]heifExtent, 0, len(offsets))
+		for i := range offsets {
+			extents = append(extents, heifExtent{offset: baseOffset.value + offsets[i].value, length: lengths[i].value})
+		}
+		locations[id] = extents
+	})
+	if err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// heifShiftItemLocations moves the file extents after the meta box by delta in place
+func heifShiftItemLocations(iloc []byte, metaStart, metaEnd, delta uint64) error {
+	var shiftErr error
+	shift := func(field heifField) {
+		value := field.value + delta
+		if field.size == 0 || (field.size < 8 && value>>(8*field.size) != 0) {
+			shiftErr = errUnsupportedHEIF
+			return
+		}
+		for i := field.size - 1; i >= 0; i-- {
+			iloc[field.pos+i] = byte(value)
+			value >>= 8
+		}
+	}
+	err := walkHEIFItemLocations(iloc, func(_ uint32, constructionMethod uint64, baseOffset heifField, offsets, lengths []heifField) {
+		if constructionMethod != 0 || len(offsets) == 0 {
+			return
+		}
+		before, after := 0, 0
+		for i, offset := range offsets {
+			start := baseOffset.value + offset.value
+			switch {
+			case start >= metaEnd:
+				after++
+			case start+lengths[i].value <= metaStart:
+				before++
+			default:
+				// Data in the meta box itself
+				shiftErr = errUnsupportedHEIF
+			}
+		}
+		switch {
+		case after == 0:
+		case before == 0 && baseOffset.size > 0:
+			shift(baseOffset)
+		default:
+			for _, offset := range offsets {
+				if baseOffset.value+offset.value >= metaEnd {
+					shift(offset)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return shiftErr
+}
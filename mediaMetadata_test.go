@@ -0,0 +1,368 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kovidgoyal/imaging"
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/webpenc"
+)
+
+// assertStrippedExif checks that the TIFF structured EXIF data has no GPS information and serial number, but the camera and exposure
+func assertStrippedExif(t *testing.T, tiff []byte, orientation int) {
+	t.Helper()
+
+	x, err := exif.Decode(bytes.NewReader(tiff))
+	require.NoError(t, err)
+	_, _, err = x.LatLong()
+	assert.Error(t, err)
+	cameraModel, err := x.Get(exif.Model)
+	require.NoError(t, err)
+	assert.Equal(t, `"Canon EOS R5"`, cameraModel.String())
+	_, err = x.Get(exif.ExposureTime)
+	assert.NoError(t, err)
+	if orientation > 0 {
+		tag, err := x.Get(exif.Orientation)
+		require.NoError(t, err)
+		o, _ := tag.Int(0)
+		assert.Equal(t, orientation, o)
+	}
+	assert.NotContains(t, string(tiff), "SERIAL123456")
+}
+
+func testPNGChunk(chunkType string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func testRIFFChunk(fourCC string, data []byte) []byte {
+	chunk := append([]byte(fourCC), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	chunk = append(chunk, data...)
+	if len(data)%2 == 1 {
+		chunk = append(chunk, 0)
+	}
+	return chunk
+}
+
+func testHEIFBox(boxType string, content ...[]byte) []byte {
+	data := bytes.Join(content, nil)
+	return append(append(binary.BigEndian.AppendUint32(nil, uint32(8+len(data))), boxType...), data...)
+}
+
+func Test_stripImageMetadata(t *testing.T) {
+	app := &goBlog{cfg: createDefaultTestConfig(t)}
+	tags := app.mediaStripExifTags()
+
+	t.Run("JPEG", func(t *testing.T) {
+		data := testJPEGWithExifData(t, testExifTIFF(true, 1), 8, 4)
+		// Add XMP and a comment
+		xmp := append([]byte("http://ns.adobe.com/xap/1.0/\x00"), "<x:xmpmeta>secret location</x:xmpmeta>"...)
+		comment := []byte("secret comment")
+		data = bytes.Join([][]byte{
+			data[:2],
+			{0xFF, 0xE1}, binary.BigEndian.AppendUint16(nil, uint16(2+len(xmp))), xmp,
+			{0xFF, 0xFE}, binary.BigEndian.AppendUint16(nil, uint16(2+len(comment))), comment,
+			data[2:],
+		}, nil)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		assertStrippedExif(t, stripped, 1)
+		assert.NotContains(t, string(stripped), "secret")
+		config, err := jpeg.DecodeConfig(bytes.NewReader(stripped))
+		require.NoError(t, err)
+		assert.Equal(t, 8, config.Width)
+		assert.Equal(t, 4, config.Height)
+
+		// Stripping again doesn't change anything
+		strippedAgain, err := stripImageMetadata(stripped, tags)
+		require.NoError(t, err)
+		assert.Equal(t, stripped, strippedAgain)
+	})
+
+	t.Run("JPEG orientation", func(t *testing.T) {
+		data := testJPEGWithExifData(t, testExifTIFF(true, 6), 8, 4)
+		// Add an ICC profile
+		icc := append([]byte("ICC_PROFILE\x00\x01\x01"), "test profile"...)
+		data = bytes.Join([][]byte{
+			data[:2],
+			{0xFF, 0xE2}, binary.BigEndian.AppendUint16(nil, uint16(2+len(icc))), icc,
+			data[2:],
+		}, nil)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		assertStrippedExif(t, stripped, 1)
+		config, err := jpeg.DecodeConfig(bytes.NewReader(stripped))
+		require.NoError(t, err)
+		assert.Equal(t, 4, config.Width)
+		assert.Equal(t, 8, config.Height)
+		// The ICC profile is kept
+		assert.Contains(t, string(stripped), "ICC_PROFILE\x00\x01\x01test profile")
+	})
+
+	t.Run("PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 4))))
+		encoded := buf.Bytes()
+		headerEnd := len(pngSignature) + 12 + 13
+		data := bytes.Join([][]byte{
+			encoded[:headerEnd],
+			testPNGChunk("eXIf", testExifTIFF(true, 6)),
+			testPNGChunk("tEXt", []byte("Author\x00Jane Doe")),
+			testPNGChunk("gAMA", []byte{0, 0, 0xB1, 0x8F}),
+			encoded[headerEnd:],
+		}, nil)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(stripped), "Jane Doe")
+		config, err := png.DecodeConfig(bytes.NewReader(stripped))
+		require.NoError(t, err)
+		assert.Equal(t, 4, config.Width)
+		assert.Equal(t, 8, config.Height)
+		_, err = png.Decode(bytes.NewReader(stripped))
+		require.NoError(t, err)
+
+		i := bytes.Index(stripped, []byte("eXIf"))
+		require.Greater(t, i, 4)
+		length := binary.BigEndian.Uint32(stripped[i-4:])
+		assertStrippedExif(t, stripped[i+4:i+4+int(length)], 1)
+		// The color information is kept
+		assert.Contains(t, string(stripped), string(testPNGChunk("gAMA", []byte{0, 0, 0xB1, 0x8F})))
+	})
+
+	t.Run("WebP", func(t *testing.T) {
+		vp8x := []byte{webpFlagEXIF | webpFlagXMP, 0, 0, 0, 7, 0, 0, 3, 0, 0}
+		tiff := testExifTIFF(true, 1)
+		chunks := bytes.Join([][]byte{
+			[]byte("WEBP"),
+			testRIFFChunk("VP8X", vp8x),
+			testRIFFChunk("VP8L", []byte{1, 2, 3, 4, 5}),
+			testRIFFChunk("EXIF", tiff),
+			testRIFFChunk("XMP ", []byte("<x:xmpmeta>secret location</x:xmpmeta>")),
+		}, nil)
+		data := append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(chunks)))...), chunks...)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(stripped), "secret")
+		assert.Equal(t, uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:]))
+		assert.Equal(t, byte(webpFlagEXIF), stripped[20])
+		i := bytes.Index(stripped, []byte("EXIF"))
+		require.Greater(t, i, 0)
+		assertStrippedExif(t, stripped[i+8:i+8+len(tiff)], 1)
+	})
+
+	t.Run("WebP orientation", func(t *testing.T) {
+		opaque := image.NewNRGBA(image.Rect(0, 0, 8, 4))
+		for i := range opaque.Pix {
+			opaque.Pix[i] = 0xFF
+		}
+		var buf bytes.Buffer
+		require.NoError(t, webpenc.Encode(&buf, opaque))
+		vp8x := []byte{webpFlagICC | webpFlagEXIF | webpFlagXMP, 0, 0, 0, 7, 0, 0, 3, 0, 0}
+		tiff := testExifTIFF(true, 6)
+		chunks := bytes.Join([][]byte{
+			[]byte("WEBP"),
+			testRIFFChunk("VP8X", vp8x),
+			testRIFFChunk("ICCP", []byte("test profile")),
+			buf.Bytes()[12:],
+			testRIFFChunk("EXIF", tiff),
+			testRIFFChunk("XMP ", []byte("<x:xmpmeta>secret location</x:xmpmeta>")),
+		}, nil)
+		data := append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(chunks)))...), chunks...)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(stripped), "secret")
+		assert.Equal(t, uint32(len(stripped)-8), binary.LittleEndian.Uint32(stripped[4:]))
+		assert.Equal(t, byte(webpFlagICC|webpFlagEXIF), stripped[20])
+		img, err := imaging.Decode(bytes.NewReader(stripped), imaging.ColorSpace(imaging.NO_CHANGE_OF_COLORSPACE))
+		require.NoError(t, err)
+		assert.Equal(t, image.Pt(4, 8), img.Bounds().Size())
+		// The color profile is kept
+		assert.Contains(t, string(stripped), string(testRIFFChunk("ICCP", []byte("test profile"))))
+		i := bytes.Index(stripped, []byte("EXIF"))
+		require.Greater(t, i, 0)
+		assertStrippedExif(t, stripped[i+8:i+8+len(tiff)], 1)
+	})
+
+	t.Run("HEIC", func(t *testing.T) {
+		tiff := testExifTIFF(true, 6)
+		item := append([]byte{0, 0, 0, 0}, tiff...)
+		xmp := []byte("<x:xmpmeta>secret location</x:xmpmeta>")
+		ftyp := testHEIFBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+		meta := func(itemOffset uint32) []byte {
+			iinf := testHEIFBox("iinf", []byte{0, 0, 0, 0, 0, 2},
+				testHEIFBox("infe", []byte{2, 0, 0, 0, 0, 1, 0, 0}, []byte("Exif\x00")),
+				testHEIFBox("infe", []byte{2, 0, 0, 0, 0, 2, 0, 0}, []byte("mime\x00application/rdf+xml\x00")),
+			)
+			iloc := testHEIFBox("iloc",
+				[]byte{0, 0, 0, 0, 0x44, 0x00, 0, 2},
+				[]byte{0, 1, 0, 0, 0, 1},
+				binary.BigEndian.AppendUint32(nil, itemOffset),
+				binary.BigEndian.AppendUint32(nil, uint32(len(item))),
+				[]byte{0, 2, 0, 0, 0, 1},
+				binary.BigEndian.AppendUint32(nil, itemOffset+uint32(len(item))),
+				binary.BigEndian.AppendUint32(nil, uint32(len(xmp))),
+			)
+			return testHEIFBox("meta", []byte{0, 0, 0, 0}, iinf, iloc)
+		}
+		itemOffset := uint32(len(ftyp) + len(meta(0)) + 8)
+		data := bytes.Join([][]byte{ftyp, meta(itemOffset), testHEIFBox("mdat", item, xmp)}, nil)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		require.Len(t, stripped, len(data))
+		assertStrippedExif(t, stripped[itemOffset+4:itemOffset+uint32(len(item))], 6)
+		// The XMP item is cleared
+		assert.NotContains(t, string(stripped), "secret")
+		// The original data isn't changed
+		assert.Contains(t, string(data), "SERIAL123456")
+	})
+
+	t.Run("HEIC orientation", func(t *testing.T) {
+		tiff := testExifTIFF(true, 7)
+		item := append([]byte{0, 0, 0, 0}, tiff...)
+		ftyp := testHEIFBox("ftyp", []byte("heic\x00\x00\x00\x00mif1heic"))
+		meta := func(itemOffset uint32) []byte {
+			pitm := testHEIFBox("pitm", []byte{0, 0, 0, 0, 0, 2})
+			iinf := testHEIFBox("iinf", []byte{0, 0, 0, 0, 0, 2},
+				testHEIFBox("infe", []byte{2, 0, 0, 0, 0, 1, 0, 0}, []byte("Exif\x00")),
+				testHEIFBox("infe", []byte{2, 0, 0, 0, 0, 2, 0, 0}, []byte("hvc1\x00")),
+			)
+			iloc := testHEIFBox("iloc",
+				[]byte{0, 0, 0, 0, 0x44, 0x00, 0, 1},
+				[]byte{0, 1, 0, 0, 0, 1},
+				binary.BigEndian.AppendUint32(nil, itemOffset),
+				binary.BigEndian.AppendUint32(nil, uint32(len(item))),
+			)
+			ispe := testHEIFBox("ispe", []byte{0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 4})
+			ipma := testHEIFBox("ipma", []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 2, 1, 0x81})
+			iprp := testHEIFBox("iprp", testHEIFBox("ipco", ispe), ipma)
+			return testHEIFBox("meta", []byte{0, 0, 0, 0}, pitm, iinf, iloc, iprp)
+		}
+		itemOffset := uint32(len(ftyp) + len(meta(0)) + 8)
+		data := bytes.Join([][]byte{ftyp, meta(itemOffset), testHEIFBox("mdat", item)}, nil)
+
+		stripped, err := stripImageMetadata(data, tags)
+		require.NoError(t, err)
+
+		// The rotation and mirroring are added as essential properties of the primary image
+		iprp := findHEIFBox(findHEIFBox(stripped, "meta")[4:], "iprp")
+		assert.Equal(t, bytes.Join([][]byte{
+			testHEIFBox("ispe", []byte{0, 0, 0, 0, 0, 0, 0, 8, 0, 0, 0, 4}),
+			testHEIFBox("irot", []byte{3}),
+			testHEIFBox("imir", []byte{0}),
+		}, nil), findHEIFBox(iprp, "ipco"))
+		assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 2, 3, 0x81, 0x82, 0x83}, findHEIFBox(iprp, "ipma"))
+		// The item data moved and the orientation is reset
+		delta := uint32(len(stripped) - len(data))
+		assert.Equal(t, uint32(9+9+2), delta) // Two properties and two associations
+		locations, err := heifItemLocations(findHEIFBox(findHEIFBox(stripped, "meta")[4:], "iloc"))
+		require.NoError(t, err)
+		assert.Equal(t, []heifExtent{{offset: uint64(itemOffset + delta), length: uint64(len(item))}}, locations[1])
+		assertStrippedExif(t, stripped[itemOffset+delta+4:itemOffset+delta+uint32(len(item))], 1)
+
+		// Stripping again doesn't add the properties again
+		strippedAgain, err := stripImageMetadata(stripped, tags)
+		require.NoError(t, err)
+		assert.Equal(t, stripped, strippedAgain)
+	})
+
+	t.Run("Other data", func(t *testing.T) {
+		stripped, err := stripImageMetadata([]byte("no image"), tags)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("no image"), stripped)
+
+		_, err = stripImageMetadata([]byte{0xFF, 0xD8, 0x00}, tags)
+		assert.Error(t, err)
+	})
+}
+
+func Test_mediaMetadataStorage(t *testing.T) {
+	storagePath := t.TempDir()
+	app := newAppWithStorage(t, &localMediaStorage{path: storagePath})
+
+	original := testJPEGWithExifData(t, testExifTIFF(true, 1), 8, 8)
+	readStored := func(name string) []byte {
+		data, err := os.ReadFile(filepath.Join(storagePath, name))
+		require.NoError(t, err)
+		return data
+	}
+
+	t.Run("Upload", func(t *testing.T) {
+		_, err := app.saveMediaFile("upload.jpg", bytes.NewReader(original))
+		require.NoError(t, err)
+		stored := readStored("upload.jpg")
+		assertStrippedExif(t, stored, 0)
+
+		// Files that aren't images are stored as they are
+		_, err = app.saveMediaFile("file.jpg", bytes.NewReader([]byte("no image")))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("no image"), readStored("file.jpg"))
+	})
+
+	t.Run("Configured tags", func(t *testing.T) {
+		app.cfg.Micropub.MediaStorage = &configMicropubMedia{StripExifTags: []string{"LensModel", "0xA431"}}
+		defer func() { app.cfg.Micropub.MediaStorage = nil }()
+
+		_, err := app.saveMediaFile("tags.jpg", bytes.NewReader(original))
+		require.NoError(t, err)
+		x, err := exif.Decode(bytes.NewReader(readStored("tags.jpg")))
+		require.NoError(t, err)
+		_, err = x.Get(exif.LensModel)
+		assert.Error(t, err)
+		assert.NotContains(t, string(readStored("tags.jpg")), "SERIAL123456")
+		_, _, err = x.LatLong()
+		assert.Error(t, err)
+	})
+
+	t.Run("Keep metadata", func(t *testing.T) {
+		app.cfg.Micropub.MediaStorage = &configMicropubMedia{KeepMetadata: true}
+		defer func() { app.cfg.Micropub.MediaStorage = nil }()
+
+		_, err := app.saveMediaFile("keep.jpg", bytes.NewReader(original))
+		require.NoError(t, err)
+		assert.Equal(t, original, readStored("keep.jpg"))
+	})
+
+	t.Run("Stored files", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(storagePath, "old.jpg"), original, 0o644))
+
+		changed, err := app.stripStoredMediaMetadata(true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, changed) // old.jpg and keep.jpg
+		assert.Equal(t, original, readStored("old.jpg"))
+
+		changed, err = app.stripStoredMediaMetadata(false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, changed)
+		assertStrippedExif(t, readStored("old.jpg"), 0)
+		assertStrippedExif(t, readStored("keep.jpg"), 0)
+		assert.Equal(t, []byte("no image"), readStored("file.jpg"))
+
+		changed, err = app.stripStoredMediaMetadata(false)
+		require.NoError(t, err)
+		assert.Equal(t, 0, changed)
+	})
+}
@@ -42,6 +42,10 @@ func (a *goBlog) saveMediaFile(filename string, f io.Reader) (string, error) {
 	if a.mediaStorage == nil {
 		return "", errNoMediaStorageConfigured
 	}
+	f, err := a.stripMediaFileMetadata(filename, f)
+	if err != nil {
+		return "", err
+	}
 	loc, err := a.mediaStorage.save(filename, f)
 	if err != nil {
 		return "", err
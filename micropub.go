@@ -83,7 +83,7 @@ func (s *micropubImplementation) getChannels() []micropub.Channel {
 }
 
 func (s *micropubImplementation) getVisibility() []string {
-	return []string{string(visibilityPrivate), string(visibilityUnlisted), string(visibilityPublic)}
+	return []string{string(visibilityPrivate), string(visibilityProtected), string(visibilityUnlisted), string(visibilityPublic)}
 }
 
 func (s *micropubImplementation) getPostStatuses() []string {
@@ -159,6 +159,19 @@ func (s *micropubImplementation) Create(req *micropub.Request) (string, error) {
 	delete(allValues, "photo")
 	delete(allValues, "photo-alt")
 	delete(allValues, "file") // Micropublish.net fix
+	for _, venue := range allValues["checkin"] {
+		s.setCheckin(entry, venue)
+	}
+	delete(allValues, "checkin")
+	for _, kind := range mediaLogKinds {
+		if values := allValues[kind.property]; len(values) > 0 {
+			s.setMediaLog(entry, kind, values[0])
+		}
+		delete(allValues, kind.property)
+	}
+	if locations, ok := allValues["location"]; ok {
+		allValues["location"] = micropubLocations(locations)
+	}
 	// Rest of parameters
 	for key, values := range allValues {
 		values := cast.ToStringSlice(values)
@@ -280,18 +293,25 @@ func (s *micropubImplementation) UploadMedia(file multipart.File, header *multip
 		}
 	}
 	// Generate the file name
-	fileName := fmt.Sprintf("%x%s", hash.Sum(nil), fileExtension)
+	originalHash := fmt.Sprintf("%x", hash.Sum(nil))
+	fileName := originalHash + fileExtension
 	// Save file
 	_, err = file.Seek(0, io.SeekStart)
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to read multipart file", micropub.ErrBadRequest)
 	}
-	location, err := s.a.saveMediaFile(fileName, file)
+	var f io.Reader = file
+	if isImageExtension(fileExtension) {
+		// Extract EXIF data to show in galleries
+		if f, err = s.a.stripMediaExif(originalHash, fileName, file); err != nil {
+			return "", fmt.Errorf("%w: failed to process image", micropub.ErrBadRequest)
+		}
+	}
+	location, err := s.a.saveMediaFile(fileName, f)
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to save original file", micropub.ErrBadRequest)
 	}
 	// Optimize file (when optimization is enabled and configured)
-	originalHash := fmt.Sprintf("%x", hash.Sum(nil))
 	s.a.optimizeMediaFile(originalHash, fileExtension)
 	return location, nil
 }
@@ -312,6 +332,8 @@ func (s *micropubImplementation) mapToParameterName(key string) string {
 		return s.a.cfg.Micropub.AudioParam
 	case "location":
 		return s.a.cfg.Micropub.LocationParam
+	case "rsvp":
+		return s.a.cfg.Micropub.RsvpParam
 	default:
 		return key
 	}
@@ -333,6 +355,18 @@ func (a *goBlog) processContentAndParameters(p *post) error {
 	}
 
 	// Extract specific parameters
+	extractPostFields(p)
+
+	// Add EXIF data of uploaded images
+	a.addImageExif(p)
+
+	a.addImagesToContent(p)
+
+	return nil
+}
+
+// extractPostFields moves the parameters for post fields (like path or status) to the fields
+func extractPostFields(p *post) {
 	extractParam := func(paramName string, field any) {
 		if values, ok := p.Parameters[paramName]; len(values) == 1 && ok {
 			if stringPointer, ok := field.(*string); ok {
@@ -353,9 +387,14 @@ func (a *goBlog) processContentAndParameters(p *post) error {
 	extractParam("status", func(status string) { p.Status = postStatus(status) })
 	extractParam("visibility", func(visibility string) { p.Visibility = postVisibility(visibility) })
 	extractParam("priority", func(priority string) { p.Priority = cast.ToInt(priority) })
+}
 
-	// Add images not in content
+// addImagesToContent adds images not in content (galleries show them separately)
+func (a *goBlog) addImagesToContent(p *post) {
 	images, imageAlts := p.Parameters[a.cfg.Micropub.PhotoParam], p.Parameters[a.cfg.Micropub.PhotoDescriptionParam]
+	if a.isGallery(p) {
+		images = nil
+	}
 	useAlts := len(images) == len(imageAlts)
 	for i, image := range images {
 		if !strings.Contains(p.Content, image) {
@@ -366,8 +405,6 @@ func (a *goBlog) processContentAndParameters(p *post) error {
 			}
 		}
 	}
-
-	return nil
 }
 
 func extractFrontmatter(p *post) error {
@@ -429,6 +466,8 @@ func micropubVisibility(visibility string) postVisibility {
 		return visibilityUnlisted
 	case "private":
 		return visibilityPrivate
+	case "protected":
+		return visibilityProtected
 	default:
 		return visibilityPublic
 	}
@@ -521,9 +560,81 @@ func (s *micropubImplementation) updatePostPropertiesFromMf(p *post, properties
 		}
 	}
 	delete(properties, "post-status")
+	if venues, ok := properties["checkin"]; ok {
+		delete(p.Parameters, s.a.cfg.Micropub.CheckinParam)
+		delete(p.Parameters, s.a.cfg.Micropub.CheckinURLParam)
+		for _, venue := range venues {
+			s.setCheckin(p, venue)
+		}
+	}
+	delete(properties, "checkin")
+	for _, kind := range mediaLogKinds {
+		if values := properties[kind.property]; len(values) > 0 {
+			s.setMediaLog(p, kind, values[0])
+		}
+		delete(properties, kind.property)
+	}
+	if locations, ok := properties["location"]; ok {
+		properties["location"] = micropubLocations(locations)
+	}
 
 	for key, value := range properties {
 		p.Parameters[s.mapToParameterName(key)] = cast.ToStringSlice(value)
 	}
 
 }
+
+// setCheckin sets the check-in parameters from the venue, which is an h-card or just the URL of the venue.
+// The coordinates of the venue are used as location, if the post has no location yet.
+func (s *micropubImplementation) setCheckin(p *post, venue any) {
+	mp := s.a.cfg.Micropub
+	card, isCard := venue.(map[string]any)
+	if !isCard {
+		if url := cast.ToString(venue); url != "" {
+			p.Parameters[mp.CheckinURLParam] = []string{url}
+		}
+		return
+	}
+	if name := micropubObjectProperty(card, "name"); name != "" {
+		p.Parameters[mp.CheckinParam] = []string{name}
+	}
+	if url := micropubObjectProperty(card, "url"); url != "" {
+		p.Parameters[mp.CheckinURLParam] = []string{url}
+	}
+	if location := micropubLocation(card); location != "" && len(p.Parameters[mp.LocationParam]) == 0 {
+		p.Parameters[mp.LocationParam] = []string{location}
+	}
+}
+
+// micropubLocations converts the locations to geo URIs and drops locations without coordinates
+func micropubLocations(locations []any) []any {
+	res := []any{}
+	for _, location := range locations {
+		if l := micropubLocation(location); l != "" {
+			res = append(res, l)
+		}
+	}
+	return res
+}
+
+// micropubLocation returns the location as string, objects (h-geo, h-adr or h-card) are converted to geo URIs
+func micropubLocation(location any) string {
+	object, isObject := location.(map[string]any)
+	if !isObject {
+		return cast.ToString(location)
+	}
+	lat, lon := micropubObjectProperty(object, "latitude"), micropubObjectProperty(object, "longitude")
+	if lat == "" || lon == "" {
+		return ""
+	}
+	return "geo:" + lat + "," + lon
+}
+
+// micropubObjectProperty returns the first value of a property of a microformats object
+func micropubObjectProperty(object map[string]any, name string) string {
+	properties, _ := object["properties"].(map[string]any)
+	if values := cast.ToSlice(properties[name]); len(values) > 0 {
+		return cast.ToString(values[0])
+	}
+	return ""
+}
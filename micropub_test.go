@@ -45,7 +45,7 @@ func Test_micropubQuery(t *testing.T) {
 	testCases := []testCase{
 		{
 			query:      "config",
-			want:       "{\"categories\":[\"test\",\"test2\"],\"channels\":[{\"uid\":\"default\",\"name\":\"default: My Blog\"},{\"uid\":\"default/posts\",\"name\":\"default/posts: posts\"}],\"media-endpoint\":\"http://localhost:8080/micropub/media\",\"post-status\":[\"draft\",\"published\",\"scheduled\"],\"visibility\":[\"private\",\"unlisted\",\"public\"]}\n",
+			want:       "{\"categories\":[\"test\",\"test2\"],\"channels\":[{\"uid\":\"default\",\"name\":\"default: My Blog\"},{\"uid\":\"default/posts\",\"name\":\"default/posts: posts\"}],\"media-endpoint\":\"http://localhost:8080/micropub/media\",\"post-status\":[\"draft\",\"published\",\"scheduled\"],\"visibility\":[\"private\",\"protected\",\"unlisted\",\"public\"]}\n",
 			wantStatus: http.StatusOK,
 		},
 		{
@@ -359,6 +359,96 @@ func Test_micropubUpdate(t *testing.T) {
 	})
 }
 
+func Test_micropubCheckinAndRsvp(t *testing.T) {
+	app := createMicropubTestEnv(t)
+	require.NoError(t, app.initTemplateStrings())
+
+	mp := app.getMicropubImplementation()
+
+	t.Run("Check-in", func(t *testing.T) {
+		// Like OwnYourSwarm
+		location, err := mp.Create(&micropub.Request{
+			Type: "h-entry",
+			Properties: map[string][]any{
+				"content": {"Coffee time"},
+				"checkin": {map[string]any{
+					"type": []any{"h-card"},
+					"properties": map[string]any{
+						"name":      []any{"Café Central"},
+						"url":       []any{"https://foursquare.com/v/123"},
+						"latitude":  []any{52.52},
+						"longitude": []any{13.405},
+					},
+				}},
+				"location": {map[string]any{
+					"type": []any{"h-adr"},
+					"properties": map[string]any{
+						"locality": []any{"Berlin"},
+					},
+				}},
+			},
+		})
+		require.NoError(t, err)
+
+		p, err := app.getPost(strings.TrimPrefix(location, app.cfg.Server.PublicAddress))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Café Central"}, p.Parameters["checkin"])
+		assert.Equal(t, []string{"https://foursquare.com/v/123"}, p.Parameters["checkinurl"])
+		assert.Equal(t, []string{"geo:52.52,13.405"}, p.Parameters["location"])
+
+		html := app.postHTML(&postHTMLOptions{p: p})
+		assert.Contains(t, html, "class=\"p-checkin h-card\"")
+		assert.Contains(t, html, "Café Central")
+		assert.Contains(t, html, "class=\"p-latitude\" value=\"52.520000\"")
+
+		// The venue is returned as h-card and kept on updates
+		source, err := mp.Source(location)
+		require.NoError(t, err)
+		properties := source["properties"].(map[string][]any)
+		require.Len(t, properties["checkin"], 1)
+		assert.Equal(t, "Café Central", micropubObjectProperty(properties["checkin"][0].(map[string]any), "name"))
+
+		_, err = mp.Update(&micropub.Request{
+			URL: location,
+			Updates: micropub.RequestUpdate{
+				Replace: map[string][]any{
+					"content": {"Coffee and cake"},
+				},
+			},
+		})
+		require.NoError(t, err)
+
+		p, err = app.getPost(p.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "Coffee and cake", p.Content)
+		assert.Equal(t, []string{"Café Central"}, p.Parameters["checkin"])
+		assert.Equal(t, []string{"https://foursquare.com/v/123"}, p.Parameters["checkinurl"])
+		assert.Equal(t, []string{"geo:52.52,13.405"}, p.Parameters["location"])
+	})
+
+	t.Run("RSVP", func(t *testing.T) {
+		location, err := mp.Create(&micropub.Request{
+			Type: "h-entry",
+			Properties: map[string][]any{
+				"content":     {"See you there"},
+				"in-reply-to": {"https://example.org/events/1"},
+				"rsvp":        {"yes"},
+			},
+		})
+		require.NoError(t, err)
+
+		p, err := app.getPost(strings.TrimPrefix(location, app.cfg.Server.PublicAddress))
+		require.NoError(t, err)
+		assert.Equal(t, []string{"yes"}, p.Parameters["rsvp"])
+		assert.Equal(t, []string{"https://example.org/events/1"}, p.Parameters["replylink"])
+
+		html := app.postHTML(&postHTMLOptions{p: p})
+		assert.Contains(t, html, "u-in-reply-to")
+		assert.Contains(t, html, "class=\"p-rsvp\" value=\"yes\"")
+		assert.Contains(t, html, "I&#39;m going")
+	})
+}
+
 func Test_extractFrontmatter(t *testing.T) {
 	testCases := []struct {
 		name            string
@@ -242,6 +242,39 @@ func TestNoteMarshaling(t *testing.T) {
 	assert.Equal(t, "Hello, world!", unmarshaled.Content.First().String())
 }
 
+func TestEventMarshaling(t *testing.T) {
+	event := ObjectNew(EventType)
+	event.ID = IRI("https://example.com/events/1")
+	event.Name = NaturalLanguageValues{{Value: "Meetup"}}
+	event.StartTime = time.Date(2026, 11, 3, 18, 0, 0, 0, time.UTC)
+	event.EndTime = time.Date(2026, 11, 3, 21, 0, 0, 0, time.UTC)
+	place := ObjectNew(PlaceType)
+	place.Name = NaturalLanguageValues{{Value: "Café Central"}}
+	event.Location = place
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"startTime":"2026-11-03T18:00:00Z"`)
+
+	var unmarshaled Object
+	err = json.Unmarshal(data, &unmarshaled)
+	require.NoError(t, err)
+
+	assert.Equal(t, EventType, unmarshaled.Type)
+	assert.True(t, event.StartTime.Equal(unmarshaled.StartTime))
+	assert.True(t, event.EndTime.Equal(unmarshaled.EndTime))
+	location, err := ToObject(unmarshaled.Location)
+	require.NoError(t, err)
+	assert.Equal(t, PlaceType, location.Type)
+	assert.Equal(t, "Café Central", location.Name.First().String())
+
+	// Notes have no times
+	data, err = json.Marshal(ObjectNew(NoteType))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "startTime")
+	assert.NotContains(t, string(data), "location")
+}
+
 func TestPersonMarshaling(t *testing.T) {
 	person := PersonNew(IRI("https://example.com/users/alice"))
 	person.Name = NaturalLanguageValues{{Lang: "en", Value: "Alice"}}
@@ -22,6 +22,8 @@ const (
 	ArticleType ActivityType = "Article"
 	// CollectionType is the ActivityPub Collection type.
 	CollectionType ActivityType = "Collection"
+	// EventType is the ActivityPub Event type.
+	EventType ActivityType = "Event"
 	// ImageType is the ActivityPub Image type.
 	ImageType ActivityType = "Image"
 	// MentionType is the ActivityPub Mention type.
@@ -32,6 +34,8 @@ const (
 	ObjectType ActivityType = "Object"
 	// PersonType is the ActivityPub Person type.
 	PersonType ActivityType = "Person"
+	// PlaceType is the ActivityPub Place type.
+	PlaceType ActivityType = "Place"
 	// ServiceType is the ActivityPub Service type.
 	ServiceType ActivityType = "Service"
 	// GroupType is the ActivityPub Group type.
@@ -181,6 +185,9 @@ type Object struct {
 	Attachment   any                   `json:"attachment,omitempty"`
 	Published    time.Time             `json:"published,omitzero"`
 	Updated      time.Time             `json:"updated,omitzero"`
+	StartTime    time.Time             `json:"startTime,omitzero"`
+	EndTime      time.Time             `json:"endTime,omitzero"`
+	Location     Item                  `json:"location,omitempty"`
 }
 
 // GetLink returns the object's ID
@@ -74,6 +74,9 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 		Attachment   any                   `json:"attachment,omitempty"`
 		Published    time.Time             `json:"published,omitzero"`
 		Updated      time.Time             `json:"updated,omitzero"`
+		StartTime    time.Time             `json:"startTime,omitzero"`
+		EndTime      time.Time             `json:"endTime,omitzero"`
+		Location     json.RawMessage       `json:"location,omitempty"`
 	}
 	var r raw
 	if err := json.Unmarshal(data, &r); err != nil {
@@ -102,6 +105,8 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 	o.Attachment = r.Attachment
 	o.Published = r.Published
 	o.Updated = r.Updated
+	o.StartTime = r.StartTime
+	o.EndTime = r.EndTime
 
 	if len(r.AttributedTo) > 0 {
 		item, err := UnmarshalJSON(r.AttributedTo)
@@ -124,6 +129,13 @@ func (o *Object) UnmarshalJSON(data []byte) error {
 		}
 		o.URL = item
 	}
+	if len(r.Location) > 0 {
+		item, err := UnmarshalJSON(r.Location)
+		if err != nil {
+			return err
+		}
+		o.Location = item
+	}
 	if len(r.Href) > 0 {
 		item, err := UnmarshalJSON(r.Href)
 		if err != nil {
@@ -10,6 +10,7 @@ const (
 	ATOM          = "application/atom+xml"
 	CSS           = "text/css"
 	HTML          = "text/html"
+	ICS           = "text/calendar"
 	JPEG          = "image/jpeg"
 	JS            = "application/javascript"
 	JSON          = "application/json"
@@ -28,6 +29,7 @@ const (
 	ASUTF8   = AS + CharsetUtf8Suffix
 	CSSUTF8  = CSS + CharsetUtf8Suffix
 	HTMLUTF8 = HTML + CharsetUtf8Suffix
+	ICSUTF8  = ICS + CharsetUtf8Suffix
 	JSONUTF8 = JSON + CharsetUtf8Suffix
 	JSUTF8   = JS + CharsetUtf8Suffix
 	TextUTF8 = Text + CharsetUtf8Suffix
@@ -0,0 +1,248 @@
+package mathml
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Nodes
+
+var (
+	kindInlineMath = ast.NewNodeKind("InlineMath")
+	kindMathBlock  = ast.NewNodeKind("MathBlock")
+)
+
+// inlineMath is math inside a paragraph like $x^2$ or $$x^2$$
+type inlineMath struct {
+	ast.BaseInline
+	tex     string
+	display bool
+}
+
+func (n *inlineMath) Kind() ast.NodeKind {
+	return kindInlineMath
+}
+
+func (n *inlineMath) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Tex": n.tex}, nil)
+}
+
+// mathBlock is display math in its own block, delimited by $$ lines or a fenced code block with the language math
+type mathBlock struct {
+	ast.BaseBlock
+	tex    strings.Builder
+	closed bool
+}
+
+func (n *mathBlock) Kind() ast.NodeKind {
+	return kindMathBlock
+}
+
+func (n *mathBlock) IsRaw() bool {
+	return true
+}
+
+func (n *mathBlock) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Tex": n.tex.String()}, nil)
+}
+
+// Parsers
+
+var mathDelimiter = []byte("$$")
+
+type inlineParser struct{}
+
+func (*inlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+// Parse follows the Pandoc rules: the opening $ must not be followed by a space,
+// the closing $ must not be preceded by a space and not followed by a digit (so prices like $5 and $10 stay text)
+func (*inlineParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if bytes.HasPrefix(line, mathDelimiter) {
+		end := bytes.Index(line[2:], mathDelimiter)
+		if end < 1 || len(bytes.TrimSpace(line[2:2+end])) == 0 {
+			return nil
+		}
+		block.Advance(4 + end)
+		return &inlineMath{tex: string(line[2 : 2+end]), display: true}
+	}
+	rest := line[1:]
+	if len(rest) == 0 || util.IsSpace(rest[0]) {
+		return nil
+	}
+	for i := 0; i < len(rest); i++ {
+		switch rest[i] {
+		case '\\':
+			// Skip escaped character
+			i++
+		case '$':
+			if util.IsSpace(rest[i-1]) {
+				// Can't be a closing $, but the opening of the next math
+				return nil
+			}
+			if i+1 < len(rest) && rest[i+1] >= '0' && rest[i+1] <= '9' {
+				continue
+			}
+			block.Advance(2 + i)
+			return &inlineMath{tex: string(rest[:i])}
+		}
+	}
+	return nil
+}
+
+type blockParser struct{}
+
+func (*blockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (*blockParser) Open(_ ast.Node, reader text.Reader, pc parser.Context) (ast.Node, parser.State) {
+	line, _ := reader.PeekLine()
+	pos := pc.BlockOffset()
+	if pos < 0 || !bytes.HasPrefix(line[pos:], mathDelimiter) {
+		return nil, parser.NoChildren
+	}
+	rest := bytes.TrimSpace(line[pos+2:])
+	tex, closed := bytes.CutSuffix(rest, mathDelimiter)
+	if bytes.Contains(tex, mathDelimiter) {
+		// Inline math followed by text like $$x$$ and more
+		return nil, parser.NoChildren
+	}
+	node := &mathBlock{}
+	if closed {
+		// Single line like $$x^2$$
+		node.tex.Write(tex)
+		node.closed = true
+		return node, parser.NoChildren
+	}
+	if len(rest) > 0 {
+		node.tex.Write(rest)
+		node.tex.WriteByte('\n')
+	}
+	return node, parser.NoChildren
+}
+
+func (*blockParser) Continue(node ast.Node, reader text.Reader, _ parser.Context) parser.State {
+	n := node.(*mathBlock)
+	if n.closed {
+		return parser.Close
+	}
+	line, _ := reader.PeekLine()
+	reader.AdvanceToEOL()
+	if tex, closed := bytes.CutSuffix(bytes.TrimSpace(line), mathDelimiter); closed {
+		n.tex.Write(tex)
+		return parser.Close
+	}
+	n.tex.Write(line)
+	return parser.Continue | parser.NoChildren
+}
+
+func (*blockParser) Close(_ ast.Node, _ text.Reader, _ parser.Context) {}
+
+func (*blockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (*blockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+// fencedMathTransformer replaces fenced code blocks with the language math with math blocks
+type fencedMathTransformer struct{}
+
+func (*fencedMathTransformer) Transform(doc *ast.Document, reader text.Reader, _ parser.Context) {
+	source := reader.Source()
+	var blocks []*ast.FencedCodeBlock
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if fcb, ok := n.(*ast.FencedCodeBlock); ok && entering && string(fcb.Language(source)) == "math" {
+			blocks = append(blocks, fcb)
+		}
+		return ast.WalkContinue, nil
+	})
+	for _, fcb := range blocks {
+		node := &mathBlock{}
+		for i := range fcb.Lines().Len() {
+			line := fcb.Lines().At(i)
+			node.tex.Write(line.Value(source))
+		}
+		fcb.Parent().ReplaceChild(fcb.Parent(), fcb, node)
+	}
+}
+
+// Renderer
+
+type htmlRenderer struct{}
+
+// RegisterFuncs implements NodeRenderer.RegisterFuncs.
+func (r *htmlRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindInlineMath, r.renderInlineMath)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+func (r *htmlRenderer) renderInlineMath(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*inlineMath)
+	mathML, err := Convert(n.tex, n.display)
+	if err != nil {
+		// Fallback to the source
+		_, _ = w.WriteString(`<code class="math-error">`)
+		_, _ = w.Write(util.EscapeHTML([]byte(n.tex)))
+		_, _ = w.WriteString("</code>")
+		return ast.WalkSkipChildren, nil
+	}
+	_, _ = w.WriteString(mathML)
+	return ast.WalkSkipChildren, nil
+}
+
+func (r *htmlRenderer) renderMathBlock(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*mathBlock)
+	mathML, err := Convert(n.tex.String(), true)
+	if err != nil {
+		// Fallback to the source
+		_, _ = w.WriteString(`<pre class="math-error"><code>`)
+		_, _ = w.Write(util.EscapeHTML([]byte(n.tex.String())))
+		_, _ = w.WriteString("</code></pre>\n")
+		return ast.WalkSkipChildren, nil
+	}
+	_, _ = w.WriteString(mathML)
+	_ = w.WriteByte('\n')
+	return ast.WalkSkipChildren, nil
+}
+
+type math struct{}
+
+// Math is a goldmark.Extender implementation.
+var Math = &math{}
+
+// Extend implements goldmark.Extender.
+func (*math) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		parser.WithBlockParsers(
+			// Before the paragraph parser
+			util.Prioritized(&blockParser{}, 850),
+		),
+		parser.WithInlineParsers(
+			util.Prioritized(&inlineParser{}, 150),
+		),
+		parser.WithASTTransformers(
+			util.Prioritized(&fencedMathTransformer{}, 100),
+		),
+	)
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&htmlRenderer{}, 500),
+	))
+}
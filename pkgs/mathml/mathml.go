@@ -0,0 +1,708 @@
+// Package mathml converts LaTeX math to MathML and provides a goldmark extension for math in Markdown.
+package mathml
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const mathNamespace = "http://www.w3.org/1998/Math/MathML"
+
+// Convert converts a LaTeX math expression to a MathML element, display is used for block math
+func Convert(tex string, display bool) (string, error) {
+	p := &texParser{src: []rune(tex), display: display}
+	rows, err := p.parseRows(false)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString(`<math xmlns="` + mathNamespace + `"`)
+	if display {
+		b.WriteString(` display="block"`)
+	}
+	b.WriteString(` alttext="` + html.EscapeString(strings.TrimSpace(tex)) + `">`)
+	if len(rows) == 1 && len(rows[0]) == 1 {
+		b.WriteString(mrow(rows[0][0]))
+	} else {
+		// Multiple lines
+		b.WriteString(mtable(rows, "", display))
+	}
+	b.WriteString("</math>")
+	return b.String(), nil
+}
+
+type texParser struct {
+	src     []rune
+	pos     int
+	display bool
+	font    string
+}
+
+// Stop tokens for parseList
+const (
+	stopEOF     = ""
+	stopGroup   = "}"
+	stopCell    = "&"
+	stopRow     = `\\`
+	stopEnd     = "end"
+	stopRight   = "right"
+	stopMiddle  = "middle"
+	stopBracket = "]"
+)
+
+func (p *texParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *texParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *texParser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *texParser) skipSpace() {
+	for !p.eof() && unicode.IsSpace(p.peek()) {
+		p.pos++
+	}
+}
+
+// peekCommand returns the name of the command at the current position (without backslash)
+func (p *texParser) peekCommand() string {
+	if p.peek() != '\\' {
+		return ""
+	}
+	end := p.pos + 1
+	for end < len(p.src) && isLetter(p.src[end]) {
+		end++
+	}
+	if end == p.pos+1 && end < len(p.src) {
+		// Single non-letter character like \{ or \,
+		end++
+	}
+	return string(p.src[p.pos+1 : end])
+}
+
+func (p *texParser) readCommand() string {
+	name := p.peekCommand()
+	p.pos += len([]rune(name)) + 1
+	return name
+}
+
+// parseRows parses rows separated by \\ with cells separated by &
+func (p *texParser) parseRows(inEnvironment bool) ([][][]string, error) {
+	rows := [][][]string{}
+	row := [][]string{}
+	for {
+		cell, stop, err := p.parseList(false)
+		if err != nil {
+			return nil, err
+		}
+		row = append(row, cell)
+		switch stop {
+		case stopCell:
+			continue
+		case stopRow:
+			rows = append(rows, row)
+			row = [][]string{}
+			continue
+		case stopEnd:
+			if !inEnvironment {
+				return nil, errors.New(`unexpected \end`)
+			}
+		case stopEOF:
+			if inEnvironment {
+				return nil, errors.New(`missing \end`)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %s", stop)
+		}
+		// Ignore the empty row after a trailing \\
+		if len(row) > 1 || len(row[0]) > 0 || len(rows) == 0 {
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}
+}
+
+// parseList parses elements until a stop token, which is consumed
+func (p *texParser) parseList(optionalArg bool) ([]string, string, error) {
+	list := []string{}
+	for {
+		p.skipSpace()
+		if p.eof() {
+			return list, stopEOF, nil
+		}
+		switch c := p.peek(); c {
+		case '}', '&':
+			p.pos++
+			return list, string(c), nil
+		case ']':
+			if optionalArg {
+				p.pos++
+				return list, stopBracket, nil
+			}
+		case '\\':
+			if p.peekAt(1) == '\\' {
+				p.pos += 2
+				return list, stopRow, nil
+			}
+			if name := p.peekCommand(); name == stopEnd || name == stopRight || name == stopMiddle {
+				p.readCommand()
+				return list, name, nil
+			}
+		}
+		elem, err := p.parseScripted()
+		if err != nil {
+			return nil, "", err
+		}
+		if elem != "" {
+			list = append(list, elem)
+		}
+	}
+}
+
+// parseGroup parses the content of a group after the opening brace
+func (p *texParser) parseGroup() (string, error) {
+	list, stop, err := p.parseList(false)
+	if err != nil {
+		return "", err
+	}
+	if stop != stopGroup {
+		return "", errors.New("missing }")
+	}
+	return mrow(list), nil
+}
+
+// parseArg parses a command argument or script, which is a group or a single token
+func (p *texParser) parseArg() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", errors.New("missing argument")
+	}
+	if p.peek() == '{' {
+		p.pos++
+		return p.parseGroup()
+	}
+	elem, _, err := p.parseAtom(true)
+	return elem, err
+}
+
+// parseOptionalArg parses an optional argument in brackets
+func (p *texParser) parseOptionalArg() (string, bool, error) {
+	p.skipSpace()
+	if p.peek() != '[' {
+		return "", false, nil
+	}
+	p.pos++
+	list, stop, err := p.parseList(true)
+	if err != nil {
+		return "", false, err
+	}
+	if stop != stopBracket {
+		return "", false, errors.New("missing ]")
+	}
+	return mrow(list), true, nil
+}
+
+// readRawArg reads a group without parsing it, for example for \text
+func (p *texParser) readRawArg() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", errors.New("missing argument")
+	}
+	if p.peek() != '{' {
+		p.pos++
+		return string(p.src[p.pos-1]), nil
+	}
+	start, depth := p.pos+1, 0
+	for ; !p.eof(); p.pos++ {
+		switch p.peek() {
+		case '\\':
+			p.pos++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				p.pos++
+				return string(p.src[start : p.pos-1]), nil
+			}
+		}
+	}
+	return "", errors.New("missing }")
+}
+
+// parseScripted parses an element with optional sub- and superscripts
+func (p *texParser) parseScripted() (string, error) {
+	base, limits, err := p.parseAtom(false)
+	if err != nil {
+		return "", err
+	}
+	var sub, sup string
+	primes := 0
+	hasSub, hasSup := false, false
+scripts:
+	for {
+		p.skipSpace()
+		switch p.peek() {
+		case '\'':
+			p.pos++
+			primes++
+		case '^', '_':
+			isSup := p.peek() == '^'
+			if (isSup && hasSup) || (!isSup && hasSub) {
+				return "", errors.New("double script")
+			}
+			p.pos++
+			script, err := p.parseArg()
+			if err != nil {
+				return "", err
+			}
+			if isSup {
+				sup, hasSup = script, true
+			} else {
+				sub, hasSub = script, true
+			}
+		case '\\':
+			switch p.peekCommand() {
+			case "limits":
+				limits = true
+			case "nolimits":
+				limits = false
+			default:
+				break scripts
+			}
+			p.readCommand()
+		default:
+			break scripts
+		}
+	}
+	if primes > 0 {
+		sup, hasSup = mrow([]string{"<mo>" + strings.Repeat("′", primes) + "</mo>", sup}), true
+	}
+	if !hasSub && !hasSup {
+		return base, nil
+	}
+	if base == "" {
+		base = "<mrow></mrow>"
+	}
+	under, over := "munder", "mover"
+	if !limits {
+		under, over = "msub", "msup"
+	}
+	switch {
+	case hasSub && hasSup && limits:
+		return "<munderover>" + base + sub + sup + "</munderover>", nil
+	case hasSub && hasSup:
+		return "<msubsup>" + base + sub + sup + "</msubsup>", nil
+	case hasSub:
+		return "<" + under + ">" + base + sub + "</" + under + ">", nil
+	default:
+		return "<" + over + ">" + base + sup + "</" + over + ">", nil
+	}
+}
+
+// parseAtom parses a single element, limits reports if scripts should be placed under and over it
+func (p *texParser) parseAtom(single bool) (elem string, limits bool, err error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", false, errors.New("unexpected end")
+	}
+	c := p.peek()
+	switch {
+	case c == '{':
+		p.pos++
+		elem, err = p.parseGroup()
+		return elem, false, err
+	case c == '\\':
+		return p.parseCommand()
+	case c == '^' || c == '_':
+		if single {
+			return "", false, errors.New("missing argument")
+		}
+		// Scripts without base
+		return "", false, nil
+	case c == '}' || c == '&':
+		return "", false, fmt.Errorf("unexpected %c", c)
+	case isDigit(c) || (c == '.' && isDigit(p.peekAt(1))):
+		start := p.pos
+		p.pos++
+		for !single && !p.eof() && (isDigit(p.peek()) || (p.peek() == '.' && isDigit(p.peekAt(1)))) {
+			p.pos++
+		}
+		return p.mn(string(p.src[start:p.pos])), false, nil
+	case unicode.IsLetter(c):
+		p.pos++
+		return p.mi(string(c)), false, nil
+	case c == '~':
+		p.pos++
+		return `<mspace width="0.333em"/>`, false, nil
+	case c == '\'':
+		p.pos++
+		return "<mo>′</mo>", false, nil
+	case c == '-':
+		p.pos++
+		return "<mo>−</mo>", false, nil
+	case c == '*':
+		p.pos++
+		return "<mo>∗</mo>", false, nil
+	default:
+		p.pos++
+		return p.mo(string(c)), false, nil
+	}
+}
+
+// parseCommand parses a command at the current position
+func (p *texParser) parseCommand() (elem string, limits bool, err error) {
+	name := p.readCommand()
+	if name == "" {
+		return "", false, errors.New("missing command name")
+	}
+	if font, ok := fontAliases[name]; ok {
+		name = font
+	}
+	if s, ok := identifiers[name]; ok {
+		return p.mi(s), false, nil
+	}
+	if s, ok := uprightIdentifiers[name]; ok {
+		return `<mi mathvariant="normal">` + s + "</mi>", false, nil
+	}
+	if s, ok := operators[name]; ok {
+		return p.mo(s), false, nil
+	}
+	if s, ok := largeOperators[name]; ok {
+		return p.mo(s), p.display, nil
+	}
+	if s, ok := integrals[name]; ok {
+		return p.mo(s), false, nil
+	}
+	if withLimits, ok := functions[name]; ok {
+		fn := name
+		if n, ok := functionNames[name]; ok {
+			fn = n
+		}
+		return "<mi>" + fn + "</mi>", withLimits && p.display, nil
+	}
+	if width, ok := spaces[name]; ok {
+		return `<mspace width="` + width + `"/>`, false, nil
+	}
+	if s, ok := escapedCharacters[name]; ok {
+		return p.mo(s), false, nil
+	}
+	if a, ok := accents[name]; ok {
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		mo := `<mo stretchy="` + fmt.Sprint(a.stretchy) + `">` + html.EscapeString(a.char) + "</mo>"
+		if a.under {
+			return `<munder accentunder="true">` + arg + mo + "</munder>", false, nil
+		}
+		return `<mover accent="true">` + arg + mo + "</mover>", false, nil
+	}
+	if size, ok := bigSizes[name]; ok {
+		delim, err := p.parseDelimiter()
+		if err != nil {
+			return "", false, err
+		}
+		return `<mo minsize="` + size + `" maxsize="` + size + `">` + html.EscapeString(delim) + "</mo>", false, nil
+	}
+	if _, ok := mathFonts[name]; ok || name == "mathrm" {
+		prev := p.font
+		p.font = name
+		defer func() { p.font = prev }()
+		elem, err = p.parseArg()
+		return elem, false, err
+	}
+	switch name {
+	case "frac", "dfrac", "tfrac", "cfrac", "binom":
+		num, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		den, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "binom" {
+			return `<mrow><mo>(</mo><mfrac linethickness="0">` + num + den + "</mfrac><mo>)</mo></mrow>", false, nil
+		}
+		return "<mfrac>" + num + den + "</mfrac>", false, nil
+	case "sqrt":
+		index, hasIndex, err := p.parseOptionalArg()
+		if err != nil {
+			return "", false, err
+		}
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if hasIndex {
+			return "<mroot>" + arg + index + "</mroot>", false, nil
+		}
+		return "<msqrt>" + arg + "</msqrt>", false, nil
+	case "text", "textrm", "textnormal", "textup", "mbox", "textbf", "textit", "texttt", "textsf":
+		text, err := p.readRawArg()
+		if err != nil {
+			return "", false, err
+		}
+		variant := map[string]string{"textbf": "bold", "textit": "italic", "texttt": "monospace", "textsf": "sans-serif"}[name]
+		if variant != "" {
+			return `<mtext mathvariant="` + variant + `">` + html.EscapeString(text) + "</mtext>", false, nil
+		}
+		return "<mtext>" + html.EscapeString(text) + "</mtext>", false, nil
+	case "operatorname":
+		withLimits := false
+		if p.peek() == '*' {
+			p.pos++
+			withLimits = true
+		}
+		text, err := p.readRawArg()
+		if err != nil {
+			return "", false, err
+		}
+		return "<mi>" + html.EscapeString(text) + "</mi>", withLimits && p.display, nil
+	case "overbrace", "underbrace":
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "overbrace" {
+			return `<mover accent="true">` + arg + `<mo stretchy="true">⏞</mo></mover>`, true, nil
+		}
+		return `<munder accentunder="true">` + arg + `<mo stretchy="true">⏟</mo></munder>`, true, nil
+	case "overset", "stackrel", "underset":
+		script, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		base, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		if name == "underset" {
+			return "<munder>" + base + script + "</munder>", false, nil
+		}
+		return "<mover>" + base + script + "</mover>", false, nil
+	case "not":
+		elem, _, err := p.parseAtom(true)
+		if err != nil {
+			return "", false, err
+		}
+		if strings.HasSuffix(elem, "</mo>") {
+			// Add a combining long solidus overlay
+			return strings.TrimSuffix(elem, "</mo>") + "\u0338</mo>", false, nil
+		}
+		return elem, false, nil
+	case "pmod":
+		arg, err := p.parseArg()
+		if err != nil {
+			return "", false, err
+		}
+		return `<mrow><mspace width="0.444em"/><mo>(</mo><mi>mod</mi><mspace width="0.333em"/>` + arg + "<mo>)</mo></mrow>", false, nil
+	case "left":
+		return p.parseLeftRight()
+	case "begin":
+		return p.parseEnvironment()
+	case "displaystyle", "textstyle", "scriptstyle", "limits", "nolimits":
+		// Ignore
+		return "", false, nil
+	}
+	return "", false, fmt.Errorf(`unknown command \%s`, name)
+}
+
+// parseDelimiter parses a delimiter after \left, \right or \big, "." is no delimiter
+func (p *texParser) parseDelimiter() (string, error) {
+	p.skipSpace()
+	if p.eof() {
+		return "", errors.New("missing delimiter")
+	}
+	if p.peek() == '\\' {
+		name := p.readCommand()
+		if s, ok := operators[name]; ok {
+			return s, nil
+		}
+		if s, ok := escapedCharacters[name]; ok {
+			return s, nil
+		}
+		return "", fmt.Errorf(`unknown delimiter \%s`, name)
+	}
+	c := p.peek()
+	p.pos++
+	switch c {
+	case '.':
+		return "", nil
+	case '<':
+		return "⟨", nil
+	case '>':
+		return "⟩", nil
+	case '(', ')', '[', ']', '|', '/':
+		return string(c), nil
+	}
+	return "", fmt.Errorf("unknown delimiter %c", c)
+}
+
+func (p *texParser) parseLeftRight() (string, bool, error) {
+	left, err := p.parseDelimiter()
+	if err != nil {
+		return "", false, err
+	}
+	list := []string{fence(left)}
+	for {
+		inner, stop, err := p.parseList(false)
+		if err != nil {
+			return "", false, err
+		}
+		list = append(list, inner...)
+		delim := ""
+		if stop == stopMiddle || stop == stopRight {
+			if delim, err = p.parseDelimiter(); err != nil {
+				return "", false, err
+			}
+		}
+		switch stop {
+		case stopMiddle:
+			list = append(list, fence(delim))
+		case stopRight:
+			list = append(list, fence(delim))
+			return "<mrow>" + strings.Join(list, "") + "</mrow>", false, nil
+		default:
+			return "", false, errors.New(`missing \right`)
+		}
+	}
+}
+
+func (p *texParser) parseEnvironment() (string, bool, error) {
+	name, err := p.readRawArg()
+	if err != nil {
+		return "", false, err
+	}
+	delims, ok := environments[name]
+	if !ok {
+		return "", false, fmt.Errorf("unknown environment %s", name)
+	}
+	columnAlign := ""
+	switch name {
+	case "array":
+		// Column specification like {lcr}
+		spec, err := p.readRawArg()
+		if err != nil {
+			return "", false, err
+		}
+		var aligns []string
+		for _, c := range spec {
+			if a, ok := map[rune]string{'l': "left", 'c': "center", 'r': "right"}[c]; ok {
+				aligns = append(aligns, a)
+			}
+		}
+		columnAlign = strings.Join(aligns, " ")
+	case "cases":
+		columnAlign = "left left"
+	case "aligned", "align", "align*", "split":
+		columnAlign = "right left right left right left"
+	}
+	// Environments like aligned are in display style
+	display := p.display
+	if !strings.Contains(name, "matrix") && name != "array" && name != "cases" {
+		p.display = true
+	}
+	rows, err := p.parseRows(true)
+	p.display = display
+	if err != nil {
+		return "", false, err
+	}
+	end, err := p.readRawArg()
+	if err != nil {
+		return "", false, err
+	}
+	if end != name {
+		return "", false, fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end)
+	}
+	table := mtable(rows, columnAlign, false)
+	if delims[0] == "" && delims[1] == "" {
+		return table, false, nil
+	}
+	return "<mrow>" + fence(delims[0]) + table + fence(delims[1]) + "</mrow>", false, nil
+}
+
+// Element helpers
+
+func (p *texParser) mi(s string) string {
+	if p.font == "mathrm" {
+		return `<mi mathvariant="normal">` + html.EscapeString(s) + "</mi>"
+	}
+	return "<mi>" + html.EscapeString(p.styled(s)) + "</mi>"
+}
+
+func (p *texParser) mn(s string) string {
+	return "<mn>" + html.EscapeString(p.styled(s)) + "</mn>"
+}
+
+func (p *texParser) mo(s string) string {
+	return "<mo>" + html.EscapeString(s) + "</mo>"
+}
+
+func (p *texParser) styled(s string) string {
+	if p.font == "" {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		return styleRune(p.font, r)
+	}, s)
+}
+
+func fence(delim string) string {
+	if delim == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + html.EscapeString(delim) + "</mo>"
+}
+
+func mrow(list []string) string {
+	list = slices.DeleteFunc(list, func(s string) bool { return s == "" })
+	if len(list) == 1 {
+		return list[0]
+	}
+	return "<mrow>" + strings.Join(list, "") + "</mrow>"
+}
+
+func mtable(rows [][][]string, columnAlign string, display bool) string {
+	var b strings.Builder
+	b.WriteString("<mtable")
+	if columnAlign != "" {
+		b.WriteString(` columnalign="` + columnAlign + `"`)
+	}
+	if display {
+		b.WriteString(` displaystyle="true"`)
+	}
+	b.WriteString(">")
+	for _, row := range rows {
+		b.WriteString("<mtr>")
+		for _, cell := range row {
+			b.WriteString("<mtd>" + mrow(cell) + "</mtd>")
+		}
+		b.WriteString("</mtr>")
+	}
+	b.WriteString("</mtable>")
+	return b.String()
+}
+
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
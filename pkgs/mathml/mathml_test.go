@@ -0,0 +1,86 @@
+package mathml
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yuin/goldmark"
+)
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		tex, contains string
+	}{
+		{`x^2`, `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{`x^10`, `<mrow><msup><mi>x</mi><mn>1</mn></msup><mn>0</mn></mrow>`},
+		{`a_{ij}`, `<msub><mi>a</mi><mrow><mi>i</mi><mi>j</mi></mrow></msub>`},
+		{`\frac{a+b}{c}`, `<mfrac><mrow><mi>a</mi><mo>+</mo><mi>b</mi></mrow><mi>c</mi></mfrac>`},
+		{`\sqrt{2}`, `<msqrt><mn>2</mn></msqrt>`},
+		{`\sqrt[3]{x}`, `<mroot><mi>x</mi><mn>3</mn></mroot>`},
+		{`\alpha + \Omega`, `<mi>α</mi><mo>+</mo><mi mathvariant="normal">Ω</mi>`},
+		{`a \leq b`, `<mo>≤</mo>`},
+		{`\not\in`, `<mo>∉</mo>`},
+		{`\left( x \middle| y \right)`, `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">|</mo><mi>y</mi><mo fence="true" stretchy="true">)</mo></mrow>`},
+		{`\mathbb{R}^n`, `<msup><mi>ℝ</mi><mi>n</mi></msup>`},
+		{`\mathbf{v}`, `<mi>𝐯</mi>`},
+		{`\mathrm{d}x`, `<mi mathvariant="normal">d</mi>`},
+		{`\text{if } x < 0`, `<mtext>if </mtext><mi>x</mi><mo>&lt;</mo><mn>0</mn>`},
+		{`f'(x)`, `<msup><mi>f</mi><mo>′</mo></msup>`},
+		{`\sin x`, `<mi>sin</mi><mi>x</mi>`},
+		{`\hat{x}`, `<mover accent="true"><mi>x</mi><mo stretchy="false">^</mo></mover>`},
+		{`\begin{pmatrix} a & b \\ c & d \end{pmatrix}`, `<mo fence="true" stretchy="true">(</mo><mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable>`},
+		{`|x| = \begin{cases} x & x \geq 0 \\ -x & x < 0 \end{cases}`, `<mtable columnalign="left left">`},
+		{`3.14`, `<mn>3.14</mn>`},
+	} {
+		res, err := Convert(tc.tex, false)
+		require.NoError(t, err, tc.tex)
+		assert.Contains(t, res, tc.contains, tc.tex)
+	}
+}
+
+func TestConvertLimits(t *testing.T) {
+	res, err := Convert(`\sum_{i=1}^n i`, true)
+	require.NoError(t, err)
+	assert.Contains(t, res, `display="block"`)
+	assert.Contains(t, res, `<munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover>`)
+
+	// Inline math uses scripts
+	res, err = Convert(`\sum_{i=1}^n i`, false)
+	require.NoError(t, err)
+	assert.Contains(t, res, `<msubsup><mo>∑</mo>`)
+
+	// Integrals always use scripts
+	res, err = Convert(`\int_0^1 x`, true)
+	require.NoError(t, err)
+	assert.Contains(t, res, `<msubsup><mo>∫</mo><mn>0</mn><mn>1</mn></msubsup>`)
+
+	res, err = Convert(`\lim_{x \to 0}`, true)
+	require.NoError(t, err)
+	assert.Contains(t, res, `<munder><mi>lim</mi>`)
+}
+
+func TestConvertErrors(t *testing.T) {
+	for _, tex := range []string{`\unknown`, `\frac{a}`, `{x`, `x}`, `x^a^b`, `\begin{matrix} a`, `\begin{matrix} a \end{pmatrix}`, `\left( x`} {
+		_, err := Convert(tex, false)
+		assert.Error(t, err, tex)
+	}
+}
+
+func TestMath_Extend(t *testing.T) {
+	md := goldmark.New(
+		goldmark.WithExtensions(Math),
+	)
+
+	var buf bytes.Buffer
+	source := "Prices like $5 and $10 stay text, $x^2$ is math.\n\n$$\n\\frac{1}{2}\n$$\n\n```math\nE = mc^2\n```\n\nInvalid $\\foo$ math.\n"
+	err := md.Convert([]byte(source), &buf)
+	require.NoError(t, err)
+	output := buf.String()
+	assert.Contains(t, output, "Prices like $5 and $10 stay text, <math")
+	assert.Contains(t, output, `alttext="x^2"><msup><mi>x</mi><mn>2</mn></msup></math> is math.`)
+	assert.Contains(t, output, `<math xmlns="http://www.w3.org/1998/Math/MathML" display="block" alttext="\frac{1}{2}"><mfrac><mn>1</mn><mn>2</mn></mfrac></math>`)
+	assert.Contains(t, output, `alttext="E = mc^2"`)
+	assert.Contains(t, output, `Invalid <code class="math-error">\foo</code> math.`)
+}
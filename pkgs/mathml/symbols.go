@@ -0,0 +1,174 @@
+package mathml
+
+// Identifiers like Greek letters and other symbols
+var identifiers = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ",
+	"lambda": "λ", "mu": "μ", "nu": "ν", "xi": "ξ", "omicron": "ο", "pi": "π", "varpi": "ϖ",
+	"rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς", "tau": "τ", "upsilon": "υ",
+	"phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "varnothing": "∅",
+	"hbar": "ℏ", "ell": "ℓ", "aleph": "ℵ", "Re": "ℜ", "Im": "ℑ", "wp": "℘",
+	"imath": "ı", "jmath": "ȷ", "top": "⊤", "bot": "⊥", "angle": "∠", "triangle": "△",
+	"prime": "′", "degree": "°", "checkmark": "✓", "square": "□", "Box": "□",
+	"clubsuit": "♣", "diamondsuit": "♢", "heartsuit": "♡", "spadesuit": "♠",
+	"sharp": "♯", "flat": "♭", "natural": "♮",
+}
+
+// Upright identifiers like uppercase Greek letters
+var uprightIdentifiers = map[string]string{
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+// Operators, relations, arrows and delimiters
+var operators = map[string]string{
+	// Binary operators
+	"pm": "±", "mp": "∓", "times": "×", "div": "÷", "cdot": "⋅", "ast": "∗", "star": "⋆",
+	"circ": "∘", "bullet": "∙", "oplus": "⊕", "ominus": "⊖", "otimes": "⊗", "oslash": "⊘",
+	"odot": "⊙", "cap": "∩", "cup": "∪", "setminus": "∖", "wedge": "∧", "land": "∧",
+	"vee": "∨", "lor": "∨", "sqcap": "⊓", "sqcup": "⊔", "uplus": "⊎", "dagger": "†",
+	"ddagger": "‡", "amalg": "⨿", "wr": "≀", "diamond": "⋄", "bmod": "mod", "mod": "mod",
+	// Relations
+	"leq": "≤", "le": "≤", "geq": "≥", "ge": "≥", "neq": "≠", "ne": "≠", "equiv": "≡",
+	"approx": "≈", "sim": "∼", "simeq": "≃", "cong": "≅", "propto": "∝", "ll": "≪", "gg": "≫",
+	"subset": "⊂", "supset": "⊃", "subseteq": "⊆", "supseteq": "⊇", "nsubseteq": "⊈",
+	"in": "∈", "notin": "∉", "ni": "∋", "mid": "∣", "nmid": "∤", "parallel": "∥", "perp": "⊥",
+	"models": "⊨", "vdash": "⊢", "dashv": "⊣", "prec": "≺", "succ": "≻", "preceq": "⪯",
+	"succeq": "⪰", "doteq": "≐", "asymp": "≍", "leqslant": "⩽", "geqslant": "⩾",
+	"lesssim": "≲", "gtrsim": "≳", "coloneqq": "≔", "triangleq": "≜",
+	// Logic
+	"forall": "∀", "exists": "∃", "nexists": "∄", "neg": "¬", "lnot": "¬",
+	"therefore": "∴", "because": "∵",
+	// Arrows
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+	"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹",
+	"impliedby": "⟸", "iff": "⟺", "mapsto": "↦", "longrightarrow": "⟶", "longleftarrow": "⟵",
+	"longleftrightarrow": "⟷", "longmapsto": "⟼", "Longrightarrow": "⟹", "Longleftarrow": "⟸",
+	"Longleftrightarrow": "⟺", "uparrow": "↑", "downarrow": "↓", "updownarrow": "↕",
+	"Uparrow": "⇑", "Downarrow": "⇓", "nearrow": "↗", "searrow": "↘", "swarrow": "↙",
+	"nwarrow": "↖", "hookrightarrow": "↪", "hookleftarrow": "↩", "rightharpoonup": "⇀",
+	"leftharpoonup": "↼", "rightleftharpoons": "⇌",
+	// Delimiters
+	"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+	"vert": "|", "Vert": "‖", "lvert": "|", "rvert": "|", "lVert": "‖", "rVert": "‖",
+	"lbrace": "{", "rbrace": "}", "backslash": "\\",
+	// Dots and punctuation
+	"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱", "colon": ":",
+}
+
+// Large operators, which get limits under and over them in display mode
+var largeOperators = map[string]string{
+	"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂", "bigsqcup": "⨆",
+	"bigvee": "⋁", "bigwedge": "⋀", "bigoplus": "⨁", "bigotimes": "⨂", "bigodot": "⨀",
+	"biguplus": "⨄",
+}
+
+// Integrals, which get limits as scripts
+var integrals = map[string]string{
+	"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮", "oiint": "∯",
+}
+
+// Function names, the value is true for functions with limits under them in display mode
+var functions = map[string]bool{
+	"sin": false, "cos": false, "tan": false, "cot": false, "sec": false, "csc": false,
+	"arcsin": false, "arccos": false, "arctan": false, "sinh": false, "cosh": false,
+	"tanh": false, "coth": false, "log": false, "ln": false, "lg": false, "exp": false,
+	"dim": false, "ker": false, "deg": false, "arg": false, "hom": false,
+	"lim": true, "liminf": true, "limsup": true, "max": true, "min": true, "sup": true,
+	"inf": true, "det": true, "gcd": true, "Pr": true,
+}
+
+var functionNames = map[string]string{
+	"liminf": "lim inf", "limsup": "lim sup",
+}
+
+// Spacing commands and their widths
+var spaces = map[string]string{
+	",": "0.167em", ":": "0.222em", ">": "0.222em", ";": "0.278em", "!": "-0.167em",
+	" ": "0.333em", "quad": "1em", "qquad": "2em", "enspace": "0.5em", "thinspace": "0.167em",
+	"medspace": "0.222em", "thickspace": "0.278em",
+}
+
+// Escaped characters like \{
+var escapedCharacters = map[string]string{
+	"{": "{", "}": "}", "|": "‖", "%": "%", "$": "$", "#": "#", "&": "&", "_": "_",
+}
+
+type accent struct {
+	char     string
+	stretchy bool
+	under    bool
+}
+
+var accents = map[string]accent{
+	"hat": {char: "^"}, "widehat": {char: "^", stretchy: true}, "bar": {char: "¯"},
+	"overline": {char: "¯", stretchy: true}, "vec": {char: "→"}, "overrightarrow": {char: "→", stretchy: true},
+	"overleftarrow": {char: "←", stretchy: true}, "dot": {char: "˙"}, "ddot": {char: "¨"},
+	"tilde": {char: "˜"}, "widetilde": {char: "˜", stretchy: true}, "check": {char: "ˇ"},
+	"breve": {char: "˘"}, "acute": {char: "´"}, "grave": {char: "`"}, "mathring": {char: "˚"},
+	"underline": {char: "_", stretchy: true, under: true},
+}
+
+// Sizes for \big and similar commands
+var bigSizes = map[string]string{
+	"big": "1.2em", "bigl": "1.2em", "bigr": "1.2em", "bigm": "1.2em",
+	"Big": "1.8em", "Bigl": "1.8em", "Bigr": "1.8em", "Bigm": "1.8em",
+	"bigg": "2.4em", "biggl": "2.4em", "biggr": "2.4em", "biggm": "2.4em",
+	"Bigg": "3em", "Biggl": "3em", "Biggr": "3em", "Biggm": "3em",
+}
+
+// Delimiters for environments
+var environments = map[string][2]string{
+	"matrix": {"", ""}, "smallmatrix": {"", ""}, "pmatrix": {"(", ")"}, "bmatrix": {"[", "]"},
+	"Bmatrix": {"{", "}"}, "vmatrix": {"|", "|"}, "Vmatrix": {"‖", "‖"}, "cases": {"{", ""},
+	"array": {"", ""}, "aligned": {"", ""}, "align": {"", ""}, "align*": {"", ""},
+	"split": {"", ""}, "gathered": {"", ""}, "gather": {"", ""}, "gather*": {"", ""},
+}
+
+type mathFont struct {
+	upper, lower, digit rune
+	exceptions          map[rune]rune
+}
+
+// Unicode mathematical alphanumeric symbols
+var mathFonts = map[string]mathFont{
+	"mathbf": {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	"mathit": {upper: 0x1D434, lower: 0x1D44E, exceptions: map[rune]rune{'h': 0x210E}},
+	"mathcal": {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 0x212C, 'E': 0x2130, 'F': 0x2131, 'H': 0x210B, 'I': 0x2110, 'L': 0x2112,
+		'M': 0x2133, 'R': 0x211B, 'e': 0x212F, 'g': 0x210A, 'o': 0x2134,
+	}},
+	"mathfrak": {upper: 0x1D504, lower: 0x1D51E, exceptions: map[rune]rune{
+		'C': 0x212D, 'H': 0x210C, 'I': 0x2111, 'R': 0x211C, 'Z': 0x2128,
+	}},
+	"mathbb": {upper: 0x1D538, lower: 0x1D552, digit: 0x1D7D8, exceptions: map[rune]rune{
+		'C': 0x2102, 'H': 0x210D, 'N': 0x2115, 'P': 0x2119, 'Q': 0x211A, 'R': 0x211D, 'Z': 0x2124,
+	}},
+	"mathsf": {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	"mathtt": {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+}
+
+var fontAliases = map[string]string{
+	"boldsymbol": "mathbf", "bm": "mathbf", "mathscr": "mathcal",
+}
+
+// styleRune returns the character in the font, if available
+func styleRune(font string, r rune) rune {
+	f, ok := mathFonts[font]
+	if !ok {
+		return r
+	}
+	if e, ok := f.exceptions[r]; ok {
+		return e
+	}
+	switch {
+	case r >= 'A' && r <= 'Z':
+		return f.upper + r - 'A'
+	case r >= 'a' && r <= 'z':
+		return f.lower + r - 'a'
+	case r >= '0' && r <= '9' && f.digit != 0:
+		return f.digit + r - '0'
+	}
+	return r
+}
@@ -122,6 +122,8 @@ type Post interface {
 	GetStatus() string
 	// Get the post visibility (e.g. "public", "unlisted", "private")
 	GetVisibility() string
+	// Get the related posts (only available for the post of a rendered post page and posts from GetPost)
+	GetRelatedPosts() []Post
 }
 
 // Blog contains methods to access the blog's configuration.
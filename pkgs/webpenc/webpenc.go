@@ -0,0 +1,362 @@
+// Package webpenc encodes images as lossless WebP (VP8L).
+//
+// The encoder is kept simple: it uses the subtract green and predictor transforms
+// and prefix coded literals, but no backward references or color cache.
+// It's meant for re-encoding images after lossless operations like rotating.
+package webpenc
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	maxDimension = 1 << 14
+
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+
+	// Predictor mode 7 is the average of the left and the top pixel
+	predictorMode = 7
+	// Use the largest predictor blocks, all blocks use the same mode
+	predictorBits = 9
+
+	maxCodeLength = 15
+)
+
+// Order in which the code lengths of the code length code are stored
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// Alphabet sizes of the prefix codes for green (with length prefixes), red, blue, alpha and distance
+var alphabetSizes = [5]int{256 + 24, 256, 256, 256, 40}
+
+var errTooLarge = errors.New("webpenc: image is too large")
+
+// Encode writes the image as lossless WebP file to w
+func Encode(w io.Writer, img image.Image) error {
+	bitstream, err := EncodeBitstream(img)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, 0, 20)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(4+8+len(bitstream)+len(bitstream)%2))
+	header = append(header, "WEBPVP8L"...)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(bitstream)))
+	if _, err = w.Write(header); err != nil {
+		return err
+	}
+	if len(bitstream)%2 == 1 {
+		bitstream = append(bitstream, 0)
+	}
+	_, err = w.Write(bitstream)
+	return err
+}
+
+// EncodeBitstream returns the VP8L bitstream of the image (the content of the VP8L chunk)
+func EncodeBitstream(img image.Image) ([]byte, error) {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > maxDimension || height > maxDimension {
+		return nil, errTooLarge
+	}
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Stride != 4*width || b.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	}
+	pix := nrgba.Pix
+	alphaUsed := false
+	for i := 3; i < len(pix); i += 4 {
+		if pix[i] != 0xff {
+			alphaUsed = true
+			break
+		}
+	}
+
+	bw := &bitWriter{}
+	// Header
+	bw.write(0x2f, 8)
+	bw.write(uint64(width-1), 14)
+	bw.write(uint64(height-1), 14)
+	if alphaUsed {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3) // Version
+	// Transforms, the decoder applies the inverse transforms in reverse order
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	blocks := func(size int) int { return (size + 1<<predictorBits - 1) >> predictorBits }
+	writeImage(bw, constantImage(blocks(width)*blocks(height), 0, predictorMode, 0, 0), false)
+	bw.write(0, 1)
+	// Image data
+	writeImage(bw, residuals(pix, width, height), true)
+	return bw.bytes(), nil
+}
+
+// constantImage returns a sub-image with all pixels of the same color
+func constantImage(size int, r, g, b, a byte) []byte {
+	pix := make([]byte, 4*size)
+	for i := 0; i < len(pix); i += 4 {
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = r, g, b, a
+	}
+	return pix
+}
+
+// residuals applies the subtract green and the predictor transform to the NRGBA pixels
+func residuals(pix []byte, width, height int) []byte {
+	// Subtract green
+	transformed := make([]byte, len(pix))
+	copy(transformed, pix)
+	for i := 0; i < len(transformed); i += 4 {
+		transformed[i] -= transformed[i+1]
+		transformed[i+2] -= transformed[i+1]
+	}
+	// Predictor
+	res := make([]byte, len(transformed))
+	stride := 4 * width
+	for y := range height {
+		for x := range width {
+			i := y*stride + 4*x
+			for c := range 4 {
+				var prediction byte
+				switch {
+				case x == 0 && y == 0:
+					// Opaque black
+					if c == 3 {
+						prediction = 0xff
+					}
+				case y == 0:
+					prediction = transformed[i-4+c]
+				case x == 0:
+					prediction = transformed[i-stride+c]
+				default:
+					prediction = byte((int(transformed[i-4+c]) + int(transformed[i-stride+c])) / 2)
+				}
+				res[i+c] = transformed[i+c] - prediction
+			}
+		}
+	}
+	return res
+}
+
+// writeImage writes the pixels (in RGBA order) with a single prefix code group and without color cache
+func writeImage(bw *bitWriter, pix []byte, topLevel bool) {
+	bw.write(0, 1) // No color cache
+	if topLevel {
+		bw.write(0, 1) // No meta prefix codes
+	}
+	// The prefix codes are written in the order green, red, blue, alpha, distance
+	channels := [4]int{1, 0, 2, 3}
+	var codes [5]*prefixCode
+	for i, alphabetSize := range alphabetSizes {
+		freqs := make([]int, alphabetSize)
+		if i < 4 {
+			for p := channels[i]; p < len(pix); p += 4 {
+				freqs[pix[p]]++
+			}
+		}
+		codes[i] = newPrefixCode(freqs)
+		codes[i].writeTo(bw)
+	}
+	for p := 0; p < len(pix); p += 4 {
+		for i, c := range channels {
+			codes[i].writeSymbol(bw, int(pix[p+c]))
+		}
+	}
+}
+
+type prefixCode struct {
+	lengths []int
+	codes   []uint64 // bit reversed, so they can be written LSB first
+	symbols []int    // the used symbols
+}
+
+func newPrefixCode(freqs []int) *prefixCode {
+	pc := &prefixCode{}
+	for s, f := range freqs {
+		if f > 0 {
+			pc.symbols = append(pc.symbols, s)
+		}
+	}
+	if len(pc.symbols) <= 2 {
+		// Simple code, a single symbol needs no bits, two symbols use one bit each
+		pc.lengths, pc.codes = make([]int, len(freqs)), make([]uint64, len(freqs))
+		if len(pc.symbols) == 2 {
+			pc.lengths[pc.symbols[0]], pc.lengths[pc.symbols[1]] = 1, 1
+			pc.codes[pc.symbols[1]] = 1
+		}
+		return pc
+	}
+	pc.lengths = codeLengths(freqs, maxCodeLength)
+	pc.codes = canonicalCodes(pc.lengths)
+	return pc
+}
+
+// writeTo writes the prefix code itself
+func (pc *prefixCode) writeTo(bw *bitWriter) {
+	if len(pc.symbols) <= 2 {
+		symbols := pc.symbols
+		if len(symbols) == 0 {
+			symbols = []int{0}
+		}
+		bw.write(1, 1) // Simple code
+		bw.write(uint64(len(symbols)-1), 1)
+		if symbols[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint64(symbols[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint64(symbols[0]), 8)
+		}
+		if len(symbols) == 2 {
+			bw.write(uint64(symbols[1]), 8)
+		}
+		return
+	}
+	// Normal code, the code lengths are written with a code length code using 4 bits for each length from 0 to 15
+	bw.write(0, 1)
+	bw.write(uint64(len(codeLengthCodeOrder)-4), 4)
+	for _, s := range codeLengthCodeOrder {
+		if s < 16 {
+			bw.write(4, 3)
+		} else {
+			bw.write(0, 3)
+		}
+	}
+	bw.write(0, 1) // Code lengths for all symbols
+	for _, l := range pc.lengths {
+		bw.write(reverseBits(uint64(l), 4), 4)
+	}
+}
+
+func (pc *prefixCode) writeSymbol(bw *bitWriter, symbol int) {
+	bw.write(pc.codes[symbol], uint(pc.lengths[symbol]))
+}
+
+// codeLengths returns the Huffman code lengths for the frequencies, limited to the maximum length
+func codeLengths(freqs []int, maxLength int) []int {
+	freqs = append([]int(nil), freqs...)
+	for {
+		lengths, ok := huffmanLengths(freqs, maxLength)
+		if ok {
+			return lengths
+		}
+		// Flatten the frequencies until the code is short enough
+		for i, f := range freqs {
+			if f > 0 {
+				freqs[i] = max(1, f/2)
+			}
+		}
+	}
+}
+
+func huffmanLengths(freqs []int, maxLength int) ([]int, bool) {
+	type node struct {
+		weight int
+		parent int
+	}
+	var nodes []node
+	var active []int
+	leaves := map[int]int{} // symbol to node
+	for s, f := range freqs {
+		if f > 0 {
+			leaves[s] = len(nodes)
+			active = append(active, len(nodes))
+			nodes = append(nodes, node{weight: f, parent: -1})
+		}
+	}
+	popMin := func() int {
+		mi := 0
+		for i := range active {
+			if nodes[active[i]].weight < nodes[active[mi]].weight {
+				mi = i
+			}
+		}
+		n := active[mi]
+		active = append(active[:mi], active[mi+1:]...)
+		return n
+	}
+	for len(active) > 1 {
+		a, b := popMin(), popMin()
+		parent := len(nodes)
+		nodes = append(nodes, node{weight: nodes[a].weight + nodes[b].weight, parent: -1})
+		nodes[a].parent, nodes[b].parent = parent, parent
+		active = append(active, parent)
+	}
+	lengths := make([]int, len(freqs))
+	for s, n := range leaves {
+		for p := nodes[n].parent; p != -1; p = nodes[p].parent {
+			lengths[s]++
+		}
+		if lengths[s] > maxLength {
+			return nil, false
+		}
+	}
+	return lengths, true
+}
+
+// canonicalCodes returns the bit reversed canonical codes for the code lengths (like in DEFLATE)
+func canonicalCodes(lengths []int) []uint64 {
+	var count [maxCodeLength + 1]uint64
+	for _, l := range lengths {
+		count[l]++
+	}
+	count[0] = 0
+	var next [maxCodeLength + 1]uint64
+	code := uint64(0)
+	for l := 1; l <= maxCodeLength; l++ {
+		code = (code + count[l-1]) << 1
+		next[l] = code
+	}
+	codes := make([]uint64, len(lengths))
+	for s, l := range lengths {
+		if l > 0 {
+			codes[s] = reverseBits(next[l], l)
+			next[l]++
+		}
+	}
+	return codes
+}
+
+func reverseBits(v uint64, n int) uint64 {
+	var r uint64
+	for range n {
+		r = r<<1 | v&1
+		v >>= 1
+	}
+	return r
+}
+
+// bitWriter writes bits starting with the least significant bit
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (w *bitWriter) write(bits uint64, n uint) {
+	w.acc |= bits << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nBits = 0, 0
+	}
+	return w.buf
+}
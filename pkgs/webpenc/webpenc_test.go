@@ -0,0 +1,94 @@
+package webpenc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/kovidgoyal/imaging"
+)
+
+func assertRoundTrip(t *testing.T, img *image.NRGBA) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, img); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	decoded, err := imaging.Decode(bytes.NewReader(buf.Bytes()), imaging.ColorSpace(imaging.NO_CHANGE_OF_COLORSPACE))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded.Bounds().Size() != img.Bounds().Size() {
+		t.Fatalf("Expected size %v, got %v", img.Bounds().Size(), decoded.Bounds().Size())
+	}
+	for y := range img.Bounds().Dy() {
+		for x := range img.Bounds().Dx() {
+			want := img.NRGBAAt(x, y)
+			got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+			if want.A == 0 {
+				// Fully transparent pixels don't have a color
+				want, got = color.NRGBA{}, color.NRGBA{A: got.A}
+			}
+			if got != want {
+				t.Fatalf("Pixel (%d, %d): expected %v, got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestEncodeGradient(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 600, 40))
+	for y := range 40 {
+		for x := range 600 {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(x * y), B: uint8(y * 7), A: 255})
+		}
+	}
+	assertRoundTrip(t, img)
+}
+
+func TestEncodeAlpha(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 17, 9))
+	for y := range 9 {
+		for x := range 17 {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 13), G: uint8(y * 29), B: uint8(x ^ y), A: uint8(x * y * 3)})
+		}
+	}
+	assertRoundTrip(t, img)
+}
+
+func TestEncodeSingleColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0x80
+	}
+	assertRoundTrip(t, img)
+	assertRoundTrip(t, image.NewNRGBA(image.Rect(0, 0, 1, 1)))
+}
+
+func TestEncodeTooLarge(t *testing.T) {
+	if _, err := EncodeBitstream(image.NewNRGBA(image.Rect(0, 0, maxDimension+1, 1))); err == nil {
+		t.Error("Expected error for too large image")
+	}
+}
+
+func TestCodeLengthsLimited(t *testing.T) {
+	// Fibonacci frequencies lead to the longest codes
+	freqs := make([]int, 30)
+	a, b := 1, 1
+	for i := range freqs {
+		freqs[i] = a
+		a, b = b, a+b
+	}
+	lengths := codeLengths(freqs, maxCodeLength)
+	kraft := 0.0
+	for _, l := range lengths {
+		if l < 1 || l > maxCodeLength {
+			t.Fatalf("Invalid code length %d", l)
+		}
+		kraft += 1 / float64(int(1)<<l)
+	}
+	if kraft != 1 {
+		t.Errorf("Expected a complete code, got Kraft sum %v", kraft)
+	}
+}
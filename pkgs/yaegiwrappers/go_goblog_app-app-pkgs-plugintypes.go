@@ -288,6 +288,7 @@ type _go_goblog_app_app_pkgs_plugintypes_Post struct {
 	WGetParameters          func() map[string][]string
 	WGetPath                func() string
 	WGetPublished           func() string
+	WGetRelatedPosts        func() []plugintypes.Post
 	WGetSection             func() string
 	WGetStatus              func() string
 	WGetTitle               func() string
@@ -316,6 +317,9 @@ func (W _go_goblog_app_app_pkgs_plugintypes_Post) GetPath() string {
 func (W _go_goblog_app_app_pkgs_plugintypes_Post) GetPublished() string {
 	return W.WGetPublished()
 }
+func (W _go_goblog_app_app_pkgs_plugintypes_Post) GetRelatedPosts() []plugintypes.Post {
+	return W.WGetRelatedPosts()
+}
 func (W _go_goblog_app_app_pkgs_plugintypes_Post) GetSection() string {
 	return W.WGetSection()
 }
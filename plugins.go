@@ -12,6 +12,7 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/samber/lo"
 	"go.goblog.app/app/pkgs/bufferpool"
 	"go.goblog.app/app/pkgs/plugins"
 	"go.goblog.app/app/pkgs/plugintypes"
@@ -103,7 +104,12 @@ func (a *goBlog) GetDatabase() plugintypes.Database {
 }
 
 func (a *goBlog) GetPost(path string) (plugintypes.Post, error) {
-	return a.getPost(path)
+	p, err := a.getPost(path)
+	if err != nil {
+		return nil, err
+	}
+	p.related = a.relatedPosts(p)
+	return p, nil
 }
 
 func (a *goBlog) GetPosts(query *plugintypes.PostsQuery) ([]plugintypes.Post, error) {
@@ -287,7 +293,7 @@ func (a *goBlog) GetTaxonomyValues(blog string, taxonomy string) ([]string, erro
 }
 
 func (a *goBlog) GetComments(query *plugintypes.CommentsQuery) ([]plugintypes.Comment, error) {
-	cfg := &commentsRequestConfig{}
+	cfg := &commentsRequestConfig{status: commentStatusApproved}
 	if query != nil {
 		cfg.target = query.Target
 		cfg.limit = query.Limit
@@ -311,7 +317,7 @@ func (a *goBlog) GetComments(query *plugintypes.CommentsQuery) ([]plugintypes.Co
 }
 
 func (a *goBlog) CountComments(query *plugintypes.CommentsQuery) (int, error) {
-	cfg := &commentsRequestConfig{}
+	cfg := &commentsRequestConfig{status: commentStatusApproved}
 	if query != nil {
 		cfg.target = query.Target
 	}
@@ -448,6 +454,10 @@ func (p *post) GetVisibility() string {
 	return string(p.Visibility)
 }
 
+func (p *post) GetRelatedPosts() []plugintypes.Post {
+	return lo.Map(p.related, func(rp *post, _ int) plugintypes.Post { return rp })
+}
+
 func (b *configBlog) GetLanguage() string {
 	return b.Lang
 }
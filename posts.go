@@ -33,6 +33,9 @@ type post struct {
 	// Not persisted
 	Slug          string
 	RenderedTitle string
+	related       []*post // only set for the post page and plugins
+	preview       bool    // only set when rendered for a preview link
+	passwordHash  string  // only set when saving a new password of a protected post
 }
 
 type postStatus string
@@ -49,10 +52,11 @@ const (
 	statusScheduled        postStatus = "scheduled"
 	statusScheduledDeleted            = statusScheduled + statusDeletedSuffix
 
-	visibilityNil      postVisibility = ""
-	visibilityPublic   postVisibility = "public"
-	visibilityUnlisted postVisibility = "unlisted"
-	visibilityPrivate  postVisibility = "private"
+	visibilityNil       postVisibility = ""
+	visibilityPublic    postVisibility = "public"
+	visibilityUnlisted  postVisibility = "unlisted"
+	visibilityPrivate   postVisibility = "private"
+	visibilityProtected postVisibility = "protected"
 )
 
 func validPostStatus(s postStatus) bool {
@@ -62,7 +66,7 @@ func validPostStatus(s postStatus) bool {
 }
 
 func validPostVisibility(v postVisibility) bool {
-	return v == visibilityPublic || v == visibilityUnlisted || v == visibilityPrivate
+	return v == visibilityPublic || v == visibilityUnlisted || v == visibilityPrivate || v == visibilityProtected
 }
 
 func (a *goBlog) servePost(w http.ResponseWriter, r *http.Request) {
@@ -101,6 +105,9 @@ func (a *goBlog) servePost(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("X-Robots-Tag", "noindex")
 	}
 	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=shortlink", a.shortPostURL(p)))
+	if !isHome {
+		p.related = a.relatedPosts(p)
+	}
 	a.renderWithStatusCode(w, r, status, renderMethod, &renderData{
 		BlogString: p.Blog,
 		Canonical:  canonical,
@@ -179,6 +186,7 @@ func (a *goBlog) serveDrafts(w http.ResponseWriter, r *http.Request) {
 		path:        bc.getRelativePath("/editor/drafts"),
 		title:       a.ts.GetTemplateStringVariant(bc.Lang, "drafts"),
 		description: a.ts.GetTemplateStringVariant(bc.Lang, "draftsdesc"),
+		bulkActions: true,
 		status:      []postStatus{statusDraft},
 	})))
 }
@@ -189,6 +197,7 @@ func (a *goBlog) servePrivate(w http.ResponseWriter, r *http.Request) {
 		path:        bc.getRelativePath("/editor/private"),
 		title:       a.ts.GetTemplateStringVariant(bc.Lang, "privateposts"),
 		description: a.ts.GetTemplateStringVariant(bc.Lang, "privatepostsdesc"),
+		bulkActions: true,
 		status:      []postStatus{statusPublished},
 		visibility:  []postVisibility{visibilityPrivate},
 	})))
@@ -200,18 +209,33 @@ func (a *goBlog) serveUnlisted(w http.ResponseWriter, r *http.Request) {
 		path:        bc.getRelativePath("/editor/unlisted"),
 		title:       a.ts.GetTemplateStringVariant(bc.Lang, "unlistedposts"),
 		description: a.ts.GetTemplateStringVariant(bc.Lang, "unlistedpostsdesc"),
+		bulkActions: true,
 		status:      []postStatus{statusPublished},
 		visibility:  []postVisibility{visibilityUnlisted},
 	})))
 }
 
+func (a *goBlog) serveProtected(w http.ResponseWriter, r *http.Request) {
+	_, bc := a.getBlog(r)
+	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
+		path:        bc.getRelativePath("/editor/protected"),
+		title:       a.ts.GetTemplateStringVariant(bc.Lang, "protectedposts"),
+		description: a.ts.GetTemplateStringVariant(bc.Lang, "protectedpostsdesc"),
+		bulkActions: true,
+		status:      []postStatus{statusPublished},
+		visibility:  []postVisibility{visibilityProtected},
+	})))
+}
+
 func (a *goBlog) serveScheduled(w http.ResponseWriter, r *http.Request) {
 	_, bc := a.getBlog(r)
 	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
-		path:        bc.getRelativePath("/editor/scheduled"),
-		title:       a.ts.GetTemplateStringVariant(bc.Lang, "scheduledposts"),
-		description: a.ts.GetTemplateStringVariant(bc.Lang, "scheduledpostsdesc"),
-		status:      []postStatus{statusScheduled},
+		path:           bc.getRelativePath("/editor/scheduled"),
+		title:          a.ts.GetTemplateStringVariant(bc.Lang, "scheduledposts"),
+		description:    a.ts.GetTemplateStringVariant(bc.Lang, "scheduledpostsdesc"),
+		bulkActions:    true,
+		status:         []postStatus{statusScheduled},
+		scheduledEdits: true,
 	})))
 }
 
@@ -221,6 +245,7 @@ func (a *goBlog) serveDeleted(w http.ResponseWriter, r *http.Request) {
 		path:        bc.getRelativePath("/editor/deleted"),
 		title:       a.ts.GetTemplateStringVariant(bc.Lang, "deletedposts"),
 		description: a.ts.GetTemplateStringVariant(bc.Lang, "deletedpostsdesc"),
+		bulkActions: true,
 		status:      []postStatus{statusPublishedDeleted, statusDraftDeleted, statusScheduledDeleted},
 	})))
 }
@@ -291,6 +316,9 @@ type indexConfig struct {
 	tax              *configTaxonomy
 	taxValue         string
 	parameter        string
+	parameterValue   string
+	orderParameter   string
+	ascendingOrder   bool
 	year, month, day int
 	title            string
 	titleSuffix      string
@@ -303,6 +331,10 @@ type indexConfig struct {
 	withoutFeeds     bool
 	allBlogs         bool
 	isHome           bool
+	scheduledEdits   bool
+	bulkActions      bool
+	upcomingEvents   bool
+	mediaLog         *mediaLogKind
 }
 
 const defaultPhotosPath = "/photos"
@@ -342,21 +374,25 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	}
 	// Create paginator
 	p := paginator.New(&postPaginationAdapter{config: &postsRequestConfig{
-		blogs:          lo.If(!ic.allBlogs, []string{blog}).Else([]string{}),
-		sections:       sections,
-		taxonomy:       ic.tax,
-		taxonomyValue:  ic.taxValue,
-		parameter:      ic.parameter,
-		allParams:      params,
-		allParamValues: paramValues,
-		search:         ic.search,
-		publishedYear:  ic.year,
-		publishedMonth: ic.month,
-		publishedDay:   ic.day,
-		usesFile:       ic.usesFile,
-		status:         status,
-		visibility:     visibility,
-		priorityOrder:  true,
+		blogs:             lo.If(!ic.allBlogs, []string{blog}).Else([]string{}),
+		sections:          sections,
+		taxonomy:          ic.tax,
+		taxonomyValue:     ic.taxValue,
+		parameter:         ic.parameter,
+		parameterValue:    ic.parameterValue,
+		orderParameter:    ic.orderParameter,
+		allParams:         params,
+		allParamValues:    paramValues,
+		search:            ic.search,
+		publishedYear:     ic.year,
+		publishedMonth:    ic.month,
+		publishedDay:      ic.day,
+		usesFile:          ic.usesFile,
+		status:            status,
+		visibility:        visibility,
+		priorityOrder:     ic.orderParameter == "",
+		ascendingOrder:    ic.ascendingOrder,
+		eventsEndingAfter: lo.If(ic.upcomingEvents, time.Now()).Else(time.Time{}),
 	}, a: a}, bc.Pagination)
 	p.SetPage(stringToInt(chi.URLParam(r, "page")))
 	var posts []*post
@@ -416,6 +452,29 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	if summaryTemplate == "" {
 		summaryTemplate = defaultSummary
 	}
+	// Pending edits of published posts
+	var scheduledEdits []*scheduledEdit
+	if ic.scheduledEdits {
+		scheduledEdits, err = a.db.getScheduledEdits(blog, time.Time{})
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	// Years with media log posts
+	var years []string
+	var yearsPath string
+	if ic.mediaLog != nil {
+		yearsPath = bc.mediaLogPath(ic.mediaLog)
+		stats, err := a.db.getMediaLogStats(blog)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		years = lo.FilterMap(stats, func(row *mediaLogStatsRow, _ int) (string, bool) {
+			return row.Year, row.Counts[ic.mediaLog.name] > 0
+		})
+	}
 	a.render(w, r, a.renderIndex, &renderData{
 		Canonical: a.getFullAddress(ic.path) + paramURLQuery,
 		IsHome:    ic.isHome,
@@ -431,6 +490,11 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 			summaryTemplate: summaryTemplate,
 			paramURLQuery:   paramURLQuery,
 			withoutFeeds:    ic.withoutFeeds,
+			scheduledEdits:  scheduledEdits,
+			bulkActions:     ic.bulkActions,
+			calendar:        lo.If(ic.upcomingEvents, ic.path+eventsICSSuffix).Else(""),
+			years:           years,
+			yearsPath:       yearsPath,
 		},
 	})
 }
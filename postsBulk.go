@@ -0,0 +1,186 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const editorBulkPath = "/bulk"
+
+// bulkJob is a bulk action on multiple posts, running in the background to report the progress
+type bulkJob struct {
+	mu       sync.Mutex
+	total    int
+	done     int
+	errors   []string
+	finished time.Time
+	back     string
+}
+
+func (j *bulkJob) progress() (total, done int, errs []string, finished bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.total, j.done, slices.Clone(j.errors), !j.finished.IsZero()
+}
+
+// bulkAction returns the function to apply the action to a post, actions are "section:<name>", "visibility:<visibility>",
+// "status:<status>", "add:<taxonomy>", "remove:<taxonomy>", "delete", "deletepermanently" and "undelete";
+// "delete" skips posts that are already marked as deleted, only "deletepermanently" removes them
+func (a *goBlog) bulkAction(bc *configBlog, action, value string) (func(p *post) error, error) {
+	typ, arg, _ := strings.Cut(action, ":")
+	switch typ {
+	case "delete":
+		return func(p *post) error {
+			if p.Deleted() {
+				return nil
+			}
+			return a.deletePost(p.Path)
+		}, nil
+	case "deletepermanently":
+		return func(p *post) error {
+			if !p.Deleted() {
+				return errors.New("post isn't marked as deleted")
+			}
+			return a.deletePost(p.Path)
+		}, nil
+	case "undelete":
+		return func(p *post) error {
+			if !p.Deleted() {
+				return nil
+			}
+			return a.undeletePost(p.Path)
+		}, nil
+	case "section":
+		if _, ok := bc.Sections[arg]; !ok {
+			return nil, errors.New("section doesn't exist")
+		}
+		return func(p *post) error {
+			p.Section = arg
+			return nil
+		}, nil
+	case "visibility":
+		if v := postVisibility(arg); validPostVisibility(v) {
+			return func(p *post) error {
+				p.Visibility = v
+				return nil
+			}, nil
+		}
+		return nil, errors.New("invalid visibility")
+	case "status":
+		if s := postStatus(arg); s == statusPublished || s == statusDraft {
+			return func(p *post) error {
+				p.Status = s
+				return nil
+			}, nil
+		}
+		return nil, errors.New("invalid status")
+	case "add", "remove":
+		if !slices.ContainsFunc(bc.Taxonomies, func(t *configTaxonomy) bool { return t.Name == arg }) {
+			return nil, errors.New("taxonomy not found")
+		}
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return nil, errors.New("value missing")
+		}
+		return func(p *post) error {
+			if p.Parameters == nil {
+				p.Parameters = map[string][]string{}
+			}
+			if typ == "add" {
+				p.Parameters[arg] = lo.UniqBy(append(p.Parameters[arg], value), strings.ToLower)
+			} else {
+				p.Parameters[arg] = lo.Reject(p.Parameters[arg], func(v string, _ int) bool { return strings.EqualFold(v, value) })
+			}
+			return nil
+		}, nil
+	}
+	return nil, errors.New("unknown action")
+}
+
+// runBulkJob applies the action to all posts, changes are saved using createOrReplacePost, so hooks are triggered
+func (a *goBlog) runBulkJob(job *bulkJob, blog string, paths []string, action string, apply func(p *post) error) {
+	for _, path := range paths {
+		err := func() error {
+			p, err := a.getPost(path)
+			if err != nil {
+				return err
+			}
+			if p.Blog != blog {
+				// Sections and taxonomies of the action are only checked for this blog
+				return errors.New("post belongs to another blog")
+			}
+			if action == "delete" || action == "deletepermanently" || action == "undelete" {
+				return apply(p)
+			}
+			oldStatus, oldVisibility := p.Status, p.Visibility
+			if err := apply(p); err != nil {
+				return err
+			}
+			return a.replacePost(p, p.Path, oldStatus, oldVisibility, false)
+		}()
+		job.mu.Lock()
+		job.done++
+		if err != nil {
+			job.errors = append(job.errors, path+": "+err.Error())
+		}
+		job.mu.Unlock()
+	}
+	job.mu.Lock()
+	job.finished = time.Now()
+	job.mu.Unlock()
+}
+
+func (a *goBlog) serveEditorBulkPost(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	action := r.FormValue("action")                              //nolint:gosec
+	apply, err := a.bulkAction(bc, action, r.FormValue("value")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	paths := lo.Uniq(lo.Compact(r.Form["paths"]))
+	if len(paths) == 0 {
+		a.serveError(w, r, "no posts selected", http.StatusBadRequest)
+		return
+	}
+	job := &bulkJob{
+		total: len(paths),
+		back:  r.FormValue("back"), //nolint:gosec
+	}
+	id := randomString(16)
+	a.bulkJobsMutex.Lock()
+	if a.bulkJobs == nil {
+		a.bulkJobs = map[string]*bulkJob{}
+	}
+	// Forget old finished jobs
+	for jobID, j := range a.bulkJobs {
+		j.mu.Lock()
+		if !j.finished.IsZero() && time.Since(j.finished) > time.Hour {
+			delete(a.bulkJobs, jobID)
+		}
+		j.mu.Unlock()
+	}
+	a.bulkJobs[id] = job
+	a.bulkJobsMutex.Unlock()
+	go a.runBulkJob(job, blog, paths, action, apply)
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorBulkPath)+"?id="+id, http.StatusFound)
+}
+
+func (a *goBlog) serveEditorBulk(w http.ResponseWriter, r *http.Request) {
+	a.bulkJobsMutex.Lock()
+	job, ok := a.bulkJobs[r.URL.Query().Get("id")]
+	a.bulkJobsMutex.Unlock()
+	if !ok {
+		a.serve404(w, r)
+		return
+	}
+	a.render(w, r, a.renderEditorBulk, &renderData{
+		Data: job,
+	})
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_postsBulk(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+	app.cfg.Blogs["default"].Sections["notes"] = &configSection{Name: "notes", Title: "Notes"}
+
+	app.d = app.buildRouter()
+
+	for _, path := range []string{"/a", "/b", "/c"} {
+		require.NoError(t, app.createPost(&post{
+			Path:       path,
+			Section:    "posts",
+			Status:     statusDraft,
+			Content:    "Content",
+			Parameters: map[string][]string{"tags": {"old"}},
+		}))
+	}
+
+	var hooked []string
+	var hookedMu sync.Mutex
+	hook := func(p *post) {
+		hookedMu.Lock()
+		defer hookedMu.Unlock()
+		hooked = append(hooked, p.Path)
+	}
+	app.pPostHooks = append(app.pPostHooks, hook)
+	app.pUpdateHooks = append(app.pUpdateHooks, hook)
+
+	// bulk runs the action and waits for the job to finish
+	bulk := func(data url.Values) (string, *bulkJob) {
+		req := httptest.NewRequest(http.MethodPost, editorPath+editorBulkPath, strings.NewReader(data.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorBulkPost(rec, req)
+		require.Equal(t, http.StatusFound, rec.Code)
+		id := strings.TrimPrefix(rec.Header().Get("Location"), editorPath+editorBulkPath+"?id=")
+		app.bulkJobsMutex.Lock()
+		job := app.bulkJobs[id]
+		app.bulkJobsMutex.Unlock()
+		require.NotNil(t, job)
+		require.Eventually(t, func() bool {
+			_, _, _, finished := job.progress()
+			return finished
+		}, 5*time.Second, 10*time.Millisecond)
+		return id, job
+	}
+
+	t.Run("List", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/editor/drafts", http.NoBody)
+		rec := httptest.NewRecorder()
+		app.serveDrafts(rec, req)
+		body := rec.Body.String()
+		assert.Contains(t, body, "action=/editor/bulk")
+		assert.Contains(t, body, "name=paths value=/a")
+		assert.Contains(t, body, "value=add:tags")
+	})
+
+	t.Run("Tags", func(t *testing.T) {
+		bulk(url.Values{"action": {"add:tags"}, "value": {"New"}, "paths": {"/a", "/b"}, "back": {"/editor/drafts"}})
+		p, err := app.getPost("/a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"old", "New"}, p.Parameters["tags"])
+
+		bulk(url.Values{"action": {"remove:tags"}, "value": {"OLD"}, "paths": {"/a", "/c"}})
+		p, err = app.getPost("/a")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"New"}, p.Parameters["tags"])
+		p, err = app.getPost("/c")
+		require.NoError(t, err)
+		assert.Empty(t, p.Parameters["tags"])
+	})
+
+	t.Run("Section and visibility", func(t *testing.T) {
+		bulk(url.Values{"action": {"section:notes"}, "paths": {"/a"}})
+		bulk(url.Values{"action": {"visibility:unlisted"}, "paths": {"/a", "/b"}})
+		p, err := app.getPost("/a")
+		require.NoError(t, err)
+		assert.Equal(t, "notes", p.Section)
+		assert.Equal(t, visibilityUnlisted, p.Visibility)
+	})
+
+	t.Run("Status", func(t *testing.T) {
+		id, job := bulk(url.Values{"action": {"status:published"}, "paths": {"/a", "/b", "/missing"}, "back": {"/editor/drafts"}})
+		total, done, errs, _ := job.progress()
+		assert.Equal(t, 3, total)
+		assert.Equal(t, 3, done)
+		assert.Len(t, errs, 1)
+
+		p, err := app.getPost("/b")
+		require.NoError(t, err)
+		assert.Equal(t, statusPublished, p.Status)
+
+		// Hooks are triggered for each post
+		require.Eventually(t, func() bool {
+			hookedMu.Lock()
+			defer hookedMu.Unlock()
+			return len(hooked) == 2
+		}, 5*time.Second, 10*time.Millisecond)
+
+		// Progress report
+		req := httptest.NewRequest(http.MethodGet, editorPath+editorBulkPath+"?id="+id, http.NoBody)
+		rec := httptest.NewRecorder()
+		app.serveEditorBulk(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "<progress max=3 value=3>")
+		assert.Contains(t, body, "3 of 3 posts processed. Done.")
+		assert.Contains(t, body, "/missing:")
+		assert.Contains(t, body, "href=/editor/drafts")
+	})
+
+	t.Run("Delete and undelete", func(t *testing.T) {
+		bulk(url.Values{"action": {"delete"}, "paths": {"/c"}})
+		p, err := app.getPost("/c")
+		require.NoError(t, err)
+		assert.True(t, p.Deleted())
+
+		// Deleting again doesn't delete permanently
+		bulk(url.Values{"action": {"delete"}, "paths": {"/c"}})
+		p, err = app.getPost("/c")
+		require.NoError(t, err)
+		assert.True(t, p.Deleted())
+
+		bulk(url.Values{"action": {"undelete"}, "paths": {"/c"}})
+		p, err = app.getPost("/c")
+		require.NoError(t, err)
+		assert.False(t, p.Deleted())
+
+		// Only posts marked as deleted are deleted permanently
+		_, job := bulk(url.Values{"action": {"deletepermanently"}, "paths": {"/c"}})
+		_, _, errs, _ := job.progress()
+		assert.Len(t, errs, 1)
+		bulk(url.Values{"action": {"delete"}, "paths": {"/c"}})
+		bulk(url.Values{"action": {"deletepermanently"}, "paths": {"/c"}})
+		_, err = app.getPost("/c")
+		assert.ErrorIs(t, err, errPostNotFound)
+	})
+
+	t.Run("Other blog", func(t *testing.T) {
+		app.cfg.Blogs["en"] = &configBlog{Path: "/en", Lang: "en", Sections: map[string]*configSection{"posts": {Name: "posts"}}}
+		defer delete(app.cfg.Blogs, "en")
+		require.NoError(t, app.createPost(&post{Path: "/en/other", Blog: "en", Section: "posts", Content: "Other"}))
+
+		_, job := bulk(url.Values{"action": {"section:notes"}, "paths": {"/en/other"}})
+		_, _, errs, _ := job.progress()
+		assert.Len(t, errs, 1)
+		p, err := app.getPost("/en/other")
+		require.NoError(t, err)
+		assert.Equal(t, "posts", p.Section)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, data := range []url.Values{
+			{"action": {"section:unknown"}, "paths": {"/a"}},
+			{"action": {"status:scheduled"}, "paths": {"/a"}},
+			{"action": {"add:tags"}, "paths": {"/a"}},
+			{"action": {"unknown"}, "paths": {"/a"}},
+			{"action": {"delete"}},
+		} {
+			req := httptest.NewRequest(http.MethodPost, editorPath+editorBulkPath, strings.NewReader(data.Encode()))
+			req.Header.Set(contentType, contenttype.WWWForm)
+			rec := httptest.NewRecorder()
+			app.serveEditorBulkPost(rec, req)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, data.Encode())
+		}
+	})
+}
@@ -110,6 +110,13 @@ func (a *goBlog) checkPost(p *post, isNew, noUpdated bool) (err error) {
 		}
 		p.Parameters[pk] = pvs
 	}
+	// Hash the password of protected posts, it's stored separately and never as a parameter
+	if password := p.firstParameter(protectedPasswordParam); password != "" {
+		if p.passwordHash, err = hashPassword(password); err != nil {
+			return err
+		}
+	}
+	delete(p.Parameters, protectedPasswordParam)
 	// Add context for replies and likes
 	if isNew {
 		a.addReplyTitleAndContext(p)
@@ -216,6 +223,8 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 	contentText := db.a.renderTextSafe(p.Content)
 	wc := wordCount(contentText)
 	cc := charCount(contentText)
+	// Get internal links for backlinks
+	links := db.a.internalPostLinks(p)
 	// Lock post creation
 	db.pcm.Lock()
 	defer db.pcm.Unlock()
@@ -263,6 +272,17 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 			}
 		}
 	}
+	// Store new password hash
+	if p.passwordHash != "" {
+		sqlBuilder.WriteString("insert or replace into post_passwords (path, hash) values (?, ?);")
+		sqlArgs = append(sqlArgs, p.Path, p.passwordHash)
+	}
+	// Store internal links
+	postLinksSQL(sqlBuilder, &sqlArgs, p.Path, links)
+	// Store revision and prune old ones
+	if err := db.a.postRevisionSQL(sqlBuilder, &sqlArgs, p); err != nil {
+		return err
+	}
 	// Commit transaction
 	sqlBuilder.WriteString("commit;")
 	// Execute
@@ -410,22 +430,36 @@ func (a *goBlog) undeletePost(path string) error {
 }
 
 func (db *database) replacePostParam(path, param string, values []string) error {
-	// Filter empty values
-	values = lo.Filter(values, loStringNotEmpty)
+	return db.replacePostsParam(param, map[string][]string{path: values})
+}
+
+// replacePostsParam replaces the parameter of multiple posts (path to new values) in one transaction
+func (db *database) replacePostsParam(param string, pathValues map[string][]string) error {
+	return db.replacePostsParamWith(param, pathValues, nil)
+}
+
+// replacePostsParamWith is like replacePostsParam, but adds more statements to the same transaction
+func (db *database) replacePostsParamWith(param string, pathValues map[string][]string, addSQL func(sqlBuilder *strings.Builder, sqlArgs *[]any)) error {
 	// Lock post creation
 	db.pcm.Lock()
 	defer db.pcm.Unlock()
-	// Read existing rows
-	allRows, err := db.readPostParamRows(path)
-	if err != nil {
-		return err
-	}
 	// Build SQL
 	sqlBuilder := builderpool.Get()
 	defer builderpool.Put(sqlBuilder)
 	var sqlArgs []any
 	sqlBuilder.WriteString("begin;")
-	diffParamSQL(sqlBuilder, &sqlArgs, path, param, allRows[param], values)
+	for path, values := range pathValues {
+		// Read existing rows
+		allRows, err := db.readPostParamRows(path)
+		if err != nil {
+			return err
+		}
+		// Filter empty values
+		diffParamSQL(sqlBuilder, &sqlArgs, path, param, allRows[param], lo.Filter(values, loStringNotEmpty))
+	}
+	if addSQL != nil {
+		addSQL(sqlBuilder, &sqlArgs)
+	}
 	sqlBuilder.WriteString("commit;")
 	// Execute
 	if _, err := db.Exec(sqlBuilder.String(), sqlArgs...); err != nil {
@@ -456,9 +490,11 @@ type postsRequestConfig struct {
 	excludeParameterValue                       string     // ... with exactly this value
 	publishedYear, publishedMonth, publishedDay int
 	publishedBefore                             time.Time
+	eventsEndingAfter                           time.Time // filter for events that end (or start if there's no end, all-day events at the end of the day) after this time, ordered by start
 	randomOrder                                 bool
 	priorityOrder                               bool
 	ascendingOrder                              bool
+	orderParameter                              string   // order by the numeric value of this parameter first, posts without it follow
 	fetchWithoutParams                          bool     // fetch posts without parameters
 	fetchParams                                 []string // only fetch these parameters
 	withoutRenderedTitle                        bool     // fetch posts without rendered title
@@ -610,6 +646,10 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 		queryBuilder.WriteString(" and toutc(published) < @publishedbefore")
 		args = append(args, sql.Named("publishedbefore", c.publishedBefore.UTC().Format(time.RFC3339)))
 	}
+	if !c.eventsEndingAfter.IsZero() {
+		queryBuilder.WriteString(" and eventendutc(coalesce((select value from post_parameters where post_parameters.path = posts.path and parameter = @eventend and length(coalesce(value, '')) > 0 limit 1), (select value from post_parameters where post_parameters.path = posts.path and parameter = @eventstart and length(coalesce(value, '')) > 0 limit 1))) >= @eventsendingafter")
+		args = append(args, sql.Named("eventstart", eventStartParam), sql.Named("eventend", eventEndParam), sql.Named("eventsendingafter", c.eventsEndingAfter.UTC().Format(time.RFC3339)))
+	}
 	if c.usesFile != "" {
 		queryBuilder.WriteString(" and path in (select ps.path from posts_fts ps where ps.content MATCH '\"' || @usesfile || '\"' union all select pp.path from post_parameters pp where pp.value LIKE '%' || @usesfile || '%' )")
 		args = append(args, sql.Named("usesfile", c.usesFile))
@@ -619,6 +659,15 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 	if c.randomOrder {
 		queryBuilder.WriteString("random()")
 	} else {
+		if !c.eventsEndingAfter.IsZero() {
+			queryBuilder.WriteString("toutc((select value from post_parameters where post_parameters.path = posts.path and parameter = @eventstart limit 1)) asc, ")
+		}
+		if c.orderParameter != "" {
+			queryBuilder.WriteString("(select cast(value as real) from post_parameters where post_parameters.path = posts.path and parameter = @orderparam and length(coalesce(value, '')) > 0 limit 1)")
+			queryBuilder.WriteString(lo.If(c.ascendingOrder, " asc").Else(" desc"))
+			queryBuilder.WriteString(" nulls last, ")
+			args = append(args, sql.Named("orderparam", c.orderParameter))
+		}
 		if c.priorityOrder {
 			queryBuilder.WriteString("priority desc, published")
 		} else {
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+const (
+	expiresParam       = "expires"
+	expiresActionParam = "expiresaction"
+
+	expiryActionUnlisted = "unlisted"
+	expiryActionPrivate  = "private"
+	expiryActionDelete   = "delete"
+)
+
+// expiryAction returns what should happen with the post when it expires, the post parameter overrides the blog config
+func (a *goBlog) expiryAction(p *post) string {
+	action := p.firstParameter(expiresActionParam)
+	if action == "" {
+		if bc := a.getBlogFromPost(p); bc != nil && bc.PostExpiry != nil {
+			action = bc.PostExpiry.Action
+		}
+	}
+	switch action {
+	case expiryActionPrivate, expiryActionDelete:
+		return action
+	default:
+		return expiryActionUnlisted
+	}
+}
+
+func (a *goBlog) checkExpiredPosts() {
+	postsToExpire, err := a.getPosts(&postsRequestConfig{
+		status:    []postStatus{statusPublished},
+		parameter: expiresParam,
+	})
+	if err != nil {
+		a.error("Error getting expiring posts", "err", err)
+		return
+	}
+	for _, post := range postsToExpire {
+		expires, err := dateparse.ParseLocal(post.firstParameter(expiresParam))
+		if err != nil || expires.After(time.Now()) {
+			continue
+		}
+		if err := a.expirePost(post); err != nil {
+			a.error("Error expiring post", "path", post.Path, "err", err)
+			continue
+		}
+	}
+}
+
+func (a *goBlog) expirePost(p *post) error {
+	oldVisibility := p.Visibility
+	action := a.expiryAction(p)
+	switch action {
+	case expiryActionDelete:
+		// Remove the expiry date first, so the post isn't deleted again after it's restored
+		if err := a.db.replacePostParam(p.Path, expiresParam, nil); err != nil {
+			return err
+		}
+		// Runs the delete hooks
+		if err := a.deletePost(p.Path); err != nil {
+			return err
+		}
+	case expiryActionPrivate:
+		if oldVisibility == visibilityPrivate {
+			return nil
+		}
+		p.Visibility = visibilityPrivate
+		if err := a.replacePost(p, p.Path, p.Status, oldVisibility, true); err != nil {
+			return err
+		}
+		// The post isn't visible anymore, so remove it from ActivityPub, Telegram etc.
+		if oldVisibility == visibilityPublic || oldVisibility == visibilityUnlisted {
+			a.postDeleteHooks(p)
+		}
+	default:
+		if oldVisibility != visibilityPublic {
+			return nil
+		}
+		// Runs the update hooks
+		p.Visibility = visibilityUnlisted
+		if err := a.replacePost(p, p.Path, p.Status, oldVisibility, true); err != nil {
+			return err
+		}
+	}
+	a.info("Expired post", "path", p.Path, "action", action)
+	return nil
+}
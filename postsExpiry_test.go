@@ -0,0 +1,97 @@
+package main
+
+import (
+	"cmp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postsExpiry(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Blogs = map[string]*configBlog{
+		"en": {
+			Sections: map[string]*configSection{
+				"test": {},
+			},
+			Lang: "en",
+		},
+	}
+
+	updateHook, deleteHook := make(chan string, 10), make(chan string, 10)
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		updateHook <- p.Path
+	})
+	app.pDeleteHooks = append(app.pDeleteHooks, func(p *post) {
+		deleteHook <- p.Path
+	})
+
+	_ = app.initConfig(false)
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	for _, p := range []*post{
+		{Path: "/test/unlisted", Parameters: map[string][]string{expiresParam: {past}}},
+		{Path: "/test/private", Parameters: map[string][]string{expiresParam: {past}, expiresActionParam: {"private"}}},
+		{Path: "/test/delete", Parameters: map[string][]string{expiresParam: {past}, expiresActionParam: {"delete"}}},
+		{Path: "/test/protected", Visibility: visibilityProtected, Parameters: map[string][]string{expiresParam: {past}, expiresActionParam: {"private"}}},
+		{Path: "/test/future", Parameters: map[string][]string{expiresParam: {future}}},
+		{Path: "/test/never"},
+	} {
+		p.Content = "Content"
+		p.Blog = "en"
+		p.Section = "test"
+		p.Status = statusPublished
+		p.Visibility = cmp.Or(p.Visibility, visibilityPublic)
+		require.NoError(t, app.db.savePost(p, &postCreationOptions{isNew: true}))
+	}
+
+	app.checkExpiredPosts()
+
+	getPost := func(path string) *post {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p
+	}
+
+	unlisted := getPost("/test/unlisted")
+	assert.Equal(t, visibilityUnlisted, unlisted.Visibility)
+	assert.Equal(t, statusPublished, unlisted.Status)
+	assert.Empty(t, unlisted.Updated)
+	assert.Equal(t, visibilityPrivate, getPost("/test/private").Visibility)
+	assert.Equal(t, statusPublishedDeleted, getPost("/test/delete").Status)
+	assert.Equal(t, visibilityPrivate, getPost("/test/protected").Visibility)
+	assert.Equal(t, visibilityPublic, getPost("/test/future").Visibility)
+	assert.Equal(t, visibilityPublic, getPost("/test/never").Visibility)
+
+	// Hooks, the protected post wasn't public before
+	assert.Equal(t, "/test/unlisted", <-updateHook)
+	deleted := []string{<-deleteHook, <-deleteHook}
+	assert.ElementsMatch(t, []string{"/test/private", "/test/delete"}, deleted)
+
+	// Already expired posts are not changed again
+	app.checkExpiredPosts()
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, updateHook)
+	assert.Empty(t, deleteHook)
+
+	// Restored posts are not deleted again
+	require.NoError(t, app.undeletePost("/test/delete"))
+	restored := getPost("/test/delete")
+	assert.Equal(t, statusPublished, restored.Status)
+	assert.Empty(t, restored.firstParameter(expiresParam))
+	app.checkExpiredPosts()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, statusPublished, getPost("/test/delete").Status)
+	assert.Empty(t, deleteHook)
+
+	// Blog config sets the default action
+	app.cfg.Blogs["en"].PostExpiry = &configPostExpiry{Action: "private"}
+	assert.Equal(t, expiryActionPrivate, app.expiryAction(getPost("/test/future")))
+	assert.Equal(t, expiryActionDelete, app.expiryAction(getPost("/test/delete")))
+}
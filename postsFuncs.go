@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"time"
 
@@ -46,6 +47,7 @@ type postHTMLOptions struct {
 	absolute     bool
 	activityPub  bool
 	simpleImages bool
+	toc          bool
 }
 
 func (a *goBlog) postHTML(o *postHTMLOptions) (res string) {
@@ -74,10 +76,20 @@ func (a *goBlog) postHTMLToWriter(w io.Writer, o *postHTMLOptions) {
 		a.renderPostReplyContext(hb, o.p)
 	}
 	a.renderPostLikeContext(hb, o.p)
+	a.renderPostCheckinContext(hb, o.p)
+	a.renderPostMediaLogContext(hb, o.p)
 	// Render markdown
 	hb.WriteElementOpen("div", "class", "e-content")
-	_ = a.renderPostMarkdownToWriter(hb, o.p.Content, o.absolute, o.p.Path, o.simpleImages)
+	tocTitle := ""
+	if o.toc && a.tocEnabled(o.p) {
+		tocTitle = a.ts.GetTemplateStringVariant(a.getBlogFromPost(o.p).Lang, "toc")
+	}
+	_ = a.renderPostMarkdownToWriter(hb, o.p.Content, o.absolute, o.p.Path, o.simpleImages, tocTitle)
 	hb.WriteElementClose("div")
+	// Add gallery
+	if a.isGallery(o.p) {
+		a.renderGallery(hb, o.p, o.absolute, o.simpleImages)
+	}
 	// Add bookmark links to the bottom
 	for _, l := range o.p.Parameters[a.cfg.Micropub.BookmarkParam] {
 		hb.WriteElementOpen("p")
@@ -98,7 +110,7 @@ func (a *goBlog) feedHTML(w io.Writer, p *post) {
 		hb.WriteElementClose("audio")
 	}
 	// Add post HTML
-	a.postHTMLToWriter(hb, &postHTMLOptions{p: p, absolute: true, simpleImages: true})
+	a.postHTMLToWriter(hb, &postHTMLOptions{p: p, absolute: true, simpleImages: true, toc: true})
 	// Add link to interactions and comments
 	if a.commentsEnabledForPost(p) {
 		hb.WriteElementOpen("p")
@@ -206,6 +218,8 @@ func (a *goBlog) postMfProperties(p *post, contentWithParams bool) map[string][]
 		mfVisibility = "unlisted"
 	case visibilityPrivate:
 		mfVisibility = "private"
+	case visibilityProtected:
+		mfVisibility = "protected"
 	}
 
 	properties := map[string][]any{}
@@ -236,6 +250,16 @@ func (a *goBlog) postMfProperties(p *post, contentWithParams bool) map[string][]
 	addIfNotEmpty("audio", p.Parameters[a.cfg.Micropub.AudioParam])
 	addIfNotEmpty("mp-channel", []string{p.getChannel()})
 	addIfNotEmpty("location", p.Parameters[a.cfg.Micropub.LocationParam])
+	addIfNotEmpty("start", p.Parameters[eventStartParam])
+	addIfNotEmpty("end", p.Parameters[eventEndParam])
+	addIfNotEmpty("rsvp", p.Parameters[a.cfg.Micropub.RsvpParam])
+	if venue := a.checkinVenue(p); venue != nil {
+		properties["checkin"] = []any{venue}
+	}
+	if kind := postMediaLogKind(p); kind != nil {
+		properties[kind.property] = []any{mediaLogCite(p, kind)}
+		addIfNotEmpty("rating", p.Parameters[mediaLogRatingParam])
+	}
 
 	return properties
 }
@@ -286,6 +310,41 @@ func (a *goBlog) likeContext(p *post) string {
 	return p.firstParameter(a.cfg.Micropub.LikeContextParam)
 }
 
+func (a *goBlog) rsvp(p *post) string {
+	return strings.ToLower(p.firstParameter(a.cfg.Micropub.RsvpParam))
+}
+
+func (a *goBlog) checkinName(p *post) string {
+	return p.firstParameter(a.cfg.Micropub.CheckinParam)
+}
+
+func (a *goBlog) checkinURL(p *post) string {
+	return p.firstParameter(a.cfg.Micropub.CheckinURLParam)
+}
+
+// checkinVenue returns the venue of a check-in as microformats h-card
+func (a *goBlog) checkinVenue(p *post) map[string]any {
+	name, url := a.checkinName(p), a.checkinURL(p)
+	if name == "" && url == "" {
+		return nil
+	}
+	properties := map[string]any{}
+	if name != "" {
+		properties["name"] = []any{name}
+	}
+	if url != "" {
+		properties["url"] = []any{url}
+	}
+	if geoURIs := a.geoURIs(p); len(geoURIs) > 0 {
+		properties["latitude"] = []any{strconv.FormatFloat(geoURIs[0].Latitude, 'f', -1, 64)}
+		properties["longitude"] = []any{strconv.FormatFloat(geoURIs[0].Longitude, 'f', -1, 64)}
+	}
+	return map[string]any{
+		"type":       []any{"h-card"},
+		"properties": properties,
+	}
+}
+
 func (a *goBlog) photoLinks(p *post) []string {
 	return p.Parameters[a.cfg.Micropub.PhotoParam]
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	c "go.goblog.app/app/pkgs/cache"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+const (
+	protectedPasswordParam    = "password"
+	protectedShareParam       = "share"
+	protectedUnlockPath       = "/-/protected"
+	protectedShareLinkMaxAge  = 7 * 24 * time.Hour
+	protectedSessionKeyPrefix = "protected:"
+	protectedMaxFailedUnlocks = 5
+	protectedFailedUnlocksTTL = 15 * time.Minute
+)
+
+// getProtectedPostPasswordHash returns the bcrypt hash of the password of the post (empty if there's none)
+func (db *database) getProtectedPostPasswordHash(path string) (string, error) {
+	row, err := db.QueryRow("select hash from post_passwords where path = @path", sql.Named("path", path))
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	err = row.Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (a *goBlog) protectedPostKey() []byte {
+	a.ppLoad.Do(func() {
+		// Try to load key from database
+		keyBytes, err := a.db.retrievePersistentCache("protectedpostkey")
+		if err != nil {
+			a.error("Failed to retrieve protected post key", "err", err)
+			return
+		}
+		if keyBytes == nil {
+			// Generate random key
+			keyBytes = []byte(randomString(64))
+			// Store key in database
+			err = a.db.cachePersistently("protectedpostkey", keyBytes)
+			if err != nil {
+				a.error("Failed to cache protected post key", "err", err)
+				return
+			}
+		}
+		a.ppKey = keyBytes
+	})
+	return a.ppKey
+}
+
+// protectedPostSignature signs the path together with the hash of the current password,
+// so changing the password (which creates a new salted hash) revokes existing unlocks and share links
+func (a *goBlog) protectedPostSignature(p *post, expires int64) string {
+	key := a.protectedPostKey()
+	if len(key) == 0 {
+		return ""
+	}
+	hash, err := a.db.getProtectedPostPasswordHash(p.Path)
+	if err != nil {
+		a.error("Failed to get protected post password", "err", err)
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%s\n%s\n%d", p.Path, hash, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// protectedPostShareLink returns a link to the post that unlocks it until it expires
+func (a *goBlog) protectedPostShareLink(p *post) string {
+	expires := time.Now().Add(protectedShareLinkMaxAge).Unix()
+	token := strconv.FormatInt(expires, 10) + "-" + a.protectedPostSignature(p, expires)
+	return a.fullPostURL(p) + "?" + protectedShareParam + "=" + token
+}
+
+func (a *goBlog) checkProtectedPostShareToken(p *post, token string) bool {
+	expiresString, signature, ok := strings.Cut(token, "-")
+	if !ok {
+		return false
+	}
+	expires, err := strconv.ParseInt(expiresString, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	expected := a.protectedPostSignature(p, expires)
+	return expected != "" && hmac.Equal([]byte(expected), []byte(signature))
+}
+
+func (a *goBlog) checkProtectedPostPassword(p *post, password string) bool {
+	hash, err := a.db.getProtectedPostPasswordHash(p.Path)
+	if err != nil {
+		a.error("Failed to get protected post password", "err", err)
+		return false
+	}
+	return checkPasswordHash(password, hash)
+}
+
+// protectedUnlockThrottleKey identifies the client and the post for counting failed unlocks
+func protectedUnlockThrottleKey(r *http.Request, p *post) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	return ip + "\n" + p.Path
+}
+
+// protectedUnlockThrottled checks if the client tried too many wrong passwords for the post recently
+func (a *goBlog) protectedUnlockThrottled(key string) bool {
+	a.ppFailedInit.Do(func() {
+		a.ppFailed = c.New[string, int](time.Minute, 10000)
+	})
+	failed, _ := a.ppFailed.Get(key)
+	return failed >= protectedMaxFailedUnlocks
+}
+
+func (a *goBlog) protectedUnlockFailed(key string) {
+	failed, _ := a.ppFailed.Get(key)
+	a.ppFailed.Set(key, failed+1, protectedFailedUnlocksTTL, 1)
+}
+
+// isProtectedPostUnlocked checks the session for an unlock of the post with the current password
+func (a *goBlog) isProtectedPostUnlocked(r *http.Request, p *post) bool {
+	a.initSessionStores()
+	ses, err := a.loginSessions.Get(r, "l")
+	if err != nil || ses == nil {
+		return false
+	}
+	unlocked, ok := ses.Values[protectedSessionKeyPrefix+p.Path].(string)
+	expected := a.protectedPostSignature(p, 0)
+	return ok && expected != "" && hmac.Equal([]byte(expected), []byte(unlocked))
+}
+
+// unlockProtectedPost remembers the unlock in the session
+func (a *goBlog) unlockProtectedPost(w http.ResponseWriter, r *http.Request, p *post) error {
+	a.initSessionStores()
+	ses, err := a.loginSessions.Get(r, "l")
+	if err != nil {
+		return err
+	}
+	ses.Values[protectedSessionKeyPrefix+p.Path] = a.protectedPostSignature(p, 0)
+	return a.loginSessions.Save(r, w, ses)
+}
+
+// Middleware to require the password or a valid share link for protected posts
+func (a *goBlog) protectedPostMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.isLoggedIn(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		p, err := a.getPost(r.URL.Path)
+		if errors.Is(err, errPostNotFound) {
+			a.serve404(w, r)
+			return
+		} else if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if a.isProtectedPostUnlocked(r, p) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if token := r.URL.Query().Get(protectedShareParam); token != "" && a.checkProtectedPostShareToken(p, token) {
+			if err := a.unlockProtectedPost(w, r, p); err != nil {
+				a.error("Failed to save protected post session", "err", err)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		a.serveProtectedPostForm(w, r, p, false)
+	})
+}
+
+func (a *goBlog) serveProtectedPostForm(w http.ResponseWriter, r *http.Request, p *post, wrongPassword bool) {
+	w.Header().Set("X-Robots-Tag", "noindex")
+	a.renderWithStatusCode(w, r, http.StatusUnauthorized, a.renderProtectedPost, &renderData{
+		BlogString: p.Blog,
+		Data: &protectedPostRenderData{
+			path:          p.Path,
+			wrongPassword: wrongPassword,
+		},
+	})
+}
+
+func (a *goBlog) serveProtectedPostUnlock(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path")) //nolint:gosec
+	if err != nil || p.Visibility != visibilityProtected {
+		a.serve404(w, r)
+		return
+	}
+	throttleKey := protectedUnlockThrottleKey(r, p)
+	if a.protectedUnlockThrottled(throttleKey) {
+		a.serveError(w, r, "too many wrong passwords, try again later", http.StatusTooManyRequests)
+		return
+	}
+	if !a.checkProtectedPostPassword(p, r.FormValue("password")) { //nolint:gosec
+		a.protectedUnlockFailed(throttleKey)
+		a.serveProtectedPostForm(w, r, p, true)
+		return
+	}
+	if err := a.unlockProtectedPost(w, r, p); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, p.Path, http.StatusFound)
+}
+
+type protectedPostRenderData struct {
+	path          string
+	wrongPassword bool
+}
+
+func (a *goBlog) renderProtectedPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	pd, ok := rd.Data.(*protectedPostRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedpost"))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedpost"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedpostdesc"))
+			hb.WriteElementClose("p")
+			if pd.wrongPassword {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("strong")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "wrongpassword"))
+				hb.WriteElementClose("strong")
+				hb.WriteElementClose("p")
+			}
+			hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", protectedUnlockPath)
+			hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", pd.path)
+			hb.WriteElementOpen("input", "type", "password", "name", "password", "autocomplete", "current-password", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "password"), "required", "")
+			hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "unlock"))
+			hb.WriteElementClose("form")
+			hb.WriteElementClose("main")
+		},
+	)
+}
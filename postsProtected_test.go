@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_protectedPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/protected",
+		Section:    "posts",
+		Content:    "Secret content",
+		Visibility: visibilityProtected,
+		Parameters: map[string][]string{"title": {"Protected"}, protectedPasswordParam: {"open sesame"}},
+	}))
+
+	t.Run("Password not exposed", func(t *testing.T) {
+		p, err := app.getPost("/protected")
+		require.NoError(t, err)
+		assert.NotContains(t, p.Parameters, protectedPasswordParam)
+		assert.NotContains(t, p.contentWithParams(), "open sesame")
+		revisions, err := app.db.getPostRevisions("/protected")
+		require.NoError(t, err)
+		for _, r := range revisions {
+			assert.NotContains(t, r.Parameters, protectedPasswordParam)
+		}
+		hash, err := app.db.getProtectedPostPasswordHash("/protected")
+		require.NoError(t, err)
+		assert.True(t, checkPasswordHash("open sesame", hash))
+	})
+
+	newClient := func() *http.Client {
+		client := newHandlerClient(app.d)
+		client.Jar, _ = cookiejar.New(nil)
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		return client
+	}
+	get := func(client *http.Client, path string) (int, string) {
+		res, err := client.Get("https://example.com" + path)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+	unlock := func(client *http.Client, password string) (int, string) {
+		req, err := http.NewRequest(http.MethodPost, "https://example.com"+protectedUnlockPath, strings.NewReader(url.Values{
+			"path":     {"/protected"},
+			"password": {password},
+		}.Encode()))
+		require.NoError(t, err)
+		req.Header.Set(contentType, contenttype.WWWForm)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res.StatusCode, string(body)
+	}
+
+	t.Run("Locked", func(t *testing.T) {
+		status, body := get(newClient(), "/protected")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.NotContains(t, body, "Secret content")
+		assert.Contains(t, body, "type=password")
+	})
+
+	t.Run("Password", func(t *testing.T) {
+		client := newClient()
+		status, body := unlock(client, "wrong")
+		assert.Equal(t, http.StatusUnauthorized, status)
+		assert.Contains(t, body, "Wrong password")
+
+		status, _ = unlock(client, "open sesame")
+		assert.Equal(t, http.StatusFound, status)
+
+		status, body = get(client, "/protected")
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "Secret content")
+	})
+
+	t.Run("Share link", func(t *testing.T) {
+		p, err := app.getPost("/protected")
+		require.NoError(t, err)
+		shareLink := app.protectedPostShareLink(p)
+		assert.True(t, strings.HasPrefix(shareLink, "https://example.com/protected?share="))
+
+		client := newClient()
+		status, body := get(client, strings.TrimPrefix(shareLink, "https://example.com"))
+		assert.Equal(t, http.StatusOK, status)
+		assert.Contains(t, body, "Secret content")
+
+		// Unlock is remembered in the session
+		status, _ = get(client, "/protected")
+		assert.Equal(t, http.StatusOK, status)
+
+		// Expired or forged links don't work
+		assert.False(t, app.checkProtectedPostShareToken(p, "1-"+app.protectedPostSignature(p, 1)))
+		status, _ = get(newClient(), "/protected?share=9999999999-abc")
+		assert.Equal(t, http.StatusUnauthorized, status)
+
+		// Saving without a new password keeps it
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+		assert.True(t, app.checkProtectedPostShareToken(p, strings.TrimPrefix(shareLink, "https://example.com/protected?share=")))
+
+		// Changing the password revokes unlocks and share links
+		p.Parameters[protectedPasswordParam] = []string{"new password"}
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+		assert.False(t, app.checkProtectedPostShareToken(p, strings.TrimPrefix(shareLink, "https://example.com/protected?share=")))
+		status, _ = get(client, "/protected")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("Excluded", func(t *testing.T) {
+		client := newClient()
+		for _, path := range []string{"/", "/.rss", "/sitemap-blog-posts.xml"} {
+			_, body := get(client, path)
+			assert.NotContains(t, body, "/protected", path)
+		}
+	})
+
+	t.Run("Throttling", func(t *testing.T) {
+		client := newClient()
+		for range protectedMaxFailedUnlocks {
+			status, _ := unlock(client, "wrong")
+			assert.Contains(t, []int{http.StatusUnauthorized, http.StatusTooManyRequests}, status)
+		}
+		// Even the right password is rejected after too many wrong ones
+		status, _ := unlock(client, "new password")
+		assert.Equal(t, http.StatusTooManyRequests, status)
+	})
+}
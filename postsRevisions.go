@@ -0,0 +1,352 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.yaml.in/yaml/v4"
+)
+
+const (
+	editorRevisionsPath        = "/revisions"
+	defaultPostRevisionsToKeep = 50
+)
+
+// Parameters set by integrations after saving the post, restoring a revision keeps their current values
+var postRevisionKeepParams = []string{
+	activityPubMentionsParameter, activityPubReplyActorParameter, activityPubReplyObjectParameter, activityPubVersionParam,
+	atprotoURIParam, telegramChatParam, telegramMsgParam, ttsParameter,
+}
+
+type postRevision struct {
+	ID         int
+	Path       string
+	Content    string
+	Parameters map[string][]string
+	Status     postStatus
+	Visibility postVisibility
+	Created    int64
+}
+
+func (a *goBlog) initPostRevisions() {
+	a.hourlyHooks = append(a.hourlyHooks, a.pruneOldPostRevisions)
+}
+
+func (a *goBlog) postRevisionsToKeep() int {
+	if rc := a.cfg.Revisions; rc != nil && rc.Keep > 0 {
+		return rc.Keep
+	}
+	return defaultPostRevisionsToKeep
+}
+
+// postRevisionSQL appends the statements to store the saved post as a revision and prune the oldest revisions of the post
+func (a *goBlog) postRevisionSQL(sqlBuilder *strings.Builder, sqlArgs *[]any, p *post) error {
+	params := map[string][]string{}
+	for param, values := range p.Parameters {
+		if filtered := lo.Filter(values, loStringNotEmpty); len(filtered) > 0 {
+			params[param] = filtered
+		}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	sqlBuilder.WriteString("insert into post_revisions (path, content, parameters, status, visibility, created) values (?, ?, ?, ?, ?, ?);")
+	*sqlArgs = append(*sqlArgs, p.Path, p.Content, string(paramsJSON), p.Status, p.Visibility, time.Now().Unix())
+	sqlBuilder.WriteString("delete from post_revisions where path = ? and id not in (select id from post_revisions where path = ? order by id desc limit ?);")
+	*sqlArgs = append(*sqlArgs, p.Path, p.Path, a.postRevisionsToKeep())
+	return nil
+}
+
+// pruneOldPostRevisions deletes revisions older than the configured max age, but keeps the latest revision of each post
+func (a *goBlog) pruneOldPostRevisions() {
+	rc := a.cfg.Revisions
+	if rc == nil || rc.MaxAge <= 0 {
+		return
+	}
+	_, err := a.db.Exec(
+		"delete from post_revisions where created < @created and id not in (select max(id) from post_revisions group by path)",
+		sql.Named("created", time.Now().AddDate(0, 0, -rc.MaxAge).Unix()),
+	)
+	if err != nil {
+		a.error("Failed to prune old post revisions", "err", err)
+	}
+}
+
+func (db *database) getPostRevisions(path string) ([]*postRevision, error) {
+	rows, err := db.Query(
+		"select id, path, content, parameters, status, visibility, created from post_revisions where path = @path order by id desc",
+		sql.Named("path", path),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []*postRevision
+	for rows.Next() {
+		rev := &postRevision{}
+		var params string
+		if err = rows.Scan(&rev.ID, &rev.Path, &rev.Content, &params, &rev.Status, &rev.Visibility, &rev.Created); err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal([]byte(params), &rev.Parameters); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// restorePostRevision replaces the post with the content, parameters, status and visibility of the revision
+func (a *goBlog) restorePostRevision(p *post, rev *postRevision) error {
+	oldStatus, oldVisibility := p.Status, p.Visibility
+	params := maps.Clone(rev.Parameters)
+	if params == nil {
+		params = map[string][]string{}
+	}
+	for _, param := range postRevisionKeepParams {
+		delete(params, param)
+		if values, ok := p.Parameters[param]; ok {
+			params[param] = values
+		}
+	}
+	p.Content = rev.Content
+	p.Parameters = params
+	p.Status = rev.Status
+	p.Visibility = rev.Visibility
+	return a.replacePost(p, p.Path, oldStatus, oldVisibility, false)
+}
+
+// revisionMarkdown returns the revision as Markdown with front matter, used to compare revisions
+func revisionMarkdown(content string, params map[string][]string, status postStatus, visibility postVisibility) string {
+	frontMatter := map[string]any{}
+	for k, v := range params {
+		if slices.Contains(postRevisionKeepParams, k) {
+			continue
+		}
+		if l := len(v); l == 1 {
+			frontMatter[k] = v[0]
+		} else if l > 1 {
+			frontMatter[k] = v
+		}
+	}
+	frontMatter["status"] = string(status)
+	frontMatter["visibility"] = string(visibility)
+	fb, _ := yaml.Marshal(frontMatter)
+	return fmt.Sprintf("---\n%s---\n%s", string(fb), content)
+}
+
+type revisionDiffRow struct {
+	old, new               string
+	hasOld, hasNew         bool
+	oldChanged, newChanged bool
+}
+
+// sideBySideDiff compares the texts line by line, changed lines are shown next to each other
+func sideBySideDiff(oldText, newText string) []*revisionDiffRow {
+	oldLines, newLines := strings.Split(oldText, "\n"), strings.Split(newText, "\n")
+	var rows []*revisionDiffRow
+	var deleted, inserted []string
+	flush := func() {
+		for k := range max(len(deleted), len(inserted)) {
+			row := &revisionDiffRow{}
+			if k < len(deleted) {
+				row.old, row.hasOld, row.oldChanged = deleted[k], true, true
+			}
+			if k < len(inserted) {
+				row.new, row.hasNew, row.newChanged = inserted[k], true, true
+			}
+			rows = append(rows, row)
+		}
+		deleted, inserted = nil, nil
+	}
+	i, j := 0, 0
+	for _, op := range lineDiff(oldLines, newLines) {
+		switch op {
+		case lineDiffEqual:
+			flush()
+			rows = append(rows, &revisionDiffRow{old: oldLines[i], new: newLines[j], hasOld: true, hasNew: true})
+			i++
+			j++
+		case lineDiffDelete:
+			deleted = append(deleted, oldLines[i])
+			i++
+		case lineDiffInsert:
+			inserted = append(inserted, newLines[j])
+			j++
+		}
+	}
+	flush()
+	return rows
+}
+
+type lineDiffOp int
+
+const (
+	lineDiffEqual lineDiffOp = iota
+	lineDiffDelete
+	lineDiffInsert
+)
+
+// lineDiff returns the operations to turn the old lines into the new lines,
+// using Hirschberg's algorithm for the longest common subsequence to only need linear memory
+func lineDiff(oldLines, newLines []string) []lineDiffOp {
+	ops := make([]lineDiffOp, 0, max(len(oldLines), len(newLines)))
+	// Unchanged lines at the beginning and the end don't need to be compared
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+	for range prefix {
+		ops = append(ops, lineDiffEqual)
+	}
+	ops = hirschbergDiff(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix], ops)
+	for range suffix {
+		ops = append(ops, lineDiffEqual)
+	}
+	return ops
+}
+
+func hirschbergDiff(a, b []string, ops []lineDiffOp) []lineDiffOp {
+	switch {
+	case len(a) == 0:
+		for range b {
+			ops = append(ops, lineDiffInsert)
+		}
+	case len(b) == 0:
+		for range a {
+			ops = append(ops, lineDiffDelete)
+		}
+	case len(a) == 1:
+		idx := slices.Index(b, a[0])
+		if idx < 0 {
+			ops = append(ops, lineDiffDelete)
+			idx = len(b)
+		}
+		for range idx {
+			ops = append(ops, lineDiffInsert)
+		}
+		if idx < len(b) {
+			ops = append(ops, lineDiffEqual)
+			for range len(b) - idx - 1 {
+				ops = append(ops, lineDiffInsert)
+			}
+		}
+	default:
+		// Split the old lines in half and find the best matching split of the new lines
+		mid := len(a) / 2
+		forward := lcsLengths(a[:mid], b, false)
+		backward := lcsLengths(a[mid:], b, true)
+		split, best := 0, -1
+		for k := range len(b) + 1 {
+			if l := forward[k] + backward[len(b)-k]; l > best {
+				split, best = k, l
+			}
+		}
+		ops = hirschbergDiff(a[:mid], b[:split], ops)
+		ops = hirschbergDiff(a[mid:], b[split:], ops)
+	}
+	return ops
+}
+
+// lcsLengths returns the lengths of the longest common subsequences of a and each prefix of b (or suffix if reversed)
+func lcsLengths(a, b []string, reversed bool) []int {
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for i := range a {
+		ai := a[i]
+		if reversed {
+			ai = a[len(a)-1-i]
+		}
+		for j := range b {
+			bj := b[j]
+			if reversed {
+				bj = b[len(b)-1-j]
+			}
+			if ai == bj {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+type editorRevisionsRenderData struct {
+	post      *post
+	revisions []*postRevision
+	compare   *postRevision
+	diff      []*revisionDiffRow
+}
+
+func (a *goBlog) serveEditorRevisions(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path")) //nolint:gosec
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	revisions, err := a.db.getPostRevisions(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rd := &editorRevisionsRenderData{post: p, revisions: revisions}
+	if id := stringToInt(r.FormValue("id")); id != 0 { //nolint:gosec
+		rev, ok := lo.Find(revisions, func(rev *postRevision) bool { return rev.ID == id })
+		if !ok {
+			a.serve404(w, r)
+			return
+		}
+		rd.compare = rev
+		rd.diff = sideBySideDiff(
+			revisionMarkdown(rev.Content, rev.Parameters, rev.Status, rev.Visibility),
+			revisionMarkdown(p.Content, p.Parameters, p.Status, p.Visibility),
+		)
+	}
+	a.render(w, r, a.renderEditorRevisions, &renderData{
+		Data: rd,
+	})
+}
+
+func (a *goBlog) serveEditorRevisionRestore(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path")) //nolint:gosec
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	revisions, err := a.db.getPostRevisions(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id := stringToInt(r.FormValue("id")) //nolint:gosec
+	rev, ok := lo.Find(revisions, func(rev *postRevision) bool { return rev.ID == id })
+	if !ok {
+		a.serve404(w, r)
+		return
+	}
+	if err := a.restorePostRevision(p, rev); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, p.Path, http.StatusFound)
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_postRevisions(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Revisions = &configRevisions{Keep: 3}
+
+	err := app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	p := &post{
+		Path:    "/test/revisions",
+		Content: "First version",
+		Parameters: map[string][]string{
+			"title": {"Title"},
+		},
+	}
+	require.NoError(t, app.createPost(p))
+
+	p.Content = "Second version"
+	require.NoError(t, app.replacePost(p, p.Path, statusPublished, visibilityPublic, false))
+
+	revisions, err := app.db.getPostRevisions(p.Path)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, "Second version", revisions[0].Content)
+	assert.Equal(t, "First version", revisions[1].Content)
+	assert.Equal(t, []string{"Title"}, revisions[1].Parameters["title"])
+	assert.Equal(t, statusPublished, revisions[1].Status)
+
+	t.Run("Keep", func(t *testing.T) {
+		for _, content := range []string{"Third version", "Fourth version"} {
+			p.Content = content
+			require.NoError(t, app.replacePost(p, p.Path, statusPublished, visibilityPublic, false))
+		}
+		revisions, err := app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		require.Len(t, revisions, 3)
+		assert.Equal(t, "Fourth version", revisions[0].Content)
+		assert.Equal(t, "Second version", revisions[2].Content)
+	})
+
+	t.Run("Restore", func(t *testing.T) {
+		revisions, err := app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		oldest := revisions[len(revisions)-1]
+
+		// Parameters set by integrations are kept
+		require.NoError(t, app.db.replacePostParam(p.Path, telegramMsgParam, []string{"123"}))
+
+		mux := chi.NewMux()
+		mux.Use(middleware.WithValue(blogKey, app.cfg.DefaultBlog))
+		mux.Post(editorRevisionsPath, app.serveEditorRevisionRestore)
+
+		data := url.Values{}
+		data.Add("path", p.Path)
+		data.Add("id", "999")
+		req := httptest.NewRequest(http.MethodPost, editorRevisionsPath, strings.NewReader(data.Encode()))
+		req.Header.Add(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+
+		data.Set("id", strconv.Itoa(oldest.ID))
+		req = httptest.NewRequest(http.MethodPost, editorRevisionsPath, strings.NewReader(data.Encode()))
+		req.Header.Add(contentType, contenttype.WWWForm)
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, p.Path, rec.Header().Get("Location"))
+
+		restored, err := app.getPost(p.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "Second version", restored.Content)
+		assert.Equal(t, "Title", restored.Title())
+		assert.Equal(t, "123", restored.firstParameter(telegramMsgParam))
+
+		// The restore is a new revision
+		revisions, err = app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		assert.Equal(t, "Second version", revisions[0].Content)
+	})
+
+	t.Run("Compare", func(t *testing.T) {
+		revisions, err := app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+
+		mux := chi.NewMux()
+		mux.Use(middleware.WithValue(blogKey, app.cfg.DefaultBlog))
+		mux.Get(editorRevisionsPath, app.serveEditorRevisions)
+
+		req := httptest.NewRequest(http.MethodGet, editorRevisionsPath+"?path="+url.QueryEscape(p.Path)+"&id="+strconv.Itoa(revisions[1].ID), nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "revision-diff")
+		assert.Contains(t, body, "Fourth version")
+		assert.Contains(t, body, "Second version")
+
+		req = httptest.NewRequest(http.MethodGet, editorRevisionsPath+"?path=/unknown", nil)
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("Max age", func(t *testing.T) {
+		_, err := app.db.Exec("update post_revisions set created = 0")
+		require.NoError(t, err)
+
+		// Without max age nothing is deleted
+		app.pruneOldPostRevisions()
+		revisions, err := app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		assert.Len(t, revisions, 3)
+
+		// The latest revision is kept
+		app.cfg.Revisions.MaxAge = 30
+		app.pruneOldPostRevisions()
+		revisions, err = app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		require.Len(t, revisions, 1)
+		assert.Equal(t, "Second version", revisions[0].Content)
+	})
+
+	t.Run("Delete post", func(t *testing.T) {
+		require.NoError(t, app.deletePost(p.Path))
+		require.NoError(t, app.deletePost(p.Path))
+		revisions, err := app.db.getPostRevisions(p.Path)
+		require.NoError(t, err)
+		assert.Len(t, revisions, 0)
+	})
+}
+
+func Test_sideBySideDiff(t *testing.T) {
+	rows := sideBySideDiff("a\nb\nc\nd", "a\nB\nc\nd\ne")
+	require.Len(t, rows, 5)
+
+	assert.Equal(t, &revisionDiffRow{old: "a", new: "a", hasOld: true, hasNew: true}, rows[0])
+	assert.Equal(t, &revisionDiffRow{old: "b", new: "B", hasOld: true, hasNew: true, oldChanged: true, newChanged: true}, rows[1])
+	assert.Equal(t, &revisionDiffRow{old: "c", new: "c", hasOld: true, hasNew: true}, rows[2])
+	assert.Equal(t, &revisionDiffRow{old: "d", new: "d", hasOld: true, hasNew: true}, rows[3])
+	assert.Equal(t, &revisionDiffRow{new: "e", hasNew: true, newChanged: true}, rows[4])
+
+	rows = sideBySideDiff("same", "same")
+	require.Len(t, rows, 1)
+	assert.False(t, rows[0].oldChanged || rows[0].newChanged)
+}
+
+func Test_lineDiff(t *testing.T) {
+	apply := func(oldLines, newLines []string, ops []lineDiffOp) (result []string, common int) {
+		i, j := 0, 0
+		for _, op := range ops {
+			switch op {
+			case lineDiffEqual:
+				require.Equal(t, oldLines[i], newLines[j])
+				result = append(result, newLines[j])
+				common++
+				i++
+				j++
+			case lineDiffDelete:
+				i++
+			case lineDiffInsert:
+				result = append(result, newLines[j])
+				j++
+			}
+		}
+		require.Equal(t, len(oldLines), i)
+		return result, common
+	}
+
+	for _, tc := range []struct {
+		old, new string
+		common   int
+	}{
+		{"a b c d", "a B c d e", 3},
+		{"a b c a b b a", "c b a b a c", 4},
+		{"x y z", "1 2 3", 0},
+		{"", "a b", 0},
+		{"a b", "", 0},
+		{"a b c d e f", "a c e f b", 4},
+	} {
+		oldLines, newLines := strings.Fields(tc.old), strings.Fields(tc.new)
+		result, common := apply(oldLines, newLines, lineDiff(oldLines, newLines))
+		assert.Equal(t, tc.new, strings.Join(result, " "), tc)
+		assert.Equal(t, tc.common, common, tc)
+	}
+
+	// Long texts
+	oldLines, newLines := make([]string, 5000), make([]string, 5000)
+	for i := range oldLines {
+		oldLines[i] = strconv.Itoa(i)
+		newLines[i] = strconv.Itoa(i)
+		if i%100 == 0 {
+			newLines[i] = "changed"
+		}
+	}
+	_, common := apply(oldLines, newLines, lineDiff(oldLines, newLines))
+	assert.Equal(t, 4950, common)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+const editorScheduledEditsPath = "/scheduled-edits"
+
+// scheduledEdit is a pending update of a published post that is applied at a specific time
+type scheduledEdit struct {
+	ID        int
+	Path      string
+	Content   string
+	NoUpdated bool
+	ApplyAt   int64
+	Error     string
+}
+
+func (db *database) saveScheduledEdit(e *scheduledEdit) error {
+	_, err := db.Exec(
+		"insert into scheduled_edits (path, content, noupdated, apply_at) values (@path, @content, @noupdated, @applyat)",
+		sql.Named("path", e.Path), sql.Named("content", e.Content), sql.Named("noupdated", e.NoUpdated), sql.Named("applyat", e.ApplyAt),
+	)
+	return err
+}
+
+// failScheduledEdit stores why the edit couldn't be applied, so it isn't retried
+func (db *database) failScheduledEdit(id int, msg string) error {
+	_, err := db.Exec("update scheduled_edits set error = @error where id = @id", sql.Named("error", msg), sql.Named("id", id))
+	return err
+}
+
+func (db *database) deleteScheduledEdit(id int) error {
+	_, err := db.Exec("delete from scheduled_edits where id = @id", sql.Named("id", id))
+	return err
+}
+
+// getScheduledEdits returns the scheduled edits of the blog (or all blogs if empty) that are due before the time
+// and didn't fail yet (or all, including failed ones, if zero)
+func (db *database) getScheduledEdits(blog string, before time.Time) ([]*scheduledEdit, error) {
+	query := "select e.id, e.path, e.content, e.noupdated, e.apply_at, e.error from scheduled_edits e join posts p on e.path = p.path where 1 = 1"
+	var args []any
+	if blog != "" {
+		query += " and p.blog = @blog"
+		args = append(args, sql.Named("blog", blog))
+	}
+	if !before.IsZero() {
+		query += " and e.apply_at <= @before and e.error = ''"
+		args = append(args, sql.Named("before", before.Unix()))
+	}
+	query += " order by e.apply_at, e.id"
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var edits []*scheduledEdit
+	for rows.Next() {
+		e := &scheduledEdit{}
+		if err = rows.Scan(&e.ID, &e.Path, &e.Content, &e.NoUpdated, &e.ApplyAt, &e.Error); err != nil {
+			return nil, err
+		}
+		edits = append(edits, e)
+	}
+	return edits, rows.Err()
+}
+
+// scheduleEdit validates the new content (Markdown with front matter, like in the editor) and stores it to be applied later
+func (a *goBlog) scheduleEdit(path, content string, noUpdated bool, applyAt time.Time) error {
+	p, err := a.getPost(path)
+	if err != nil {
+		return err
+	}
+	if p.Deleted() {
+		return errors.New("post is marked as deleted, undelete it first")
+	}
+	// Check the edit without saving anything, images and EXIF data are added when applying it
+	edited := &post{
+		Path:       p.Path,
+		Blog:       p.Blog,
+		Section:    p.Section,
+		Published:  p.Published,
+		Updated:    p.Updated,
+		Status:     p.Status,
+		Visibility: p.Visibility,
+		Content:    content,
+		Parameters: map[string][]string{},
+	}
+	if err = extractFrontmatter(edited); err != nil {
+		return err
+	}
+	if edited.firstParameter(protectedPasswordParam) != "" {
+		// Scheduled edits are stored as they are, so they must not contain a password
+		return errors.New("scheduled edits can't change the password, change it in the editor")
+	}
+	extractPostFields(edited)
+	if err = a.checkPost(edited, false, noUpdated); err != nil {
+		return err
+	}
+	return a.db.saveScheduledEdit(&scheduledEdit{
+		Path:      p.Path,
+		Content:   content,
+		NoUpdated: noUpdated,
+		ApplyAt:   applyAt.Unix(),
+	})
+}
+
+func (a *goBlog) checkScheduledEdits() {
+	edits, err := a.db.getScheduledEdits("", time.Now())
+	if err != nil {
+		a.error("Error getting scheduled edits", "err", err)
+		return
+	}
+	for _, e := range edits {
+		if err := a.applyScheduledEdit(e); err != nil {
+			a.error("Error applying scheduled edit", "path", e.Path, "err", err)
+			if err = a.db.failScheduledEdit(e.ID, err.Error()); err != nil {
+				a.error("Error marking scheduled edit as failed", "path", e.Path, "err", err)
+			}
+			continue
+		}
+		a.info("Applied scheduled edit", "path", e.Path)
+	}
+}
+
+// applyScheduledEdit updates the post the same way as the editor does and removes the scheduled edit
+func (a *goBlog) applyScheduledEdit(e *scheduledEdit) error {
+	p, err := a.getPost(e.Path)
+	if err != nil {
+		return err
+	}
+	if p.Deleted() {
+		return errors.New("post is marked as deleted")
+	}
+	oldPath, oldStatus, oldVisibility := p.Path, p.Status, p.Visibility
+	p.Content = e.Content
+	if err = a.processContentAndParameters(p); err != nil {
+		return err
+	}
+	if err = a.replacePost(p, oldPath, oldStatus, oldVisibility, e.NoUpdated); err != nil {
+		return err
+	}
+	return a.db.deleteScheduledEdit(e.ID)
+}
+
+func (a *goBlog) editorScheduleEdit(w http.ResponseWriter, r *http.Request, applyAt time.Time) {
+	parsedURL, err := url.Parse(r.FormValue("url")) //nolint:gosec
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = a.scheduleEdit(
+		cmp.Or(parsedURL.Path, "/"),
+		r.FormValue("content"), //nolint:gosec
+		slices.Contains(r.Form["options"], "noupdated"),
+		applyAt,
+	)
+	if err != nil {
+		a.serveError(w, r, fmt.Sprintf("failed to schedule edit: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath("/editor/scheduled"), http.StatusFound)
+}
+
+func (a *goBlog) serveEditorScheduledEditDelete(w http.ResponseWriter, r *http.Request) {
+	if err := a.db.deleteScheduledEdit(stringToInt(r.FormValue("id"))); err != nil { //nolint:gosec
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath("/editor/scheduled"), http.StatusFound)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_scheduledEdits(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	updateHook := make(chan string, 10)
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		updateHook <- p.Content
+	})
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	defaultBlog := app.cfg.DefaultBlog
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/test/edit",
+		Section:    "posts",
+		Content:    "Original",
+		Parameters: map[string][]string{"title": {"Title"}},
+	}))
+
+	editorRequest := func(values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/editor", strings.NewReader(values.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, defaultBlog))
+		rec := httptest.NewRecorder()
+		app.serveEditorPost(rec, req)
+		return rec
+	}
+
+	t.Run("Schedule", func(t *testing.T) {
+		rec := editorRequest(url.Values{
+			"editoraction": {"updatepost"},
+			"url":          {"https://example.com/test/edit"},
+			"content":      {"---\ntitle: New title\n---\nCorrected"},
+			"applyat":      {time.Now().Add(time.Hour).Format("2006-01-02T15:04")},
+		})
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/editor/scheduled", rec.Header().Get("Location"))
+
+		// Post is not changed yet
+		p, err := app.getPost("/test/edit")
+		require.NoError(t, err)
+		assert.Equal(t, "Original", p.Content)
+
+		edits, err := app.db.getScheduledEdits(defaultBlog, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+		assert.Equal(t, "/test/edit", edits[0].Path)
+
+		// Not due yet
+		app.checkScheduledEdits()
+		p, err = app.getPost("/test/edit")
+		require.NoError(t, err)
+		assert.Equal(t, "Original", p.Content)
+
+		// Invalid front matter is rejected
+		rec = editorRequest(url.Values{
+			"editoraction": {"updatepost"},
+			"url":          {"https://example.com/test/edit"},
+			"content":      {"---\ntitle: [\n---\nBroken"},
+			"applyat":      {time.Now().Add(time.Hour).Format("2006-01-02T15:04")},
+		})
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/editor/scheduled", nil)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, defaultBlog))
+		rec := httptest.NewRecorder()
+		app.serveScheduled(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "Scheduled edits")
+		assert.Contains(t, body, "href=/test/edit")
+	})
+
+	t.Run("Apply", func(t *testing.T) {
+		_, err := app.db.Exec("update scheduled_edits set apply_at = ?", time.Now().Add(-time.Minute).Unix())
+		require.NoError(t, err)
+
+		app.checkScheduledEdits()
+
+		p, err := app.getPost("/test/edit")
+		require.NoError(t, err)
+		assert.Equal(t, "Corrected", p.Content)
+		assert.Equal(t, "New title", p.Title())
+		assert.Equal(t, statusPublished, p.Status)
+		assert.NotEmpty(t, p.Updated)
+		assert.Equal(t, "Corrected", <-updateHook)
+
+		edits, err := app.db.getScheduledEdits("", time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, edits)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		require.NoError(t, app.scheduleEdit("/test/edit", "Another correction", false, time.Now().Add(time.Hour)))
+		edits, err := app.db.getScheduledEdits("", time.Time{})
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+
+		req := httptest.NewRequest(http.MethodPost, editorScheduledEditsPath, strings.NewReader(url.Values{"id": {strconv.Itoa(edits[0].ID)}}.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, defaultBlog))
+		rec := httptest.NewRecorder()
+		app.serveEditorScheduledEditDelete(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		edits, err = app.db.getScheduledEdits("", time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, edits)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		assert.Error(t, app.scheduleEdit("/test/edit", "---\nstatus: unknown\n---\nText", false, time.Now().Add(time.Hour)))
+		assert.Error(t, app.scheduleEdit("/test/edit", "---\npassword: secret\n---\nText", false, time.Now().Add(time.Hour)))
+		edits, err := app.db.getScheduledEdits("", time.Time{})
+		require.NoError(t, err)
+		assert.Empty(t, edits)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		require.NoError(t, app.scheduleEdit("/test/edit", "Correction", false, time.Now().Add(time.Hour)))
+		_, err := app.db.Exec("update scheduled_edits set content = ?, apply_at = ?", "---\ntitle: [\n---\nBroken", time.Now().Add(-time.Minute).Unix())
+		require.NoError(t, err)
+
+		app.checkScheduledEdits()
+
+		// The error is stored and the edit isn't retried
+		edits, err := app.db.getScheduledEdits("", time.Time{})
+		require.NoError(t, err)
+		require.Len(t, edits, 1)
+		assert.NotEmpty(t, edits[0].Error)
+		edits, err = app.db.getScheduledEdits("", time.Now())
+		require.NoError(t, err)
+		assert.Empty(t, edits)
+
+		p, err := app.getPost("/test/edit")
+		require.NoError(t, err)
+		assert.Equal(t, "Corrected", p.Content)
+
+		// The error is shown in the list
+		req := httptest.NewRequest(http.MethodGet, "/editor/scheduled", nil)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, defaultBlog))
+		rec := httptest.NewRecorder()
+		app.serveScheduled(rec, req)
+		assert.Contains(t, rec.Body.String(), "Failed:")
+	})
+}
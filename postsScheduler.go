@@ -14,6 +14,8 @@ func (a *goBlog) startPostsScheduler() {
 				return
 			case <-ticker.C:
 				a.checkScheduledPosts()
+				a.checkScheduledEdits()
+				a.checkExpiredPosts()
 			}
 		}
 	}()
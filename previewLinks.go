@@ -0,0 +1,228 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	editorPreviewLinksPath = "/previewlinks"
+	previewLinkSubPath     = "/preview"
+	previewLinkPath        = "/-" + previewLinkSubPath
+	defaultPreviewLinkDays = 7
+)
+
+// previewLink gives read-only access to an unpublished post until it expires or is revoked
+type previewLink struct {
+	ID      int
+	Path    string
+	Expires int64
+}
+
+func (a *goBlog) initPreviewLinks() {
+	a.hourlyHooks = append(a.hourlyHooks, func() {
+		if _, err := a.db.Exec("delete from preview_links where expires < @now", sql.Named("now", time.Now().Unix())); err != nil {
+			a.error("Failed to delete expired preview links", "err", err)
+		}
+	})
+}
+
+func (a *goBlog) previewLinkKey() []byte {
+	a.plLoad.Do(func() {
+		// Try to load key from database
+		keyBytes, err := a.db.retrievePersistentCache("previewlinkkey")
+		if err != nil {
+			a.error("Failed to retrieve preview link key", "err", err)
+			return
+		}
+		if keyBytes == nil {
+			// Generate random key
+			keyBytes = []byte(randomString(64))
+			// Store key in database
+			err = a.db.cachePersistently("previewlinkkey", keyBytes)
+			if err != nil {
+				a.error("Failed to cache preview link key", "err", err)
+				return
+			}
+		}
+		a.plKey = keyBytes
+	})
+	return a.plKey
+}
+
+// previewLinkSignature signs the preview link, so tokens can't be guessed from the ID
+func (a *goBlog) previewLinkSignature(pl *previewLink) string {
+	key := a.previewLinkKey()
+	if len(key) == 0 {
+		return ""
+	}
+	mac := hmac.New(sha256.New, key)
+	_, _ = fmt.Fprintf(mac, "%d\n%s\n%d", pl.ID, pl.Path, pl.Expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (a *goBlog) previewLinkURL(pl *previewLink) string {
+	return a.getFullAddress(fmt.Sprintf("%s/%d-%s", previewLinkPath, pl.ID, a.previewLinkSignature(pl)))
+}
+
+// canHavePreviewLinks returns true for posts that are not publicly readable yet
+func (p *post) canHavePreviewLinks() bool {
+	return !p.Deleted() && (p.Status == statusDraft || p.Status == statusScheduled || p.Visibility == visibilityPrivate)
+}
+
+func (db *database) createPreviewLink(path string, expires time.Time) (*previewLink, error) {
+	res, err := db.Exec(
+		"insert into preview_links (path, expires) values (@path, @expires)",
+		sql.Named("path", path), sql.Named("expires", expires.Unix()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return &previewLink{ID: int(id), Path: path, Expires: expires.Unix()}, nil
+}
+
+func (db *database) getPreviewLinks(path string) ([]*previewLink, error) {
+	rows, err := db.Query(
+		"select id, path, expires from preview_links where path = @path and expires >= @now order by id desc",
+		sql.Named("path", path), sql.Named("now", time.Now().Unix()),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var links []*previewLink
+	for rows.Next() {
+		pl := &previewLink{}
+		if err = rows.Scan(&pl.ID, &pl.Path, &pl.Expires); err != nil {
+			return nil, err
+		}
+		links = append(links, pl)
+	}
+	return links, rows.Err()
+}
+
+func (db *database) deletePreviewLink(path string, id int) error {
+	_, err := db.Exec("delete from preview_links where path = @path and id = @id", sql.Named("path", path), sql.Named("id", id))
+	return err
+}
+
+// checkPreviewLinkToken returns the post if the token belongs to a valid preview link
+func (a *goBlog) checkPreviewLinkToken(token string) (*post, bool) {
+	idString, signature, ok := strings.Cut(token, "-")
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.Atoi(idString)
+	if err != nil {
+		return nil, false
+	}
+	pl := &previewLink{}
+	row, err := a.db.QueryRow(
+		"select id, path, expires from preview_links where id = @id and expires >= @now",
+		sql.Named("id", id), sql.Named("now", time.Now().Unix()),
+	)
+	if err != nil {
+		return nil, false
+	}
+	if err = row.Scan(&pl.ID, &pl.Path, &pl.Expires); err != nil {
+		return nil, false
+	}
+	expected := a.previewLinkSignature(pl)
+	if expected == "" || !hmac.Equal([]byte(expected), []byte(signature)) {
+		return nil, false
+	}
+	p, err := a.getPost(pl.Path)
+	if err != nil || !p.canHavePreviewLinks() {
+		return nil, false
+	}
+	return p, true
+}
+
+func (a *goBlog) servePreviewLink(w http.ResponseWriter, r *http.Request) {
+	p, ok := a.checkPreviewLinkToken(chi.URLParam(r, "token"))
+	if !ok {
+		a.serve404(w, r)
+		return
+	}
+	p.preview = true
+	a.render(w, r, a.renderPost, &renderData{
+		BlogString: p.Blog,
+		Canonical:  a.getFullAddress(r.URL.Path),
+		Data:       p,
+	})
+}
+
+type editorPreviewLinksRenderData struct {
+	post  *post
+	links []*previewLink
+}
+
+func (a *goBlog) serveEditorPreviewLinks(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path")) //nolint:gosec
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	links, err := a.db.getPreviewLinks(p.Path)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderEditorPreviewLinks, &renderData{
+		Data: &editorPreviewLinksRenderData{post: p, links: links},
+	})
+}
+
+func (a *goBlog) serveEditorPreviewLinksPost(w http.ResponseWriter, r *http.Request) {
+	p, err := a.getPost(r.FormValue("path")) //nolint:gosec
+	if errors.Is(err, errPostNotFound) {
+		a.serve404(w, r)
+		return
+	} else if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	switch r.FormValue("action") { //nolint:gosec
+	case "create":
+		if !p.canHavePreviewLinks() {
+			a.serveError(w, r, "preview links are only available for drafts, scheduled and private posts", http.StatusBadRequest)
+			return
+		}
+		days := stringToInt(r.FormValue("days")) //nolint:gosec
+		if days <= 0 {
+			days = defaultPreviewLinkDays
+		}
+		if _, err := a.db.createPreviewLink(p.Path, time.Now().AddDate(0, 0, days)); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	case "revoke":
+		if err := a.db.deletePreviewLink(p.Path, stringToInt(r.FormValue("id"))); err != nil { //nolint:gosec
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		a.serveError(w, r, "Unknown or missing action", http.StatusBadRequest)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorPreviewLinksPath)+"?path="+url.QueryEscape(p.Path), http.StatusFound)
+}
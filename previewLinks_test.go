@@ -0,0 +1,122 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_previewLinks(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/draft",
+		Section:    "posts",
+		Status:     statusDraft,
+		Content:    "Draft content",
+		Parameters: map[string][]string{"title": {"Draft"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/published",
+		Section: "posts",
+		Content: "Published content",
+	}))
+
+	client := newHandlerClient(app.d)
+	get := func(link string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodGet, link, http.NoBody)
+		require.NoError(t, err)
+		res, err := client.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		return res, string(body)
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		data := url.Values{"action": {"create"}, "path": {"/draft"}, "days": {"3"}}
+		req := httptest.NewRequest(http.MethodPost, editorPath+editorPreviewLinksPath, strings.NewReader(data.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorPreviewLinksPost(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		links, err := app.db.getPreviewLinks("/draft")
+		require.NoError(t, err)
+		require.Len(t, links, 1)
+		assert.InDelta(t, time.Now().AddDate(0, 0, 3).Unix(), links[0].Expires, 5)
+
+		// Not for published posts
+		data.Set("path", "/published")
+		req = httptest.NewRequest(http.MethodPost, editorPath+editorPreviewLinksPath, strings.NewReader(data.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec = httptest.NewRecorder()
+		app.serveEditorPreviewLinksPost(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	links, err := app.db.getPreviewLinks("/draft")
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	link := app.previewLinkURL(links[0])
+
+	t.Run("View", func(t *testing.T) {
+		// The draft itself requires login
+		res, body := get("https://example.com/draft")
+		assert.NotContains(t, body, "Draft content")
+
+		res, body = get(link)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "noindex", res.Header.Get("X-Robots-Tag"))
+		assert.Contains(t, body, "Draft content")
+		assert.Contains(t, body, "previewbanner")
+		assert.NotContains(t, body, "posteditactions")
+
+		// Forged tokens don't work
+		res, _ = get("https://example.com" + previewLinkPath + "/" + strconv.Itoa(links[0].ID) + "-abc")
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("Published", func(t *testing.T) {
+		p, err := app.getPost("/draft")
+		require.NoError(t, err)
+		p.Status = statusPublished
+		require.NoError(t, app.replacePost(p, p.Path, statusDraft, p.Visibility, false))
+		defer func() {
+			p.Status = statusDraft
+			require.NoError(t, app.replacePost(p, p.Path, statusPublished, p.Visibility, false))
+		}()
+
+		res, _ := get(link)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		data := url.Values{"action": {"revoke"}, "path": {"/draft"}, "id": {strconv.Itoa(links[0].ID)}}
+		req := httptest.NewRequest(http.MethodPost, editorPath+editorPreviewLinksPath, strings.NewReader(data.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorPreviewLinksPost(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		res, _ := get(link)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+}
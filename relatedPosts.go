@@ -0,0 +1,205 @@
+package main
+
+import (
+	"cmp"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/builderpool"
+)
+
+const (
+	defaultRelatedPostsCount = 5
+	relatedPostsFTSTerms     = 25
+	relatedPostsFTSLimit     = 50
+)
+
+var relatedPostsWordRegex = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+func (bc *configBlog) relatedPostsEnabled() bool {
+	return bc.RelatedPosts != nil && bc.RelatedPosts.Enabled
+}
+
+func (bc *configBlog) relatedPostsCount() int {
+	return cmp.Or(max(bc.RelatedPosts.Count, 0), defaultRelatedPostsCount)
+}
+
+func (a *goBlog) initRelatedPosts() {
+	// Every change can affect the related posts of other posts as well, so clear the whole cache
+	hook := func(_ *post) {
+		if _, err := a.db.Exec("delete from post_related"); err != nil {
+			a.error("Failed to clear related posts", "err", err)
+			return
+		}
+		a.purgeCache()
+	}
+	a.pPostHooks = append(a.pPostHooks, hook)
+	a.pUpdateHooks = append(a.pUpdateHooks, hook)
+	a.pDeleteHooks = append(a.pDeleteHooks, hook)
+	a.pUndeleteHooks = append(a.pUndeleteHooks, hook)
+}
+
+// relatedPosts returns the published public posts related to the post, using the cache if possible
+func (a *goBlog) relatedPosts(p *post) []*post {
+	bc, ok := a.cfg.Blogs[p.Blog]
+	if !ok || !bc.relatedPostsEnabled() {
+		return nil
+	}
+	count := bc.relatedPostsCount()
+	paths, err := a.db.cachedRelatedPosts(p.Path)
+	if err != nil {
+		a.error("Failed to get cached related posts", "err", err)
+		return nil
+	}
+	if paths == nil {
+		// Keep more paths than needed in the cache, in case some posts get private or deleted without a hook
+		if paths, err = a.computeRelatedPosts(p, bc, 2*count); err != nil {
+			a.error("Failed to compute related posts", "err", err)
+			return nil
+		}
+		if err = a.db.cacheRelatedPosts(p.Path, paths); err != nil {
+			a.error("Failed to cache related posts", "err", err)
+		}
+	}
+	related := []*post{}
+	for _, path := range paths {
+		rp, err := a.getPost(path)
+		if err != nil || rp.Status != statusPublished || rp.Visibility != visibilityPublic {
+			continue
+		}
+		related = append(related, rp)
+		if len(related) == count {
+			break
+		}
+	}
+	return related
+}
+
+// computeRelatedPosts scores other posts of the blog by shared taxonomy values and full-text similarity
+func (a *goBlog) computeRelatedPosts(p *post, bc *configBlog, limit int) ([]string, error) {
+	scores := map[string]float64{}
+	candidates := "path != @path and path in (select path from posts where blog = @blog and status = @status and visibility = @visibility)"
+	candidateArgs := []any{
+		sql.Named("path", p.Path), sql.Named("blog", p.Blog),
+		sql.Named("status", statusPublished), sql.Named("visibility", visibilityPublic),
+	}
+	// Each shared taxonomy value counts as much as the best full-text match
+	if taxonomies := lo.FilterMap(bc.Taxonomies, func(t *configTaxonomy, _ int) (string, bool) {
+		return t.Name, t.Name != ""
+	}); len(taxonomies) > 0 {
+		qb := builderpool.Get()
+		defer builderpool.Put(qb)
+		qb.WriteString("select path, count(*) from (select distinct pp.path, pp.parameter, lowerx(pp.value) from post_parameters pp ")
+		qb.WriteString("join post_parameters own on own.path = @path and own.parameter = pp.parameter and lowerx(own.value) = lowerx(pp.value) ")
+		qb.WriteString("where length(coalesce(pp.value, '')) > 0 and pp.parameter in (")
+		args := slices.Clone(candidateArgs)
+		for i, tax := range taxonomies {
+			if i > 0 {
+				qb.WriteString(", ")
+			}
+			named := "tax" + strconv.Itoa(i)
+			qb.WriteString("@" + named)
+			args = append(args, sql.Named(named, tax))
+		}
+		qb.WriteString(")) where ")
+		qb.WriteString(candidates)
+		qb.WriteString(" group by path")
+		rows, err := a.db.Query(qb.String(), args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var path string
+			var shared int
+			if err = rows.Scan(&path, &shared); err != nil {
+				return nil, err
+			}
+			scores[path] += float64(shared)
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	// Full-text similarity, the best match gets a score of 1
+	if query := relatedPostsFTSQuery(p.Title() + " " + a.renderTextSafe(p.Content)); query != "" {
+		rows, err := a.db.Query(
+			"select path from posts_fts(@query) where "+candidates+" order by rank limit @limit",
+			append(slices.Clone(candidateArgs), sql.Named("query", query), sql.Named("limit", relatedPostsFTSLimit))...,
+		)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for i := 0; rows.Next(); i++ {
+			var path string
+			if err = rows.Scan(&path); err != nil {
+				return nil, err
+			}
+			scores[path] += float64(relatedPostsFTSLimit-i) / relatedPostsFTSLimit
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	paths := lo.Keys(scores)
+	slices.SortFunc(paths, func(x, y string) int {
+		return cmp.Or(cmp.Compare(scores[y], scores[x]), strings.Compare(x, y))
+	})
+	return paths[:min(len(paths), limit)], nil
+}
+
+// relatedPostsFTSQuery builds a full-text query matching any of the most frequent words of the text
+func relatedPostsFTSQuery(text string) string {
+	counts := map[string]int{}
+	for _, word := range relatedPostsWordRegex.FindAllString(strings.ToLower(text), -1) {
+		// Skip short words, they are mostly stop words
+		if utf8.RuneCountInString(word) >= 4 {
+			counts[word]++
+		}
+	}
+	words := lo.Keys(counts)
+	slices.SortFunc(words, func(x, y string) int {
+		return cmp.Or(cmp.Compare(counts[y], counts[x]), strings.Compare(x, y))
+	})
+	words = words[:min(len(words), relatedPostsFTSTerms)]
+	return strings.Join(lo.Map(words, func(w string, _ int) string { return `"` + w + `"` }), " OR ")
+}
+
+// cachedRelatedPosts returns nil if the related posts aren't cached
+func (db *database) cachedRelatedPosts(path string) ([]string, error) {
+	row, err := db.QueryRow("select related from post_related where path = @path", sql.Named("path", path))
+	if err != nil {
+		return nil, err
+	}
+	var related string
+	if err = row.Scan(&related); errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	if err = json.Unmarshal([]byte(related), &paths); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+func (db *database) cacheRelatedPosts(path string, related []string) error {
+	relatedJSON, err := json.Marshal(related)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(
+		"insert or replace into post_related (path, related) values (@path, @related)",
+		sql.Named("path", path), sql.Named("related", string(relatedJSON)),
+	)
+	return err
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_relatedPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	_ = app.initConfig(false)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	bc.RelatedPosts = &configRelatedPosts{Enabled: true, Count: 2}
+
+	app.d = app.buildRouter()
+
+	for _, p := range []*post{
+		{Path: "/sourdough", Content: "Baking sourdough bread needs a starter, flour and patience.", Parameters: map[string][]string{"title": {"Sourdough"}, "tags": {"Baking", "Bread"}}},
+		{Path: "/rye", Content: "Rye bread is heavy, the sourdough starter makes it tasty.", Parameters: map[string][]string{"title": {"Rye"}, "tags": {"bread"}}},
+		{Path: "/cake", Content: "A cake with chocolate.", Parameters: map[string][]string{"title": {"Cake"}, "tags": {"Baking"}}},
+		{Path: "/bike", Content: "Cycling through the mountains.", Parameters: map[string][]string{"title": {"Bike"}, "tags": {"Sports"}}},
+		{Path: "/private", Content: "Secret sourdough bread starter.", Visibility: visibilityPrivate, Parameters: map[string][]string{"title": {"Private"}, "tags": {"Bread"}}},
+	} {
+		p.Section = "posts"
+		require.NoError(t, app.createPost(p))
+	}
+
+	p, err := app.getPost("/sourdough")
+	require.NoError(t, err)
+
+	// Rye shares a tag and words, cake only a tag, bike nothing and private posts are excluded
+	paths, err := app.computeRelatedPosts(p, bc, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/rye", "/cake"}, paths)
+
+	// Related posts are cached
+	related := app.relatedPosts(p)
+	require.Len(t, related, 2)
+	assert.Equal(t, "/rye", related[0].Path)
+	cached, err := app.db.cachedRelatedPosts(p.Path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/rye", "/cake"}, cached)
+
+	// Post hooks clear the cache
+	app.initRelatedPosts()
+	app.pUpdateHooks[len(app.pUpdateHooks)-1](p)
+	cached, err = app.db.cachedRelatedPosts(p.Path)
+	require.NoError(t, err)
+	assert.Nil(t, cached)
+
+	// Related posts are shown below the post
+	client := newHandlerClient(app.d)
+	var resString string
+	err = requests.
+		URL("http://localhost:8080/sourdough").
+		CheckStatus(http.StatusOK).
+		ToString(&resString).
+		Client(client).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, resString, "Related posts")
+	assert.Contains(t, resString, `<a href=/rye>Rye</a>`)
+	assert.NotContains(t, resString, `<a href=/bike>`)
+
+	// Plugins get the related posts from the post
+	pp, err := app.GetPost("/sourdough")
+	require.NoError(t, err)
+	require.Len(t, pp.GetRelatedPosts(), 2)
+	assert.Equal(t, "/rye", pp.GetRelatedPosts()[0].GetPath())
+}
+
+func Test_relatedPostsFTSQuery(t *testing.T) {
+	assert.Equal(t, `"bread" OR "bake" OR "rolls"`, relatedPostsFTSQuery("Bread, bread and \"rolls\"! Bake it."))
+	assert.Equal(t, "", relatedPostsFTSQuery("a an the"))
+}
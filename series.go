@@ -0,0 +1,171 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultSeriesPath = "/series"
+
+	seriesParam      = "series"
+	seriesOrderParam = "seriesorder"
+)
+
+func (bc *configBlog) seriesEnabled() bool {
+	return bc.Series != nil && bc.Series.Enabled
+}
+
+func (bc *configBlog) seriesPath() string {
+	return bc.getRelativePath(cmp.Or(bc.Series.Path, defaultSeriesPath))
+}
+
+func (a *goBlog) seriesTitle(bc *configBlog) string {
+	return cmp.Or(bc.Series.Title, a.ts.GetTemplateStringVariant(bc.Lang, "series"))
+}
+
+func (a *goBlog) serveSeriesList(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	allValues, err := a.db.allTaxonomyValues(blog, seriesParam)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderTaxonomy, &renderData{
+		Canonical: a.getFullAddress(r.URL.Path),
+		Data: &taxonomyRenderData{
+			// The series list looks like a taxonomy, the name is the path of the series pages
+			taxonomy: &configTaxonomy{
+				Name:        strings.Trim(cmp.Or(bc.Series.Path, defaultSeriesPath), "/"),
+				Title:       a.seriesTitle(bc),
+				Description: bc.Series.Description,
+			},
+			valueGroups: groupStrings(allValues),
+		},
+	})
+}
+
+func (a *goBlog) serveSeries(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	seriesParamValue := chi.URLParam(r, "series")
+	if seriesParamValue == "" {
+		a.serve404(w, r)
+		return
+	}
+	// Get value from DB
+	row, err := a.db.QueryRow(
+		"select value from post_parameters where parameter = @param and urlize(value) = @value and path in (select path from posts where blog = @blog) limit 1",
+		sql.Named("param", seriesParam), sql.Named("value", seriesParamValue), sql.Named("blog", blog),
+	)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var series string
+	if err = row.Scan(&series); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			a.serve404(w, r)
+			return
+		}
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Serve index with the posts in reading order
+	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
+		path:           bc.seriesPath() + "/" + seriesParamValue,
+		title:          fmt.Sprintf("%s: %s", a.seriesTitle(bc), series),
+		parameter:      seriesParam,
+		parameterValue: series,
+		orderParameter: seriesOrderParam,
+		ascendingOrder: true,
+	})))
+}
+
+type postSeriesInfo struct {
+	name  string
+	path  string
+	part  int
+	parts int
+	prev  *post
+	next  *post
+}
+
+// postSeries returns the position of the post in its series and the neighbouring posts
+func (a *goBlog) postSeries(p *post, bc *configBlog, r *http.Request) *postSeriesInfo {
+	if !bc.seriesEnabled() {
+		return nil
+	}
+	name := p.firstParameter(seriesParam)
+	if name == "" {
+		return nil
+	}
+	status, visibility := a.getDefaultPostStates(r)
+	paths, err := a.db.getSeriesPostPaths(p.Blog, name, status, visibility)
+	if err != nil {
+		a.error("Failed to get series posts", "err", err)
+		return nil
+	}
+	index := slices.Index(paths, p.Path)
+	if index < 0 {
+		return nil
+	}
+	info := &postSeriesInfo{
+		name:  name,
+		path:  bc.seriesPath() + "/" + urlize(name),
+		part:  index + 1,
+		parts: len(paths),
+	}
+	// Only the neighbouring posts are needed for the links
+	neighbour := func(path string) *post {
+		posts, err := a.getPosts(&postsRequestConfig{path: path, fetchParams: []string{"title", "summary"}})
+		if err != nil || len(posts) < 1 {
+			return nil
+		}
+		return posts[0]
+	}
+	if index > 0 {
+		info.prev = neighbour(paths[index-1])
+	}
+	if index < len(paths)-1 {
+		info.next = neighbour(paths[index+1])
+	}
+	return info
+}
+
+// getSeriesPostPaths returns only the paths of the series posts in reading order
+func (db *database) getSeriesPostPaths(blog, series string, status []postStatus, visibility []postVisibility) ([]string, error) {
+	query, args, err := buildPostsQuery(&postsRequestConfig{
+		blogs:          []string{blog},
+		parameter:      seriesParam,
+		parameterValue: series,
+		orderParameter: seriesOrderParam,
+		ascendingOrder: true,
+		status:         status,
+		visibility:     visibility,
+	}, "path")
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err = rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
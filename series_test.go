@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_series(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	_ = app.initConfig(false)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	bc.Series = &configSeries{Enabled: true}
+
+	app.d = app.buildRouter()
+
+	for _, p := range []*post{
+		// Explicit order comes first, posts without order follow by published date
+		{Path: "/part-b", Published: "2021-01-01T10:00:00Z", Parameters: map[string][]string{"title": {"Part B"}, "series": {"Go Tutorial"}, "seriesorder": {"2"}}},
+		{Path: "/part-a", Published: "2021-01-02T10:00:00Z", Parameters: map[string][]string{"title": {"Part A"}, "series": {"Go Tutorial"}, "seriesorder": {"1"}}},
+		{Path: "/part-d", Published: "2021-01-04T10:00:00Z", Parameters: map[string][]string{"title": {"Part D"}, "series": {"Go Tutorial"}}},
+		{Path: "/part-c", Published: "2021-01-03T10:00:00Z", Parameters: map[string][]string{"title": {"Part C"}, "series": {"Go Tutorial"}}},
+		{Path: "/other", Published: "2021-01-05T10:00:00Z", Parameters: map[string][]string{"title": {"Other"}}},
+	} {
+		p.Section = "posts"
+		p.Content = "Content of " + p.Path
+		require.NoError(t, app.createPost(p))
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		paths, err := app.db.getSeriesPostPaths(app.cfg.DefaultBlog, "Go Tutorial", []postStatus{statusPublished}, []postVisibility{visibilityPublic})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/part-a", "/part-b", "/part-c", "/part-d"}, paths)
+	})
+
+	client := newHandlerClient(app.d)
+
+	t.Run("Post", func(t *testing.T) {
+		var resString string
+		err := requests.
+			URL("http://localhost:8080/part-b").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+
+		assert.Contains(t, resString, "Part 2 of 4")
+		assert.Contains(t, resString, `<a href=/series/go-tutorial>Go Tutorial</a>`)
+		assert.Contains(t, resString, `<a href=/part-a rel=prev>Part A</a>`)
+		assert.Contains(t, resString, `<a href=/part-c rel=next>Part C</a>`)
+
+		err = requests.
+			URL("http://localhost:8080/other").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.NotContains(t, resString, `id=series`)
+	})
+
+	t.Run("Index", func(t *testing.T) {
+		var resString string
+		err := requests.
+			URL("http://localhost:8080/series").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, resString, `<a href=/series/go-tutorial>Go Tutorial</a>`)
+
+		err = requests.
+			URL("http://localhost:8080/series/go-tutorial").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, resString, "Series: Go Tutorial")
+		a, b, d := strings.Index(resString, "Part A"), strings.Index(resString, "Part B"), strings.Index(resString, "Part D")
+		assert.True(t, a < b && b < d)
+		assert.NotContains(t, resString, "Other")
+
+		err = requests.
+			URL("http://localhost:8080/series/go-tutorial.rss").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, resString, "<rss")
+		assert.Contains(t, resString, "Part C")
+
+		err = requests.
+			URL("http://localhost:8080/series/unknown").
+			CheckStatus(http.StatusNotFound).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+	})
+}
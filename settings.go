@@ -210,6 +210,7 @@ func (a *goBlog) settingsUpdateSection(w http.ResponseWriter, r *http.Request) {
 	sectionPathTemplate := r.FormValue("sectionpathtemplate")       //nolint:gosec
 	sectionShowFull := r.FormValue("sectionshowfull") == "on"       //nolint:gosec
 	sectionHideOnStart := r.FormValue("sectionhideonstart") == "on" //nolint:gosec
+	sectionTOC := r.FormValue("sectiontoc") == "on"                 //nolint:gosec
 	// Create section
 	section := &configSection{
 		Name:         sectionName,
@@ -218,6 +219,7 @@ func (a *goBlog) settingsUpdateSection(w http.ResponseWriter, r *http.Request) {
 		PathTemplate: sectionPathTemplate,
 		ShowFull:     sectionShowFull,
 		HideOnStart:  sectionHideOnStart,
+		TOC:          sectionTOC,
 	}
 	err := a.saveSection(blog, section)
 	if err != nil {
@@ -114,7 +114,7 @@ func (a *goBlog) loadSections() error {
 }
 
 func (a *goBlog) getSections(blog string) (map[string]*configSection, error) {
-	rows, err := a.db.Query("select name, title, description, pathtemplate, showfull, hideonstart from sections where blog = @blog", sql.Named("blog", blog))
+	rows, err := a.db.Query("select name, title, description, pathtemplate, showfull, hideonstart, toc from sections where blog = @blog", sql.Named("blog", blog))
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +122,7 @@ func (a *goBlog) getSections(blog string) (map[string]*configSection, error) {
 	sections := map[string]*configSection{}
 	for rows.Next() {
 		section := &configSection{}
-		err = rows.Scan(&section.Name, &section.Title, &section.Description, &section.PathTemplate, &section.ShowFull, &section.HideOnStart)
+		err = rows.Scan(&section.Name, &section.Title, &section.Description, &section.PathTemplate, &section.ShowFull, &section.HideOnStart, &section.TOC)
 		if err != nil {
 			return nil, err
 		}
@@ -149,8 +149,8 @@ func (a *goBlog) saveAllSections() error {
 func (a *goBlog) saveSection(blog string, section *configSection) error {
 	_, err := a.db.Exec(
 		`
-		insert into sections (blog, name, title, description, pathtemplate, showfull, hideonstart) values (@blog, @name, @title, @description, @pathtemplate, @showfull, @hideonstart)
-		on conflict (blog, name) do update set title = @title2, description = @description2, pathtemplate = @pathtemplate2, showfull = @showfull2, hideonstart = @hideonstart2
+		insert into sections (blog, name, title, description, pathtemplate, showfull, hideonstart, toc) values (@blog, @name, @title, @description, @pathtemplate, @showfull, @hideonstart, @toc)
+		on conflict (blog, name) do update set title = @title2, description = @description2, pathtemplate = @pathtemplate2, showfull = @showfull2, hideonstart = @hideonstart2, toc = @toc2
 		`,
 		sql.Named("blog", blog),
 		sql.Named("name", section.Name),
@@ -159,11 +159,13 @@ func (a *goBlog) saveSection(blog string, section *configSection) error {
 		sql.Named("pathtemplate", section.PathTemplate),
 		sql.Named("showfull", section.ShowFull),
 		sql.Named("hideonstart", section.HideOnStart),
+		sql.Named("toc", section.TOC),
 		sql.Named("title2", section.Title),
 		sql.Named("description2", section.Description),
 		sql.Named("pathtemplate2", section.PathTemplate),
 		sql.Named("showfull2", section.ShowFull),
 		sql.Named("hideonstart2", section.HideOnStart),
+		sql.Named("toc2", section.TOC),
 	)
 	return err
 }
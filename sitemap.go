@@ -80,6 +80,24 @@ func (a *goBlog) serveSitemapBlogFeatures(w http.ResponseWriter, r *http.Request
 			}),
 		})
 	}
+	// Events
+	if bc.eventsEnabled() {
+		sm.Add(&sitemap.URL{
+			Loc: a.getFullAddress(bc.eventsPath()),
+		})
+	}
+	// Media logs
+	if bc.mediaLogsEnabled() {
+		for _, kind := range mediaLogKinds {
+			sm.Add(&sitemap.URL{
+				Loc: a.getFullAddress(bc.mediaLogPath(kind)),
+				LastMod: a.sitemapLastMod(&postsRequestConfig{
+					blogs:     []string{blog},
+					parameter: kind.param,
+				}),
+			})
+		}
+	}
 	// Search
 	if bsc := bc.Search; bsc != nil && bsc.Enabled {
 		sm.Add(&sitemap.URL{
@@ -165,6 +183,26 @@ func (a *goBlog) serveSitemapBlogArchives(w http.ResponseWriter, r *http.Request
 			}
 		}
 	}
+	// Series
+	if bc.seriesEnabled() {
+		seriesPath := bc.seriesPath()
+		sm.Add(&sitemap.URL{
+			Loc:     a.getFullAddress(seriesPath),
+			LastMod: blogLastMod,
+		})
+		if seriesValues, err := a.db.allTaxonomyValues(b, seriesParam); err == nil {
+			for _, sv := range seriesValues {
+				sm.Add(&sitemap.URL{
+					Loc: a.getFullAddress(seriesPath + "/" + urlize(sv)),
+					LastMod: a.sitemapLastMod(&postsRequestConfig{
+						blogs:          []string{b},
+						parameter:      seriesParam,
+						parameterValue: sv,
+					}),
+				})
+			}
+		}
+	}
 	// Date based archives
 	datePaths, _ := a.sitemapDatePaths(b, nil)
 	for _, dp := range datePaths {
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/builderpool"
+)
+
+// Spam scoring weights and defaults
+const (
+	spamLinkScore   = 1.0  // per link above the allowed number of links
+	spamWordScore   = 2.0  // per blocklisted word
+	spamDomainScore = 5.0  // per link or website on a known spam domain
+	spamBayesWeight = 10.0 // bayesian probability of 0..1 is mapped to -5..+5
+
+	spamBayesMinMessages  = 5  // minimum number of trained messages per class before the bayesian filter is used
+	spamBayesMaxTokens    = 15 // number of most significant tokens to combine
+	spamBayesMessageToken = "*"
+
+	defaultSpamMaxLinks      = 2
+	defaultSpamModerateScore = 3.0
+	defaultSpamDropScore     = 8.0
+)
+
+type spamVerdict int
+
+const (
+	spamVerdictHam spamVerdict = iota
+	spamVerdictModerate
+	spamVerdictDrop
+)
+
+var spamLinkRegex = regexp.MustCompile(`(?i)\bhttps?://[^\s<>"']+`)
+
+func (a *goBlog) spamEnabled() bool {
+	return a.cfg.Spam != nil && a.cfg.Spam.Enabled
+}
+
+// checkSpam scores the submission and decides whether it should be moderated or dropped
+func (a *goBlog) checkSpam(text, name, website string) (spamVerdict, float64) {
+	if !a.spamEnabled() {
+		return spamVerdictHam, 0
+	}
+	sc := a.cfg.Spam
+	score := a.spamScore(text, name, website)
+	switch {
+	case score >= cmp.Or(sc.DropScore, defaultSpamDropScore):
+		return spamVerdictDrop, score
+	case score >= cmp.Or(sc.ModerateScore, defaultSpamModerateScore):
+		return spamVerdictModerate, score
+	default:
+		return spamVerdictHam, score
+	}
+}
+
+func (a *goBlog) spamScore(text, name, website string) (score float64) {
+	sc := a.cfg.Spam
+	// Links
+	links := spamLinkRegex.FindAllString(text, -1)
+	if tooMany := len(links) - cmp.Or(sc.MaxLinks, defaultSpamMaxLinks); tooMany > 0 {
+		score += float64(tooMany) * spamLinkScore
+	}
+	// Blocklisted words
+	lowerText := strings.ToLower(strings.Join([]string{text, name, website}, " "))
+	for _, word := range sc.Words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" && strings.Contains(lowerText, word) {
+			score += spamWordScore
+		}
+	}
+	// Known spam domains
+	for _, domain := range spamDomains(append(links, website)) {
+		if slices.ContainsFunc(sc.Domains, func(spamDomain string) bool {
+			spamDomain = strings.ToLower(spamDomain)
+			return domain == spamDomain || strings.HasSuffix(domain, "."+spamDomain)
+		}) {
+			score += spamDomainScore
+		}
+	}
+	// Bayesian filter
+	if probability, ok := a.spamProbability(spamTokens(text, name, website)); ok {
+		score += (probability - 0.5) * spamBayesWeight
+	}
+	return score
+}
+
+// spamDomains returns the lowercase host for each parseable URL
+func spamDomains(urls []string) []string {
+	var domains []string
+	for _, u := range urls {
+		if u == "" {
+			continue
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || parsed.Hostname() == "" {
+			continue
+		}
+		domains = append(domains, strings.ToLower(parsed.Hostname()))
+	}
+	return domains
+}
+
+// spamTokens splits the submission into unique tokens for the bayesian filter
+func spamTokens(text, name, website string) []string {
+	links := spamLinkRegex.FindAllString(text, -1)
+	tokens := []string{}
+	for _, field := range []string{spamLinkRegex.ReplaceAllString(text, " "), name} {
+		for word := range strings.FieldsFuncSeq(strings.ToLower(field), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		}) {
+			if l := len([]rune(word)); l >= 3 && l <= 30 {
+				tokens = append(tokens, word)
+			}
+		}
+	}
+	for _, domain := range spamDomains(append(links, website)) {
+		tokens = append(tokens, "domain:"+domain)
+	}
+	return lo.Uniq(tokens)
+}
+
+// spamProbability combines the token probabilities, returns false if the filter isn't trained enough yet
+func (a *goBlog) spamProbability(tokens []string) (float64, bool) {
+	counts, err := a.db.spamTokenCounts(append(tokens, spamBayesMessageToken))
+	if err != nil {
+		return 0, false
+	}
+	messages := counts[spamBayesMessageToken]
+	if messages[0] < spamBayesMinMessages || messages[1] < spamBayesMinMessages {
+		return 0, false
+	}
+	probabilities := []float64{}
+	for _, token := range tokens {
+		c, ok := counts[token]
+		if !ok {
+			continue
+		}
+		spamRatio := float64(c[0]) / float64(messages[0])
+		hamRatio := float64(c[1]) / float64(messages[1])
+		p := spamRatio / (spamRatio + hamRatio)
+		// Robinson's smoothing for rarely seen tokens
+		n := float64(c[0] + c[1])
+		probabilities = append(probabilities, (0.5+n*p)/(1+n))
+	}
+	if len(probabilities) == 0 {
+		return 0.5, true
+	}
+	// Use the most significant tokens only
+	slices.SortFunc(probabilities, func(x, y float64) int {
+		return cmp.Compare(math.Abs(y-0.5), math.Abs(x-0.5))
+	})
+	probabilities = probabilities[:min(len(probabilities), spamBayesMaxTokens)]
+	logOdds := 0.0
+	for _, p := range probabilities {
+		p = min(max(p, 0.01), 0.99)
+		logOdds += math.Log(p / (1 - p))
+	}
+	return 1 / (1 + math.Exp(-logOdds)), true
+}
+
+// trainSpam trains the bayesian filter with a submission marked as spam or ham,
+// retrain moves a submission that was trained with the other class before
+func (a *goBlog) trainSpam(text, name, website string, spam, retrain bool) error {
+	if !a.spamEnabled() {
+		return nil
+	}
+	spamCount, hamCount := 0, 1
+	if spam {
+		spamCount, hamCount = 1, 0
+	}
+	if retrain {
+		spamCount, hamCount = spamCount-hamCount, hamCount-spamCount
+	}
+	return a.db.addSpamTokens(append(spamTokens(text, name, website), spamBayesMessageToken), spamCount, hamCount)
+}
+
+// spamTokenCounts returns the spam and ham counts for the known tokens
+func (db *database) spamTokenCounts(tokens []string) (map[string][2]int, error) {
+	if len(tokens) == 0 {
+		return nil, errors.New("no tokens")
+	}
+	args := lo.Map(tokens, func(t string, _ int) any { return t })
+	rows, err := db.Query("select token, spam, ham from spam_tokens where token in ("+strings.TrimSuffix(strings.Repeat("?,", len(tokens)), ",")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string][2]int{}
+	for rows.Next() {
+		var token string
+		var spam, ham int
+		if err = rows.Scan(&token, &spam, &ham); err != nil {
+			return nil, err
+		}
+		counts[token] = [2]int{spam, ham}
+	}
+	return counts, rows.Err()
+}
+
+// addSpamTokens adds the counts to all tokens in a single transaction, counts don't go below zero
+func (db *database) addSpamTokens(tokens []string, spamCount, hamCount int) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+	sqlBuilder := builderpool.Get()
+	defer builderpool.Put(sqlBuilder)
+	sqlArgs := []any{}
+	sqlBuilder.WriteString("begin;")
+	for _, token := range tokens {
+		sqlBuilder.WriteString("insert into spam_tokens (token, spam, ham) values (?, max(?, 0), max(?, 0)) on conflict (token) do update set spam = max(spam + ?, 0), ham = max(ham + ?, 0);")
+		sqlArgs = append(sqlArgs, token, spamCount, hamCount, spamCount, hamCount)
+	}
+	sqlBuilder.WriteString("commit;")
+	_, err := db.Exec(sqlBuilder.String(), sqlArgs...)
+	return err
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_spamTokens(t *testing.T) {
+	tokens := spamTokens("Buy cheap pills at https://spam.example/pills now!", "Pill Seller", "https://www.Shop.example/")
+	assert.ElementsMatch(t, []string{"buy", "cheap", "pills", "now", "pill", "seller", "domain:spam.example", "domain:www.shop.example"}, tokens)
+}
+
+func Test_spamScore(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Spam = &configSpam{
+		Enabled: true,
+		Words:   []string{"Casino"},
+		Domains: []string{"spam.example"},
+	}
+	err := app.initConfig(false)
+	require.NoError(t, err)
+
+	t.Run("Clean", func(t *testing.T) {
+		verdict, score := app.checkSpam("Great post, thank you!", "Alice", "https://alice.example")
+		assert.Equal(t, spamVerdictHam, verdict)
+		assert.Equal(t, 0.0, score)
+	})
+
+	t.Run("Links", func(t *testing.T) {
+		_, score := app.checkSpam("https://a.example https://b.example https://c.example https://d.example", "", "")
+		assert.Equal(t, 2*spamLinkScore, score)
+	})
+
+	t.Run("Words", func(t *testing.T) {
+		verdict, score := app.checkSpam("Visit our online casino", "Casino Bot", "")
+		assert.Equal(t, spamVerdictHam, verdict)
+		assert.Equal(t, spamWordScore, score)
+	})
+
+	t.Run("Domains", func(t *testing.T) {
+		verdict, score := app.checkSpam("Nice", "Bob", "https://www.spam.example/")
+		assert.Equal(t, spamVerdictModerate, verdict)
+		assert.Equal(t, spamDomainScore, score)
+
+		verdict, _ = app.checkSpam("Nice, see https://spam.example and play casino", "Bob", "https://spam.example")
+		assert.Equal(t, spamVerdictDrop, verdict)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		app.cfg.Spam.Enabled = false
+		defer func() { app.cfg.Spam.Enabled = true }()
+		verdict, score := app.checkSpam("casino", "", "https://spam.example")
+		assert.Equal(t, spamVerdictHam, verdict)
+		assert.Equal(t, 0.0, score)
+	})
+}
+
+func Test_spamBayes(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Spam = &configSpam{
+		Enabled: true,
+	}
+	err := app.initConfig(false)
+	require.NoError(t, err)
+
+	// Not trained enough
+	_, ok := app.spamProbability(spamTokens("cheap replica watches", "", ""))
+	assert.False(t, ok)
+
+	for i := range spamBayesMinMessages {
+		require.NoError(t, app.trainSpam(fmt.Sprintf("Cheap replica watches and discount pills %d", i), "Shop", "https://shop.example", true, false))
+		require.NoError(t, app.trainSpam(fmt.Sprintf("Thanks for the interesting article about gardening %d", i), "Alice", "", false, false))
+	}
+
+	spamProbability, ok := app.spamProbability(spamTokens("Discount replica watches", "", "https://shop.example"))
+	require.True(t, ok)
+	assert.Greater(t, spamProbability, 0.9)
+
+	hamProbability, ok := app.spamProbability(spamTokens("Interesting article, thanks", "", ""))
+	require.True(t, ok)
+	assert.Less(t, hamProbability, 0.1)
+
+	// Bayesian filter is part of the score
+	verdict, _ := app.checkSpam("Cheap replica watches and discount pills", "Shop", "https://shop.example")
+	assert.Equal(t, spamVerdictModerate, verdict)
+}
+
+func Test_spamComments(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.Spam = &configSpam{
+		Enabled: true,
+		Words:   []string{"casino"},
+		Domains: []string{"spam.example"},
+	}
+	err := app.initConfig(false)
+	require.NoError(t, err)
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+
+	// Moderate
+	addr, _, err := app.createComment(bc, "https://example.com/abc", "Hello", "Bob", "https://spam.example", "", 0, false)
+	require.NoError(t, err)
+	id, ok := app.commentIDFromPath(bc, addr)
+	require.True(t, ok)
+	comments, err := app.db.getComments(&commentsRequestConfig{id: id})
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, commentStatusPending, comments[0].Status)
+
+	// Drop
+	_, status, err := app.createComment(bc, "https://example.com/abc", "Play casino at https://spam.example", "Bob", "https://spam.example", "", 0, false)
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Trusted comments are not checked
+	addr, _, err = app.createComment(bc, "https://example.com/abc", "Play casino at https://spam.example", "Admin", "https://spam.example", "", 0, true)
+	require.NoError(t, err)
+	id, ok = app.commentIDFromPath(bc, addr)
+	require.True(t, ok)
+	comments, err = app.db.getComments(&commentsRequestConfig{id: id})
+	require.NoError(t, err)
+	require.Len(t, comments, 1)
+	assert.Equal(t, commentStatusApproved, comments[0].Status)
+}
+
+func Test_spamCommentActions(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+	app.cfg.Spam = &configSpam{
+		Enabled: true,
+	}
+	err := app.initConfig(false)
+	require.NoError(t, err)
+	_ = app.initTemplateStrings()
+
+	bc := app.cfg.Blogs[app.cfg.DefaultBlog]
+	messageCounts := func() [2]int {
+		counts, err := app.db.spamTokenCounts([]string{spamBayesMessageToken})
+		require.NoError(t, err)
+		return counts[spamBayesMessageToken]
+	}
+	commentAction := func(handler http.HandlerFunc, id int) {
+		data := url.Values{}
+		data.Add("commentid", cast.ToString(id))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(data.Encode()))
+		req.Header.Add(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		handler(rec, req.WithContext(context.WithValue(req.Context(), blogKey, app.cfg.DefaultBlog)))
+		assert.Equal(t, http.StatusFound, rec.Code)
+	}
+	commentStatus := func(id int) commentStatus {
+		comments, err := app.db.getComments(&commentsRequestConfig{id: id})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		return comments[0].Status
+	}
+
+	// Published comments are not trained automatically
+	addr, _, err := app.createComment(bc, "https://example.com/abc", "Nice article", "Alice", "", "", 0, false)
+	require.NoError(t, err)
+	id, ok := app.commentIDFromPath(bc, addr)
+	require.True(t, ok)
+	assert.Equal(t, commentStatusApproved, commentStatus(id))
+	assert.Equal(t, [2]int{0, 0}, messageCounts())
+
+	// Mark as spam
+	commentAction(app.commentsAdminSpam, id)
+	assert.Equal(t, commentStatusSpam, commentStatus(id))
+	assert.Equal(t, [2]int{1, 0}, messageCounts())
+
+	// Marking again doesn't train again
+	commentAction(app.commentsAdminSpam, id)
+	assert.Equal(t, [2]int{1, 0}, messageCounts())
+
+	// Not spam
+	commentAction(app.commentsAdminApprove, id)
+	assert.Equal(t, commentStatusApproved, commentStatus(id))
+	assert.Equal(t, [2]int{0, 1}, messageCounts())
+
+	// Deleting doesn't train
+	commentAction(app.commentsAdminDelete, id)
+	assert.Equal(t, [2]int{0, 1}, messageCounts())
+}
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -30,7 +31,7 @@ func (a *goBlog) serveTaxonomy(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *goBlog) serveTaxonomyValue(w http.ResponseWriter, r *http.Request) {
-	_, bc := a.getBlog(r)
+	blog, bc := a.getBlog(r)
 	tax := r.Context().Value(taxonomyContextKey).(*configTaxonomy)
 	taxValueParam := chi.URLParam(r, "taxValue")
 	if taxValueParam == "" {
@@ -50,6 +51,13 @@ func (a *goBlog) serveTaxonomyValue(w http.ResponseWriter, r *http.Request) {
 	err = row.Scan(&taxValue)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			// Check if the value was renamed or merged
+			if newValue, err := a.db.getTaxonomyRedirect(blog, tax.Name, taxValueParam); err == nil && newValue != "" {
+				oldPath := bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, taxValueParam))
+				newPath := bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, newValue))
+				http.Redirect(w, r, newPath+strings.TrimPrefix(r.URL.Path, oldPath), http.StatusMovedPermanently)
+				return
+			}
 			a.serve404(w, r)
 			return
 		}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+const editorTaxonomiesPath = "/taxonomies"
+
+type taxonomyValueCount struct {
+	Value string
+	Count int
+}
+
+// taxonomyValueCounts returns all values of the taxonomy in the blog with the number of posts, including unpublished posts
+func (db *database) taxonomyValueCounts(blog, taxonomy string) ([]*taxonomyValueCount, error) {
+	rows, err := db.Query(
+		"select value, count(distinct path) from post_parameters where parameter = @tax and length(coalesce(value, '')) > 0 and path in (select path from posts where blog = @blog) group by value order by lowerx(value), value",
+		sql.Named("tax", taxonomy), sql.Named("blog", blog),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var counts []*taxonomyValueCount
+	for rows.Next() {
+		c := &taxonomyValueCount{}
+		if err = rows.Scan(&c.Value, &c.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// taxonomyRedirectSQL adds the statements to redirect the old value URL to the new value URL, also for values previously redirected to the old value
+func taxonomyRedirectSQL(sqlBuilder *strings.Builder, sqlArgs *[]any, blog, taxonomy, from, to string) {
+	// The new value is in use again
+	sqlBuilder.WriteString("delete from taxonomy_redirects where blog = ? and taxonomy = ? and fromvalue = ?;")
+	*sqlArgs = append(*sqlArgs, blog, taxonomy, to)
+	// Avoid redirect chains
+	sqlBuilder.WriteString("update taxonomy_redirects set tovalue = ? where blog = ? and taxonomy = ? and tovalue = ?;")
+	*sqlArgs = append(*sqlArgs, to, blog, taxonomy, from)
+	sqlBuilder.WriteString("insert or replace into taxonomy_redirects (blog, taxonomy, fromvalue, tovalue) values (?, ?, ?, ?);")
+	*sqlArgs = append(*sqlArgs, blog, taxonomy, from, to)
+}
+
+func (db *database) getTaxonomyRedirect(blog, taxonomy, from string) (string, error) {
+	row, err := db.QueryRow(
+		"select tovalue from taxonomy_redirects where blog = @blog and taxonomy = @tax and fromvalue = @from",
+		sql.Named("blog", blog), sql.Named("tax", taxonomy), sql.Named("from", from),
+	)
+	if err != nil {
+		return "", err
+	}
+	var to string
+	if err = row.Scan(&to); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return to, nil
+}
+
+// replaceTaxonomyValues replaces the old values of the taxonomy with the new value in all posts of the blog
+// (renaming or merging them) or removes them if the new value is empty, and returns the number of changed posts
+func (a *goBlog) replaceTaxonomyValues(blog, taxonomy string, oldValues []string, newValue string, federate bool) (int, error) {
+	bc, ok := a.cfg.Blogs[blog]
+	if !ok {
+		return 0, errors.New("blog not found")
+	}
+	tax, ok := lo.Find(bc.Taxonomies, func(t *configTaxonomy) bool { return t.Name == taxonomy })
+	if !ok {
+		return 0, errors.New("taxonomy not found")
+	}
+	newValue = strings.TrimSpace(newValue)
+	oldValues = lo.Filter(oldValues, func(v string, _ int) bool {
+		return strings.TrimSpace(v) != "" && v != newValue
+	})
+	if len(oldValues) == 0 {
+		return 0, errors.New("no values to replace")
+	}
+	// Collect the posts with any of the old values
+	posts := map[string]*post{}
+	for _, oldValue := range oldValues {
+		valuePosts, err := a.getPosts(&postsRequestConfig{
+			blogs:         []string{blog},
+			taxonomy:      tax,
+			taxonomyValue: oldValue,
+			fetchParams:   []string{taxonomy},
+		})
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range valuePosts {
+			posts[p.Path] = p
+		}
+	}
+	isOld := func(v string) bool {
+		return slices.ContainsFunc(oldValues, func(o string) bool { return strings.EqualFold(o, v) })
+	}
+	// Update the parameters of all posts at once
+	pathValues := map[string][]string{}
+	for _, p := range posts {
+		values := []string{}
+		for _, v := range p.Parameters[taxonomy] {
+			if isOld(v) {
+				if newValue == "" {
+					continue
+				}
+				v = newValue
+			}
+			values = append(values, v)
+		}
+		pathValues[p.Path] = lo.UniqBy(values, strings.ToLower)
+	}
+	// Redirect the old URLs in the same transaction
+	err := a.db.replacePostsParamWith(taxonomy, pathValues, func(sqlBuilder *strings.Builder, sqlArgs *[]any) {
+		if newValue == "" {
+			return
+		}
+		for _, oldValue := range oldValues {
+			if from, to := urlize(oldValue), urlize(newValue); from != to {
+				taxonomyRedirectSQL(sqlBuilder, sqlArgs, blog, taxonomy, from, to)
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	// Purge the cache just once
+	a.purgeCache()
+	// Send ActivityPub updates
+	if federate && a.apEnabled() {
+		for path := range posts {
+			p, err := a.getPost(path)
+			if err != nil {
+				continue
+			}
+			if p.isPublishedSectionPost() && (p.Visibility == visibilityPublic || p.Visibility == visibilityUnlisted) {
+				a.apUpdate(p)
+			}
+		}
+	}
+	return len(posts), nil
+}
+
+type editorTaxonomiesRenderData struct {
+	taxonomies []*configTaxonomy
+	values     map[string][]*taxonomyValueCount
+	changed    string
+}
+
+func (a *goBlog) serveEditorTaxonomies(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	rd := &editorTaxonomiesRenderData{
+		values:  map[string][]*taxonomyValueCount{},
+		changed: r.URL.Query().Get("changed"),
+	}
+	for _, tax := range bc.Taxonomies {
+		if tax.Name == "" {
+			continue
+		}
+		values, err := a.db.taxonomyValueCounts(blog, tax.Name)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rd.taxonomies = append(rd.taxonomies, tax)
+		rd.values[tax.Name] = values
+	}
+	a.render(w, r, a.renderEditorTaxonomies, &renderData{
+		Data: rd,
+	})
+}
+
+func (a *goBlog) serveEditorTaxonomiesPost(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	taxonomy := r.FormValue("taxonomy")         //nolint:gosec
+	federate := r.FormValue("federate") == "on" //nolint:gosec
+	values := lo.Compact(r.Form["values"])
+	var newValue string
+	switch r.FormValue("action") { //nolint:gosec
+	case "rename":
+		newValue = strings.TrimSpace(r.FormValue("newvalue")) //nolint:gosec
+		if newValue == "" {
+			a.serveError(w, r, "new value missing", http.StatusBadRequest)
+			return
+		}
+	case "delete":
+	default:
+		a.serveError(w, r, "Unknown or missing action", http.StatusBadRequest)
+		return
+	}
+	changed, err := a.replaceTaxonomyValues(blog, taxonomy, values, newValue, federate)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, bc.getRelativePath(editorPath+editorTaxonomiesPath)+"?changed="+strconv.Itoa(changed), http.StatusFound)
+}
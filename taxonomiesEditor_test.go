@@ -0,0 +1,137 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_taxonomiesEditor(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	require.NoError(t, app.initConfig(false))
+	require.NoError(t, app.initTemplateStrings())
+
+	app.d = app.buildRouter()
+
+	for path, tags := range map[string][]string{
+		"/one":   {"golang", "web"},
+		"/two":   {"Go", "golang"},
+		"/three": {"go lang", "misc"},
+		"/four":  {"misc"},
+	} {
+		require.NoError(t, app.createPost(&post{
+			Path:       path,
+			Section:    "posts",
+			Content:    "Content",
+			Parameters: map[string][]string{"tags": tags},
+		}))
+	}
+
+	tags := func(path string) []string {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p.Parameters["tags"]
+	}
+
+	post := func(data url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, editorPath+editorTaxonomiesPath, strings.NewReader(data.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorTaxonomiesPost(rec, req)
+		return rec
+	}
+
+	t.Run("Counts", func(t *testing.T) {
+		counts, err := app.db.taxonomyValueCounts("default", "tags")
+		require.NoError(t, err)
+		values := map[string]int{}
+		for _, c := range counts {
+			values[c.Value] = c.Count
+		}
+		assert.Equal(t, map[string]int{"Go": 1, "golang": 2, "go lang": 1, "misc": 2, "web": 1}, values)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		rec := post(url.Values{"action": {"rename"}, "taxonomy": {"tags"}, "values": {"golang", "go lang"}, "newvalue": {"Go"}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+		assert.Equal(t, "/editor/taxonomies?changed=3", rec.Header().Get("Location"))
+
+		assert.Equal(t, []string{"Go", "web"}, tags("/one"))
+		assert.Equal(t, []string{"Go"}, tags("/two"))
+		assert.Equal(t, []string{"Go", "misc"}, tags("/three"))
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		client := newHandlerClient(app.d)
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		fetch := func(path string) *http.Response {
+			req, err := http.NewRequest(http.MethodGet, "http://localhost:8080"+path, http.NoBody)
+			require.NoError(t, err)
+			res, err := client.Do(req)
+			require.NoError(t, err)
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+			return res
+		}
+
+		res := fetch("/tags/golang")
+		assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+		assert.Equal(t, "/tags/go", res.Header.Get("Location"))
+
+		res = fetch("/tags/go-lang.rss")
+		assert.Equal(t, http.StatusMovedPermanently, res.StatusCode)
+		assert.Equal(t, "/tags/go.rss", res.Header.Get("Location"))
+
+		assert.Equal(t, http.StatusOK, fetch("/tags/go").StatusCode)
+		assert.Equal(t, http.StatusNotFound, fetch("/tags/unknown").StatusCode)
+	})
+
+	t.Run("Chained redirect", func(t *testing.T) {
+		changed, err := app.replaceTaxonomyValues("default", "tags", []string{"Go"}, "Golang", false)
+		require.NoError(t, err)
+		assert.Equal(t, 3, changed)
+
+		to, err := app.db.getTaxonomyRedirect("default", "tags", "go-lang")
+		require.NoError(t, err)
+		assert.Equal(t, "golang", to)
+		// No redirect loop for the value now in use again
+		to, err = app.db.getTaxonomyRedirect("default", "tags", "golang")
+		require.NoError(t, err)
+		assert.Empty(t, to)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		rec := post(url.Values{"action": {"delete"}, "taxonomy": {"tags"}, "values": {"misc"}})
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		assert.Empty(t, tags("/four"))
+		assert.Equal(t, []string{"Golang"}, tags("/three"))
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, post(url.Values{"action": {"rename"}, "taxonomy": {"tags"}, "values": {"web"}}).Code)
+		assert.Equal(t, http.StatusBadRequest, post(url.Values{"action": {"delete"}, "taxonomy": {"unknown"}, "values": {"web"}}).Code)
+		assert.Equal(t, http.StatusBadRequest, post(url.Values{"action": {"delete"}, "taxonomy": {"tags"}}).Code)
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, editorPath+editorTaxonomiesPath+"?changed=2", http.NoBody)
+		rec := httptest.NewRecorder()
+		app.serveEditorTaxonomies(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "2 posts changed.")
+		assert.Contains(t, body, "value=Golang")
+		assert.Contains(t, body, "value=web")
+	})
+}
@@ -0,0 +1,59 @@
+(() => {
+    const lightbox = document.getElementById('lightbox');
+    if (!lightbox) return;
+
+    const links = Array.from(document.querySelectorAll('.gallery:not(.photogrid) figure > a'));
+    if (links.length === 0) return;
+
+    const image = document.getElementById('lightboxImage');
+    const caption = document.getElementById('lightboxCaption');
+    const counter = document.getElementById('lightboxCounter');
+    const prevButton = document.getElementById('lightboxPrev');
+    const nextButton = document.getElementById('lightboxNext');
+    const closeButton = document.getElementById('lightboxClose');
+
+    let current = 0;
+    let opener = null;
+
+    const show = (index) => {
+        current = (index + links.length) % links.length;
+        const link = links[current];
+        const img = link.querySelector('img');
+        const figcaption = link.closest('figure').querySelector('figcaption');
+        image.src = link.href;
+        image.alt = img ? img.alt : '';
+        caption.textContent = figcaption ? figcaption.innerText : '';
+        counter.textContent = `${current + 1} / ${links.length}`;
+        prevButton.disabled = nextButton.disabled = links.length < 2;
+    };
+
+    links.forEach((link, index) => {
+        link.addEventListener('click', (event) => {
+            event.preventDefault();
+            opener = link;
+            show(index);
+            lightbox.showModal();
+            lightbox.focus();
+        });
+    });
+
+    prevButton.addEventListener('click', () => show(current - 1));
+    nextButton.addEventListener('click', () => show(current + 1));
+    closeButton.addEventListener('click', () => lightbox.close());
+    lightbox.addEventListener('click', (event) => {
+        if (event.target === lightbox) lightbox.close();
+    });
+    lightbox.addEventListener('keydown', (event) => {
+        if (event.key === 'ArrowLeft') {
+            event.preventDefault();
+            show(current - 1);
+        } else if (event.key === 'ArrowRight') {
+            event.preventDefault();
+            show(current + 1);
+        }
+    });
+    lightbox.addEventListener('close', () => {
+        image.removeAttribute('src');
+        if (opener) opener.focus();
+    });
+})();
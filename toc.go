@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+const (
+	tocPostParam = "toc"
+	// A table of contents with less entries isn't helpful
+	tocMinHeadings = 2
+)
+
+// tocEnabled checks if the table of contents should be rendered for the post, the post parameter overrides the section setting
+func (a *goBlog) tocEnabled(p *post) bool {
+	switch p.firstParameter(tocPostParam) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	bc, ok := a.cfg.Blogs[p.Blog]
+	if !ok {
+		return false
+	}
+	sec, ok := bc.Sections[p.Section]
+	return ok && sec != nil && sec.TOC
+}
+
+func (c *customRenderer) renderHeading(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	if entering {
+		_, _ = fmt.Fprintf(w, "<h%d", n.Level)
+		if n.Attributes() != nil {
+			html.RenderAttributes(w, n, html.HeadingAttributeFilter)
+		}
+		_ = w.WriteByte('>')
+		return ast.WalkContinue, nil
+	}
+	// Hover permalink, only on the post page itself (not in feeds or other absolute renderings)
+	if id, ok := n.AttributeString("id"); ok && !c.absoluteLinks && c.postPath != "" {
+		hb := htmlbuilder.NewHTMLBuilder(w)
+		hb.WriteElementOpen("a", "class", "heading-anchor", "href", "#"+string(id.([]byte)), "aria-hidden", "true", "tabindex", "-1")
+		hb.WriteEscaped("#")
+		hb.WriteElementClose("a")
+	}
+	_, _ = fmt.Fprintf(w, "</h%d>\n", n.Level)
+	return ast.WalkContinue, nil
+}
+
+// Table of contents
+
+var kindTableOfContents = ast.NewNodeKind("TableOfContents")
+
+type tableOfContentsNode struct {
+	ast.BaseBlock
+	title    string
+	headings []*ast.Heading
+}
+
+func (n *tableOfContentsNode) Kind() ast.NodeKind {
+	return kindTableOfContents
+}
+
+func (n *tableOfContentsNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Title": n.title}, nil)
+}
+
+// tocTransformer inserts the table of contents at the top of the document
+type tocTransformer struct {
+	title string
+}
+
+func (t *tocTransformer) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	toc := &tableOfContentsNode{title: t.title}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		if h, ok := n.(*ast.Heading); ok {
+			if _, hasID := h.AttributeString("id"); hasID {
+				toc.headings = append(toc.headings, h)
+			}
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	if len(toc.headings) < tocMinHeadings {
+		return
+	}
+	doc.InsertBefore(doc, doc.FirstChild(), toc)
+}
+
+func (c *customRenderer) renderTableOfContents(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*tableOfContentsNode)
+	// Link to the post page in absolute renderings like feeds
+	prefix := ""
+	if c.absoluteLinks && c.publicAddress != "" {
+		prefix = c.publicAddress + c.postPath
+	}
+	minLevel := n.headings[0].Level
+	for _, h := range n.headings {
+		minLevel = min(minLevel, h.Level)
+	}
+	hb := htmlbuilder.NewHTMLBuilder(w)
+	hb.WriteElementOpen("nav", "class", "toc")
+	hb.WriteElementOpen("p")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(n.title)
+	hb.WriteElementClose("strong")
+	hb.WriteElementClose("p")
+	// Nested lists, skipped heading levels are ignored
+	depth := 0
+	for _, h := range n.headings {
+		level := min(h.Level-minLevel+1, depth+1)
+		if level > depth {
+			hb.WriteElementOpen("ul")
+			depth++
+		} else {
+			hb.WriteElementClose("li")
+			for ; depth > level; depth-- {
+				hb.WriteElementClose("ul")
+				hb.WriteElementClose("li")
+			}
+		}
+		id, _ := h.AttributeString("id")
+		hb.WriteElementOpen("li")
+		hb.WriteElementOpen("a", "href", prefix+"#"+string(id.([]byte)))
+		hb.WriteEscaped(c.extractTextFromChildren(h, source))
+		hb.WriteElementClose("a")
+	}
+	for ; depth > 0; depth-- {
+		hb.WriteElementClose("li")
+		hb.WriteElementClose("ul")
+	}
+	hb.WriteElementClose("nav")
+	return ast.WalkSkipChildren, nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_toc(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	_ = app.initConfig(false)
+	_ = app.initTemplateStrings()
+
+	app.d = app.buildRouter()
+
+	content := "Intro\n\n## First *part*\n\nText\n\n### Details\n\nText\n\n## Second part\n\nText"
+
+	t.Run("Render", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, app.renderPostMarkdownToWriter(&buf, content, false, "/toc", false, "Contents"))
+		rendered := buf.String()
+		assert.Contains(t, rendered, `<nav class="toc"><p><strong>Contents</strong></p><ul><li><a href="#first-part">First part</a><ul><li><a href="#details">Details</a></li></ul></li><li><a href="#second-part">Second part</a></li></ul></nav>`)
+		assert.Contains(t, rendered, `<h2 id="first-part">First <em>part</em><a class="heading-anchor" href="#first-part" aria-hidden="true" tabindex="-1">#</a></h2>`)
+		assert.Less(t, strings.Index(rendered, "toc"), strings.Index(rendered, "Intro"))
+
+		// Absolute links and no permalinks for feeds
+		buf.Reset()
+		require.NoError(t, app.renderPostMarkdownToWriter(&buf, content, true, "/toc", true, "Contents"))
+		rendered = buf.String()
+		assert.Contains(t, rendered, `<a href="https://example.com/toc#details">Details</a>`)
+		assert.NotContains(t, rendered, "heading-anchor")
+
+		// No table of contents without title or with less than two headings
+		buf.Reset()
+		require.NoError(t, app.renderPostMarkdownToWriter(&buf, content, false, "/toc", false, ""))
+		assert.NotContains(t, buf.String(), "toc")
+		buf.Reset()
+		require.NoError(t, app.renderPostMarkdownToWriter(&buf, "## Only\n\nText", false, "/toc", false, "Contents"))
+		assert.NotContains(t, buf.String(), "toc")
+
+		// Same IDs as before, so existing links keep working
+		buf.Reset()
+		require.NoError(t, app.renderPostMarkdownToWriter(&buf, "## Über uns\n\n## Über uns", false, "/toc", false, ""))
+		assert.Contains(t, buf.String(), `<h2 id="ber-uns">`)
+		assert.Contains(t, buf.String(), `<h2 id="ber-uns-1">`)
+	})
+
+	require.NoError(t, app.createPost(&post{
+		Path:       "/toc",
+		Section:    "posts",
+		Content:    content,
+		Parameters: map[string][]string{"title": {"TOC"}, "toc": {"true"}},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:       "/notoc",
+		Section:    "posts",
+		Content:    content,
+		Parameters: map[string][]string{"title": {"No TOC"}},
+	}))
+
+	client := newHandlerClient(app.d)
+	fetch := func(path string) string {
+		var resString string
+		err := requests.
+			URL("http://localhost:8080" + path).
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		return resString
+	}
+
+	t.Run("Post", func(t *testing.T) {
+		assert.Contains(t, fetch("/toc"), `<nav class=toc>`)
+		notoc := fetch("/notoc")
+		assert.NotContains(t, notoc, `<nav class=toc>`)
+		assert.Contains(t, notoc, "heading-anchor")
+	})
+
+	t.Run("Feeds", func(t *testing.T) {
+		assert.Contains(t, fetch("/.rss"), "https://example.com/toc#first-part")
+		assert.NotContains(t, fetch("/.min.rss"), "https://example.com/toc#first-part")
+	})
+
+	t.Run("Section", func(t *testing.T) {
+		sec := app.cfg.Blogs[app.cfg.DefaultBlog].Sections["posts"]
+		sec.TOC = true
+		defer func() { sec.TOC = false }()
+
+		p, err := app.getPost("/notoc")
+		require.NoError(t, err)
+		assert.True(t, app.tocEnabled(p))
+
+		p.Parameters["toc"] = []string{"false"}
+		assert.False(t, app.tocEnabled(p))
+	})
+}
@@ -20,7 +20,7 @@ import (
 func (a *goBlog) renderEditorPreview(hb *htmlbuilder.HTMLBuilder, bc *configBlog, p *post) {
 	a.renderPostTitle(hb, p)
 	a.renderPostMeta(hb, p, bc, "preview")
-	a.postHTMLToWriter(hb, &postHTMLOptions{p: p, absolute: true})
+	a.postHTMLToWriter(hb, &postHTMLOptions{p: p, absolute: true, toc: true})
 	a.renderPostTrack(hb, p, bc, true)
 	a.renderPostTax(hb, p, bc)
 }
@@ -337,7 +337,18 @@ func (a *goBlog) renderComment(h *htmlbuilder.HTMLBuilder, rd *renderData) {
 			hb.WriteElementClose("h1")
 			// Target
 			hb.WriteElementOpen("p")
-			hb.WriteElementOpen("a", "class", "u-in-reply-to", "href", a.getFullAddress(c.Target))
+			targetClass := "u-in-reply-to"
+			if c.Parent != 0 {
+				targetClass = ""
+				parentAddress := a.getFullAddress(rd.Blog.getRelativePath(fmt.Sprintf("%s/%d", commentPath, c.Parent)))
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "replyto"))
+				hb.WriteEscaped(": ")
+				hb.WriteElementOpen("a", "class", "u-in-reply-to", "href", parentAddress)
+				hb.WriteEscaped(parentAddress)
+				hb.WriteElementClose("a")
+				hb.WriteElementOpen("br")
+			}
+			hb.WriteElementOpen("a", "class", targetClass, "href", a.getFullAddress(c.Target))
 			hb.WriteEscaped(a.getFullAddress(c.Target))
 			hb.WriteElementClose("a")
 			hb.WriteElementClose("p")
@@ -353,6 +364,15 @@ func (a *goBlog) renderComment(h *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteElementClose("span")
 			}
 			hb.WriteElementClose("p")
+			// Date
+			if c.Created != 0 {
+				created := time.Unix(c.Created, 0)
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("time", "class", "dt-published", "datetime", created.Format(time.RFC3339))
+				hb.WriteEscaped(created.Format(time.DateOnly))
+				hb.WriteElementClose("time")
+				hb.WriteElementClose("p")
+			}
 			// Content
 			hb.WriteElementOpen("p", "class", "e-content")
 			hb.WriteUnescaped(strings.ReplaceAll(c.Comment, "\n", "<br>")) // Already escaped
@@ -373,6 +393,7 @@ func (a *goBlog) renderComment(h *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "edit"))
 				hb.WriteElementClose("a")
 				hb.WriteElementClose("div")
+				a.renderCommentReplyForm(hb, rd, c.ID)
 			}
 			// Interactions
 			if a.commentsEnabled(rd.Blog) {
@@ -390,6 +411,11 @@ type indexRenderData struct {
 	paramURLQuery      string
 	summaryTemplate    summaryTyp
 	withoutFeeds       bool
+	scheduledEdits     []*scheduledEdit
+	bulkActions        bool
+	calendar           string
+	years              []string
+	yearsPath          string
 }
 
 func (a *goBlog) renderIndex(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
@@ -413,6 +439,9 @@ func (a *goBlog) renderIndex(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteElementOpen("link", "rel", "alternate", "type", "application/atom+xml", "title", "ATOM"+feedTitle, "href", a.getFullAddress(id.first+".atom")+id.paramURLQuery)
 				hb.WriteElementOpen("link", "rel", "alternate", "type", "application/feed+json", "title", "JSON Feed"+feedTitle, "href", a.getFullAddress(id.first+".json")+id.paramURLQuery)
 			}
+			if id.calendar != "" {
+				hb.WriteElementOpen("link", "rel", "alternate", "type", contenttype.ICS, "title", "iCalendar"+feedTitle, "href", a.getFullAddress(id.calendar))
+			}
 		},
 		func(hb *htmlbuilder.HTMLBuilder) {
 			hb.WriteElementOpen("main", "class", "h-feed")
@@ -429,10 +458,94 @@ func (a *goBlog) renderIndex(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 				titleOrDesc = true
 				_ = a.renderMarkdownToWriter(hb, id.description)
 			}
+			// Calendar subscription
+			if id.calendar != "" {
+				titleOrDesc = true
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped("📅 ")
+				hb.WriteElementOpen("a", "href", id.calendar)
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "subscribecalendar"))
+				hb.WriteElementClose("a")
+				hb.WriteElementClose("p")
+			}
+			// Year filter
+			if len(id.years) > 0 {
+				titleOrDesc = true
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("a", "href", id.yearsPath)
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "allyears"))
+				hb.WriteElementClose("a")
+				for _, year := range id.years {
+					hb.WriteEscaped(" · ")
+					hb.WriteElementOpen("a", "href", id.yearsPath+"/"+year)
+					hb.WriteEscaped(year)
+					hb.WriteElementClose("a")
+				}
+				hb.WriteElementClose("p")
+			}
 			if titleOrDesc {
 				hb.WriteElementOpen("hr")
 			}
-			if len(id.posts) > 0 {
+			// Scheduled edits
+			if len(id.scheduledEdits) > 0 {
+				hb.WriteElementOpen("h2")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "scheduledits"))
+				hb.WriteElementClose("h2")
+				hb.WriteElementOpen("table", "class", "settings-table")
+				for _, e := range id.scheduledEdits {
+					hb.WriteElementOpen("tr")
+					hb.WriteElementOpen("td", "class", "expand")
+					hb.WriteElementOpen("a", "href", e.Path)
+					hb.WriteEscaped(e.Path)
+					hb.WriteElementClose("a")
+					if e.Error != "" {
+						hb.WriteElementOpen("br")
+						hb.WriteElementOpen("strong")
+						hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "scheduleditfailed"))
+						hb.WriteElementClose("strong")
+						hb.WriteUnescaped(" ")
+						hb.WriteEscaped(e.Error)
+					}
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteEscaped(time.Unix(e.ApplyAt, 0).Format(time.DateTime))
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath(editorPath+editorScheduledEditsPath))
+					hb.WriteElementOpen("input", "type", "hidden", "name", "id", "value", e.ID)
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"), "class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"))
+					hb.WriteElementClose("form")
+					hb.WriteElementClose("td")
+					hb.WriteElementClose("tr")
+				}
+				hb.WriteElementClose("table")
+				hb.WriteElementOpen("script", "defer", "", "src", a.assetFileName("js/formconfirm.js"), "integrity", a.assetFileHash("js/formconfirm.js"))
+				hb.WriteElementClose("script")
+				hb.WriteElementOpen("hr")
+			}
+			if len(id.posts) > 0 && id.bulkActions {
+				// Posts with bulk actions
+				hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath(editorPath+editorBulkPath))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "back", "value", id.first)
+				for _, p := range id.posts {
+					hb.WriteElementOpen("p")
+					hb.WriteElementOpen("label")
+					hb.WriteElementOpen("input", "type", "checkbox", "name", "paths", "value", p.Path)
+					hb.WriteEscaped(" " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkselect"))
+					hb.WriteElementClose("label")
+					hb.WriteElementClose("p")
+					a.renderSummary(hb, rd, rd.Blog, p, id.summaryTemplate)
+				}
+				a.renderBulkActions(hb, rd)
+				hb.WriteElementClose("form")
+			} else if len(id.posts) > 0 && id.summaryTemplate == photoGridSummary {
+				// Photo grid
+				hb.WriteElementOpen("div", "class", "gallery photogrid")
+				for _, p := range id.posts {
+					a.renderSummary(hb, rd, rd.Blog, p, id.summaryTemplate)
+				}
+				hb.WriteElementClose("div")
+			} else if len(id.posts) > 0 {
 				// Posts
 				for _, p := range id.posts {
 					a.renderSummary(hb, rd, rd.Blog, p, id.summaryTemplate)
@@ -476,6 +589,7 @@ func (a *goBlog) renderBlogStats(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 			}
 			// Table
 			a.renderBlogStatsTable(hb, rd, bsd)
+			a.renderMediaLogStatsTable(hb, rd, bsd)
 			hb.WriteElementOpen("script", "src", a.assetFileName("js/blogstats.js"), "integrity", a.assetFileHash("js/blogstats.js"), "defer", "")
 			hb.WriteElementClose("script")
 			hb.WriteElementClose("main")
@@ -595,6 +709,49 @@ func (a *goBlog) renderBlogStatsTable(hb *htmlbuilder.HTMLBuilder, rd *renderDat
 	hb.WriteElementClose("table")
 }
 
+func (a *goBlog) renderMediaLogStatsTable(hb *htmlbuilder.HTMLBuilder, rd *renderData, bsd *blogStatsData) {
+	if len(bsd.MediaLogs) == 0 {
+		return
+	}
+	hb.WriteElementOpen("h2")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "medialogs"))
+	hb.WriteElementClose("h2")
+	hb.WriteElementOpen("table")
+	// Table header
+	hb.WriteElementOpen("thead")
+	hb.WriteElementOpen("th", "class", "tal")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "year"))
+	hb.WriteElementClose("th")
+	for _, kind := range mediaLogKinds {
+		hb.WriteElementOpen("th", "class", "tar")
+		hb.WriteEscaped(kind.emoji + " " + a.mediaLogTitle(rd.Blog, kind))
+		hb.WriteElementClose("th")
+	}
+	hb.WriteElementClose("thead")
+	// Table body
+	hb.WriteElementOpen("tbody")
+	for _, row := range bsd.MediaLogs {
+		hb.WriteElementOpen("tr")
+		hb.WriteElementOpen("td", "class", "tal")
+		hb.WriteEscaped(row.Year)
+		hb.WriteElementClose("td")
+		for _, kind := range mediaLogKinds {
+			hb.WriteElementOpen("td", "class", "tar")
+			if count := row.Counts[kind.name]; count > 0 && rd.Blog.mediaLogsEnabled() {
+				hb.WriteElementOpen("a", "href", rd.Blog.mediaLogPath(kind)+"/"+row.Year)
+				hb.WriteEscaped(strconv.Itoa(count))
+				hb.WriteElementClose("a")
+			} else {
+				hb.WriteEscaped(strconv.Itoa(count))
+			}
+			hb.WriteElementClose("td")
+		}
+		hb.WriteElementClose("tr")
+	}
+	hb.WriteElementClose("tbody")
+	hb.WriteElementClose("table")
+}
+
 type geoMapRenderData struct {
 	noLocations bool
 	locations   string
@@ -799,6 +956,42 @@ func (a *goBlog) renderContactSent(hb *htmlbuilder.HTMLBuilder, rd *renderData)
 	)
 }
 
+func (a *goBlog) renderCommentPending(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "commentpending"))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "commentpending"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderCommentSubscription(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	message, ok := rd.Data.(string)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, message))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, message))
+			hb.WriteElementClose("h1")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
 type captchaRenderData struct {
 	captchaMethod  string
 	captchaHeaders string
@@ -919,12 +1112,14 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 			}, selectorBodyInner)
 			defer finish()
 			// Render...
-			hb.WriteElementOpen("main", "class", "h-entry")
+			hb.WriteElementOpen("main", "class", lo.If(a.isEvent(p), "h-entry h-event").Else("h-entry"))
 			// URL (hidden just for microformats)
 			hb.WriteElementOpen("data", "value", a.getFullAddress(p.Path), "class", "u-url hide")
 			hb.WriteElementClose("data")
 			// Start article
 			hb.WriteElementOpen("article")
+			// Preview banner
+			a.renderPreviewBanner(hb, p, rd.Blog)
 			// Title
 			a.renderPostTitle(hb, p)
 			// Post meta
@@ -957,11 +1152,17 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 			// Old content warning
 			a.renderOldContentWarning(hb, p, rd.Blog)
 			// Content
-			a.postHTMLToWriter(hb, &postHTMLOptions{p: p})
+			a.postHTMLToWriter(hb, &postHTMLOptions{p: p, toc: true})
+			// Gallery lightbox
+			if a.isGallery(p) {
+				a.renderGalleryLightbox(hb, rd.Blog)
+			}
 			// External Videp
 			a.renderPostVideo(hb, p)
 			// GPS Track
 			a.renderPostTrack(hb, p, rd.Blog, false)
+			// Series
+			a.renderPostSeries(hb, p, rd)
 			// Taxonomies
 			a.renderPostTax(hb, p, rd.Blog)
 			hb.WriteElementClose("article")
@@ -970,8 +1171,12 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 			hb.WriteElementClose("main")
 			// Reactions
 			a.renderPostReactions(hb, p)
+			// Backlinks
+			a.renderBacklinks(hb, p, rd)
+			// Related posts
+			a.renderRelatedPosts(hb, p, rd.Blog)
 			// Post edit actions
-			if rd.LoggedIn() {
+			if rd.LoggedIn() && !p.preview {
 				hb.WriteElementOpen("div", "class", "actions", "id", "posteditactions")
 				// Update
 				hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath("/editor")+"#update")
@@ -979,6 +1184,18 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
 				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "update"))
 				hb.WriteElementClose("form")
+				// Revisions
+				hb.WriteElementOpen("form", "method", "get", "action", rd.Blog.getRelativePath(editorPath+editorRevisionsPath))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+				hb.WriteElementClose("form")
+				// Preview links
+				if p.canHavePreviewLinks() {
+					hb.WriteElementOpen("form", "method", "get", "action", rd.Blog.getRelativePath(editorPath+editorPreviewLinksPath))
+					hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "previewlinks"))
+					hb.WriteElementClose("form")
+				}
 				// Delete
 				hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath("/editor"))
 				hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "delete")
@@ -994,7 +1211,7 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 					hb.WriteElementClose("form")
 				}
 				// Change visibility
-				for _, visibility := range []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate} {
+				for _, visibility := range []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected} {
 					if p.Visibility != visibility {
 						hb.WriteElementOpen("form", "method", "post", "action", rd.Blog.getRelativePath("/editor"))
 						hb.WriteElementOpen("input", "type", "hidden", "name", "editoraction", "value", "visibility")
@@ -1015,9 +1232,18 @@ func (a *goBlog) renderPost(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteElementOpen("script", "defer", "", "src", a.assetFileName("js/formconfirm.js"), "integrity", a.assetFileHash("js/formconfirm.js"))
 				hb.WriteElementClose("script")
 				hb.WriteElementClose("div")
+				// Share link for protected posts
+				if p.Visibility == visibilityProtected {
+					hb.WriteElementOpen("form", "class", "fw p")
+					hb.WriteElementOpen("label", "for", "protectedsharelink")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "protectedsharelink"))
+					hb.WriteElementClose("label")
+					hb.WriteElementOpen("input", "id", "protectedsharelink", "type", "url", "readonly", "", "value", a.protectedPostShareLink(p))
+					hb.WriteElementClose("form")
+				}
 			}
 			// Comments
-			if a.commentsEnabledForPost(p) {
+			if a.commentsEnabledForPost(p) && !p.preview {
 				a.renderInteractions(hb, rd)
 			}
 		},
@@ -1267,6 +1493,303 @@ func (a *goBlog) renderEditorLinks(hb *htmlbuilder.HTMLBuilder, rd *renderData)
 	)
 }
 
+func (a *goBlog) renderEditorRevisions(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	rrd, ok := rd.Data.(*editorRevisionsRenderData)
+	if !ok {
+		return
+	}
+	revisionsPath := rd.Blog.getRelativePath(editorPath + editorRevisionsPath)
+	renderRestoreForm := func(rev *postRevision) {
+		hb.WriteElementOpen("form", "method", "post", "action", revisionsPath)
+		hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", rrd.post.Path)
+		hb.WriteElementOpen("input", "type", "hidden", "name", "id", "value", rev.ID)
+		hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "restore"), "class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmrestore"))
+		hb.WriteElementClose("form")
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revisions"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", rrd.post.Path)
+			hb.WriteEscaped(cmp.Or(rrd.post.RenderedTitle, rrd.post.Path))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			// Comparison with the current version
+			if rrd.compare != nil {
+				hb.WriteElementOpen("table", "class", "revision-diff monospace")
+				hb.WriteElementOpen("tr")
+				hb.WriteElementOpen("th")
+				hb.WriteEscaped(time.Unix(rrd.compare.Created, 0).Format(time.DateTime))
+				hb.WriteElementClose("th")
+				hb.WriteElementOpen("th")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "currentversion"))
+				hb.WriteElementClose("th")
+				hb.WriteElementClose("tr")
+				for _, row := range rrd.diff {
+					hb.WriteElementOpen("tr")
+					for _, side := range []struct {
+						text             string
+						exists, changed  bool
+						changedClassName string
+					}{
+						{row.old, row.hasOld, row.oldChanged, "diff-del"},
+						{row.new, row.hasNew, row.newChanged, "diff-ins"},
+					} {
+						className := ""
+						if !side.exists {
+							className = "diff-empty"
+						} else if side.changed {
+							className = side.changedClassName
+						}
+						hb.WriteElementOpen("td", "class", className)
+						hb.WriteEscaped(side.text)
+						hb.WriteElementClose("td")
+					}
+					hb.WriteElementClose("tr")
+				}
+				hb.WriteElementClose("table")
+				renderRestoreForm(rrd.compare)
+			}
+			// List of revisions
+			if len(rrd.revisions) == 0 {
+				hb.WriteElementOpen("p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "norevisions"))
+				hb.WriteElementClose("p")
+			} else {
+				hb.WriteElementOpen("table", "class", "settings-table")
+				for _, rev := range rrd.revisions {
+					hb.WriteElementOpen("tr")
+					hb.WriteElementOpen("td", "class", "expand")
+					hb.WriteElementOpen("a", "href", fmt.Sprintf("%s?path=%s&id=%d", revisionsPath, url.QueryEscape(rrd.post.Path), rev.ID))
+					hb.WriteEscaped(time.Unix(rev.Created, 0).Format(time.DateTime))
+					hb.WriteElementClose("a")
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteEscaped(string(rev.Status))
+					hb.WriteEscaped(", ")
+					hb.WriteEscaped(string(rev.Visibility))
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					renderRestoreForm(rev)
+					hb.WriteElementClose("td")
+					hb.WriteElementClose("tr")
+				}
+				hb.WriteElementClose("table")
+			}
+			hb.WriteElementOpen("script", "defer", "", "src", a.assetFileName("js/formconfirm.js"), "integrity", a.assetFileHash("js/formconfirm.js"))
+			hb.WriteElementClose("script")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorPreviewLinks(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	prd, ok := rd.Data.(*editorPreviewLinksRenderData)
+	if !ok {
+		return
+	}
+	previewLinksPath := rd.Blog.getRelativePath(editorPath + editorPreviewLinksPath)
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "previewlinks"))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "previewlinks"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("a", "href", prd.post.Path)
+			hb.WriteEscaped(cmp.Or(prd.post.RenderedTitle, prd.post.Path))
+			hb.WriteElementClose("a")
+			hb.WriteElementClose("p")
+			_ = a.renderMarkdownToWriter(hb, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "previewlinksdesc"))
+			// Create
+			if prd.post.canHavePreviewLinks() {
+				hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", previewLinksPath)
+				hb.WriteElementOpen("input", "type", "hidden", "name", "action", "value", "create")
+				hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", prd.post.Path)
+				hb.WriteElementOpen("label", "for", "previewlinkdays")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "previewlinkdays"))
+				hb.WriteElementClose("label")
+				hb.WriteElementOpen("input", "id", "previewlinkdays", "type", "number", "name", "days", "min", 1, "value", defaultPreviewLinkDays)
+				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "createpreviewlink"))
+				hb.WriteElementClose("form")
+			}
+			// List of links
+			if len(prd.links) > 0 {
+				hb.WriteElementOpen("table", "class", "settings-table")
+				for _, pl := range prd.links {
+					hb.WriteElementOpen("tr")
+					hb.WriteElementOpen("td", "class", "expand")
+					hb.WriteElementOpen("input", "type", "url", "readonly", "", "value", a.previewLinkURL(pl))
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteEscaped(time.Unix(pl.Expires, 0).Format(time.DateTime))
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteElementOpen("form", "method", "post", "action", previewLinksPath)
+					hb.WriteElementOpen("input", "type", "hidden", "name", "action", "value", "revoke")
+					hb.WriteElementOpen("input", "type", "hidden", "name", "path", "value", prd.post.Path)
+					hb.WriteElementOpen("input", "type", "hidden", "name", "id", "value", pl.ID)
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "revoke"))
+					hb.WriteElementClose("form")
+					hb.WriteElementClose("td")
+					hb.WriteElementClose("tr")
+				}
+				hb.WriteElementClose("table")
+			}
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorBulk(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	job, ok := rd.Data.(*bulkJob)
+	if !ok {
+		return
+	}
+	total, done, errs, finished := job.progress()
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkactions"))
+			if !finished {
+				// Reload to update the progress
+				hb.WriteElementOpen("meta", "http-equiv", "refresh", "content", 2)
+			}
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkactions"))
+			hb.WriteElementClose("h1")
+			hb.WriteElementOpen("p")
+			hb.WriteElementOpen("progress", "max", total, "value", done)
+			hb.WriteElementClose("progress")
+			hb.WriteElementClose("p")
+			hb.WriteElementOpen("p")
+			hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkprogress"), done, total))
+			if finished {
+				hb.WriteEscaped(" " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkfinished"))
+			}
+			hb.WriteElementClose("p")
+			if len(errs) > 0 {
+				hb.WriteElementOpen("ul")
+				for _, e := range errs {
+					hb.WriteElementOpen("li")
+					hb.WriteEscaped(e)
+					hb.WriteElementClose("li")
+				}
+				hb.WriteElementClose("ul")
+			}
+			if finished && strings.HasPrefix(job.back, "/") && !strings.HasPrefix(job.back, "//") {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("a", "href", job.back)
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkback"))
+				hb.WriteElementClose("a")
+				hb.WriteElementClose("p")
+			}
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorTaxonomies(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	trd, ok := rd.Data.(*editorTaxonomiesRenderData)
+	if !ok {
+		return
+	}
+	taxonomiesPath := rd.Blog.getRelativePath(editorPath + editorTaxonomiesPath)
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlbuilder.HTMLBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomies"))
+		},
+		func(hb *htmlbuilder.HTMLBuilder) {
+			hb.WriteElementOpen("main")
+			hb.WriteElementOpen("h1")
+			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomies"))
+			hb.WriteElementClose("h1")
+			_ = a.renderMarkdownToWriter(hb, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomiesdesc"))
+			if trd.changed != "" {
+				hb.WriteElementOpen("p")
+				hb.WriteElementOpen("b")
+				hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomieschanged"), trd.changed))
+				hb.WriteElementClose("b")
+				hb.WriteElementClose("p")
+			}
+			for _, tax := range trd.taxonomies {
+				hb.WriteElementOpen("h2", "id", urlize(tax.Name))
+				hb.WriteEscaped(a.renderMdTitle(cmp.Or(tax.Title, tax.Name)))
+				hb.WriteElementClose("h2")
+				values := trd.values[tax.Name]
+				if len(values) == 0 {
+					hb.WriteElementOpen("p")
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "noposts"))
+					hb.WriteElementClose("p")
+					continue
+				}
+				hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", taxonomiesPath)
+				hb.WriteElementOpen("input", "type", "hidden", "name", "taxonomy", "value", tax.Name)
+				// Values with the number of posts
+				hb.WriteElementOpen("table", "class", "settings-table")
+				for i, val := range values {
+					id := fmt.Sprintf("tax-%s-%d", urlize(tax.Name), i)
+					hb.WriteElementOpen("tr")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteElementOpen("input", "type", "checkbox", "id", id, "name", "values", "value", val.Value)
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "expand")
+					hb.WriteElementOpen("label", "for", id)
+					hb.WriteEscaped(val.Value)
+					hb.WriteElementClose("label")
+					hb.WriteElementClose("td")
+					hb.WriteElementOpen("td", "class", "fixed")
+					hb.WriteElementOpen("a", "href", rd.Blog.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, urlize(val.Value))))
+					hb.WriteEscaped(strconv.Itoa(val.Count))
+					hb.WriteElementClose("a")
+					hb.WriteElementClose("td")
+					hb.WriteElementClose("tr")
+				}
+				hb.WriteElementClose("table")
+				// Rename or merge the selected values
+				hb.WriteElementOpen("input", "type", "text", "name", "newvalue", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomynewvalue"))
+				if a.apEnabled() {
+					fedID := "federate-" + urlize(tax.Name)
+					hb.WriteElementOpen("p")
+					hb.WriteElementOpen("input", "type", "checkbox", "id", fedID, "name", "federate")
+					hb.WriteElementOpen("label", "for", fedID)
+					hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyfederate"))
+					hb.WriteElementClose("label")
+					hb.WriteElementClose("p")
+				}
+				hb.WriteElementOpen("button", "type", "submit", "name", "action", "value", "rename")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyrename"))
+				hb.WriteElementClose("button")
+				hb.WriteElementOpen(
+					"button", "type", "submit", "name", "action", "value", "delete",
+					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
+				)
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"))
+				hb.WriteElementClose("button")
+				hb.WriteElementClose("form")
+			}
+			hb.WriteElementOpen("script", "defer", "", "src", a.assetFileName("js/formconfirm.js"), "integrity", a.assetFileHash("js/formconfirm.js"))
+			hb.WriteElementClose("script")
+			hb.WriteElementClose("main")
+		},
+	)
+}
+
 type editorLinkDomainRenderData struct {
 	domain string
 	stat   *linkDomainStat
@@ -1364,9 +1887,10 @@ func (a *goBlog) renderNotificationsAdmin(hb *htmlbuilder.HTMLBuilder, rd *rende
 }
 
 type commentsRenderData struct {
-	comments         []*comment
-	hasPrev, hasNext bool
-	prev, next       string
+	comments            []*comment
+	hasPrev, hasNext    bool
+	prev, current, next string
+	status              commentStatus
 }
 
 func (a *goBlog) renderCommentsAdmin(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
@@ -1385,14 +1909,40 @@ func (a *goBlog) renderCommentsAdmin(hb *htmlbuilder.HTMLBuilder, rd *renderData
 			hb.WriteElementOpen("h1")
 			hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "comments"))
 			hb.WriteElementClose("h1")
+			// Filter
+			commentsPath := rd.Blog.getRelativePath(commentPath)
+			hb.WriteElementOpen("p")
+			for i, filter := range []commentStatus{"", commentStatusPending, commentStatusApproved, commentStatusSpam} {
+				if i > 0 {
+					hb.WriteEscaped(" | ")
+				}
+				label := a.ts.GetTemplateStringVariant(rd.Blog.Lang, cmp.Or(string(filter), "allcomments"))
+				if filter == crd.status {
+					hb.WriteElementOpen("strong")
+					hb.WriteEscaped(label)
+					hb.WriteElementClose("strong")
+					continue
+				}
+				filterPath := commentsPath
+				if filter != "" {
+					filterPath += "?status=" + string(filter)
+				}
+				hb.WriteElementOpen("a", "href", filterPath)
+				hb.WriteEscaped(label)
+				hb.WriteElementClose("a")
+			}
+			hb.WriteElementClose("p")
 			// Comments
 			for _, c := range crd.comments {
-				hb.WriteElementOpen("div", "class", "p")
+				hb.WriteElementOpen("div", "id", fmt.Sprintf("comment-%d", c.ID), "class", "p")
 				// ID, Target, Name
 				hb.WriteElementOpen("p")
 				hb.WriteEscaped("ID: ")
 				hb.WriteEscaped(fmt.Sprintf("%d", c.ID))
 				hb.WriteElementOpen("br")
+				hb.WriteEscaped("Status: ")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, string(c.Status)))
+				hb.WriteElementOpen("br")
 				hb.WriteEscaped("Target: ")
 				hb.WriteElementOpen("a", "href", c.Target, "target", "_blank")
 				hb.WriteEscaped(c.Target)
@@ -1406,6 +1956,14 @@ func (a *goBlog) renderCommentsAdmin(hb *htmlbuilder.HTMLBuilder, rd *renderData
 				if c.Website != "" {
 					hb.WriteElementClose("a")
 				}
+				if c.Parent != 0 {
+					parentPath := rd.Blog.getRelativePath(fmt.Sprintf("%s/%d", commentPath, c.Parent))
+					hb.WriteElementOpen("br")
+					hb.WriteEscaped("Parent: ")
+					hb.WriteElementOpen("a", "href", parentPath, "target", "_blank")
+					hb.WriteEscaped(parentPath)
+					hb.WriteElementClose("a")
+				}
 				if c.Original != "" {
 					hb.WriteElementOpen("br")
 					hb.WriteEscaped("Original: ")
@@ -1418,11 +1976,30 @@ func (a *goBlog) renderCommentsAdmin(hb *htmlbuilder.HTMLBuilder, rd *renderData
 				hb.WriteElementOpen("p")
 				hb.WriteUnescaped(strings.ReplaceAll(c.Comment, "\n", "<br>"))
 				hb.WriteElementClose("p")
-				// Delete form
+				// Actions
 				hb.WriteElementOpen("form", "class", "actions", "method", "post", "action", rd.Blog.getRelativePath(commentPath+commentDeleteSubPath))
 				hb.WriteElementOpen("input", "type", "hidden", "name", "commentid", "value", c.ID)
-				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"))
+				hb.WriteElementOpen("input", "type", "hidden", "name", "redir", "value", crd.current)
+				switch c.Status {
+				case commentStatusPending:
+					// Approve or reject pending comment
+					hb.WriteElementOpen("input", "type", "submit", "formaction", rd.Blog.getRelativePath(commentPath+commentApproveSubPath), "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "approve"))
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "reject"))
+				case commentStatusSpam:
+					// Publish comment wrongly marked as spam
+					hb.WriteElementOpen("input", "type", "submit", "formaction", rd.Blog.getRelativePath(commentPath+commentApproveSubPath), "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "notspam"))
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"))
+				default:
+					hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"))
+				}
+				if c.Status != commentStatusSpam {
+					hb.WriteElementOpen("input", "type", "submit", "formaction", rd.Blog.getRelativePath(commentPath+commentSpamSubPath), "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "spam"))
+				}
 				hb.WriteElementClose("form")
+				// Reply form (only for approved comments, replies are public)
+				if c.Status == commentStatusApproved {
+					a.renderCommentReplyForm(hb, rd, c.ID)
+				}
 				hb.WriteElementClose("div")
 			}
 			// Pagination
@@ -1624,6 +2201,10 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 				hb.WriteElementOpen("input", "type", "checkbox", "name", "options", "value", "noupdated")
 				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "donotsetupdated"))
 				hb.WriteElementClose("input")
+				hb.WriteElementOpen("label", "for", "applyat", "class", "p")
+				hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "applyat"))
+				hb.WriteElementClose("label")
+				hb.WriteElementOpen("input", "type", "datetime-local", "id", "applyat", "name", "applyat")
 				hb.WriteElementOpen("div", "id", "update-preview", "class", "hide")
 				hb.WriteElementClose("div")
 				hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "update"))
@@ -1648,12 +2229,16 @@ func (a *goBlog) renderEditor(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
 			postsListLink("/editor/private", "privateposts")
 			// Unlisted
 			postsListLink("/editor/unlisted", "unlistedposts")
+			// Protected
+			postsListLink("/editor/protected", "protectedposts")
 			// Scheduled
 			postsListLink("/editor/scheduled", "scheduledposts")
 			// Deleted
 			postsListLink("/editor/deleted", "deletedposts")
 			// External links
 			postsListLink(editorPath+editorLinksPath, "externallinks")
+			// Taxonomies
+			postsListLink(editorPath+editorTaxonomiesPath, "taxonomies")
 
 			// Upload
 			hb.WriteElementOpen("h2")
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,8 +21,9 @@ import (
 type summaryTyp string
 
 const (
-	defaultSummary summaryTyp = "summary"
-	photoSummary   summaryTyp = "photosummary"
+	defaultSummary   summaryTyp = "summary"
+	photoSummary     summaryTyp = "photosummary"
+	photoGridSummary summaryTyp = "photogrid"
 )
 
 // post summary on index pages
@@ -38,11 +41,25 @@ func (a *goBlog) renderSummary(origHb *htmlbuilder.HTMLBuilder, rd *renderData,
 	defer finish()
 	// Determine accessible name for article (used by screen readers)
 	articleLabel := a.titleOrFallback(p)
+	// Photo grid, only the first photo linking to the post
+	if typ == photoGridSummary {
+		photos := a.photoLinks(p)
+		if len(photos) == 0 {
+			return
+		}
+		hb.WriteElementOpen("article", "class", "h-entry", "aria-label", articleLabel)
+		hb.WriteElementOpen("a", "class", "u-url", "href", p.Path, "title", articleLabel)
+		a.writeImgElement(hb, photos[0], articleLabel, "", "u-photo", 0, 0, "")
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("article")
+		return
+	}
+	articleClass := lo.If(a.isEvent(p), "h-entry h-event border-bottom").Else("h-entry border-bottom")
 	// Start article
 	if p.RenderedTitle == "" && articleLabel != "" {
-		hb.WriteElementOpen("article", "class", "h-entry border-bottom", "aria-label", articleLabel)
+		hb.WriteElementOpen("article", "class", articleClass, "aria-label", articleLabel)
 	} else {
-		hb.WriteElementOpen("article", "class", "h-entry border-bottom")
+		hb.WriteElementOpen("article", "class", articleClass)
 	}
 	if p.Priority > 0 {
 		// Is pinned post
@@ -77,6 +94,8 @@ func (a *goBlog) renderSummary(origHb *htmlbuilder.HTMLBuilder, rd *renderData,
 		// Show IndieWeb context
 		a.renderPostReplyContext(hb, p)
 		a.renderPostLikeContext(hb, p)
+		a.renderPostCheckinContext(hb, p)
+		a.renderPostMediaLogContext(hb, p)
 		// Show summary
 		hb.WriteElementOpen("p", "class", "p-summary")
 		hb.WriteEscaped(a.postSummary(p))
@@ -147,6 +166,91 @@ func (a *goBlog) renderPostTax(hb *htmlbuilder.HTMLBuilder, p *post, b *configBl
 	}
 }
 
+// "Part N of M" box with links to the previous and next post of the series
+func (a *goBlog) renderPostSeries(hb *htmlbuilder.HTMLBuilder, p *post, rd *renderData) {
+	if rd.Blog == nil || p == nil {
+		return
+	}
+	info := a.postSeries(p, rd.Blog, rd.req)
+	if info == nil {
+		return
+	}
+	hb.WriteElementOpen("div", "class", "p border-top border-bottom", "id", "series")
+	// Position in series
+	hb.WriteElementOpen("p")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "seriespart"), info.part, info.parts))
+	hb.WriteElementClose("strong")
+	hb.WriteUnescaped(": ")
+	hb.WriteElementOpen("a", "href", info.path)
+	hb.WriteEscaped(a.renderMdTitle(info.name))
+	hb.WriteElementClose("a")
+	hb.WriteElementClose("p")
+	// Previous and next post
+	for _, link := range []struct {
+		p   *post
+		rel string // also the key of the label string
+	}{{info.prev, "prev"}, {info.next, "next"}} {
+		if link.p == nil {
+			continue
+		}
+		hb.WriteElementOpen("p")
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, link.rel))
+		hb.WriteUnescaped(": ")
+		hb.WriteElementOpen("a", "href", link.p.Path, "rel", link.rel)
+		hb.WriteEscaped(a.titleOrFallback(link.p))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("p")
+	}
+	hb.WriteElementClose("div")
+}
+
+// list of related posts below the post
+func (a *goBlog) renderRelatedPosts(hb *htmlbuilder.HTMLBuilder, p *post, b *configBlog) {
+	if b == nil || p == nil || len(p.related) == 0 {
+		return
+	}
+	hb.WriteElementOpen("div", "class", "p", "id", "related")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(b.Lang, "relatedposts"))
+	hb.WriteElementClose("strong")
+	hb.WriteElementOpen("ul")
+	for _, rp := range p.related {
+		hb.WriteElementOpen("li")
+		hb.WriteElementOpen("a", "href", rp.Path)
+		hb.WriteEscaped(a.titleOrFallback(rp))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("li")
+	}
+	hb.WriteElementClose("ul")
+	hb.WriteElementClose("div")
+}
+
+// list of posts linking to the post
+func (a *goBlog) renderBacklinks(hb *htmlbuilder.HTMLBuilder, p *post, rd *renderData) {
+	if rd.Blog == nil || p == nil {
+		return
+	}
+	backlinks := a.postBacklinks(p, rd.req)
+	if len(backlinks) == 0 {
+		return
+	}
+	hb.WriteElementOpen("div", "class", "p", "id", "backlinks")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "linkedfrom"))
+	hb.WriteElementClose("strong")
+	hb.WriteElementOpen("ul")
+	for _, bp := range backlinks {
+		hb.WriteElementOpen("li")
+		hb.WriteElementOpen("a", "href", bp.Path)
+		hb.WriteEscaped(a.titleOrFallback(bp))
+		hb.WriteElementClose("a")
+		hb.WriteElementClose("li")
+	}
+	hb.WriteElementClose("ul")
+	hb.WriteElementClose("div")
+}
+
 // post meta information.
 // typ can be "summary", "post" or "preview".
 func (a *goBlog) renderPostMeta(hb *htmlbuilder.HTMLBuilder, p *post, b *configBlog, typ string) {
@@ -187,6 +291,8 @@ func (a *goBlog) renderPostMeta(hb *htmlbuilder.HTMLBuilder, p *post, b *configB
 		hb.WriteElementClose("time")
 		hb.WriteElementClose("div")
 	}
+	// Event
+	a.renderEventDetails(hb, p)
 	// Geo
 	if geoURIs := a.geoURIs(p); len(geoURIs) != 0 {
 		hb.WriteElementOpen("div")
@@ -256,9 +362,21 @@ func (a *goBlog) renderPostMeta(hb *htmlbuilder.HTMLBuilder, p *post, b *configB
 	}
 }
 
-// Reply ("u-in-reply-to")
+// Reply ("u-in-reply-to") and RSVP ("p-rsvp")
 func (a *goBlog) renderPostReplyContext(hb *htmlbuilder.HTMLBuilder, p *post) {
-	a.renderPostLikeReplyContext(hb, "u-in-reply-to", a.ts.GetTemplateStringVariant(a.getBlogFromPost(p).Lang, "replyto"), a.replyLink(p), a.replyTitle(p), a.replyContext(p))
+	lang := a.getBlogFromPost(p).Lang
+	a.renderPostLikeReplyContext(hb, "u-in-reply-to", a.ts.GetTemplateStringVariant(lang, "replyto"), a.replyLink(p), a.replyTitle(p), a.replyContext(p))
+	if rsvp := a.rsvp(p); rsvp == "yes" || rsvp == "no" || rsvp == "maybe" || rsvp == "interested" {
+		hb.WriteElementOpen("p")
+		hb.WriteElementOpen("strong")
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(lang, "rsvp"))
+		hb.WriteEscaped(": ")
+		hb.WriteElementOpen("data", "class", "p-rsvp", "value", rsvp)
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(lang, "rsvp"+rsvp))
+		hb.WriteElementClose("data")
+		hb.WriteElementClose("strong")
+		hb.WriteElementClose("p")
+	}
 }
 
 // Like ("u-like-of")
@@ -266,6 +384,82 @@ func (a *goBlog) renderPostLikeContext(hb *htmlbuilder.HTMLBuilder, p *post) {
 	a.renderPostLikeReplyContext(hb, "u-like-of", a.ts.GetTemplateStringVariant(a.getBlogFromPost(p).Lang, "likeof"), a.likeLink(p), a.likeTitle(p), a.likeContext(p))
 }
 
+// Check-in ("p-checkin" with the venue as "h-card")
+func (a *goBlog) renderPostCheckinContext(hb *htmlbuilder.HTMLBuilder, p *post) {
+	name, url := a.checkinName(p), a.checkinURL(p)
+	if name == "" && url == "" {
+		return
+	}
+	hb.WriteElementOpen("p")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(a.getBlogFromPost(p).Lang, "checkedin"))
+	hb.WriteEscaped(": ")
+	if url != "" {
+		hb.WriteElementOpen("a", "class", "p-checkin h-card", "rel", "noopener", "target", "_blank", "href", url)
+	} else {
+		hb.WriteElementOpen("span", "class", "p-checkin h-card")
+	}
+	hb.WriteElementOpen("span", "class", "p-name")
+	hb.WriteEscaped(cmp.Or(name, url))
+	hb.WriteElementClose("span")
+	if geoURIs := a.geoURIs(p); len(geoURIs) > 0 {
+		hb.WriteElementOpen("data", "class", "p-latitude", "value", fmt.Sprintf("%f", geoURIs[0].Latitude))
+		hb.WriteElementClose("data")
+		hb.WriteElementOpen("data", "class", "p-longitude", "value", fmt.Sprintf("%f", geoURIs[0].Longitude))
+		hb.WriteElementClose("data")
+	}
+	hb.WriteElementClose(lo.If(url != "", "a").Else("span"))
+	hb.WriteElementClose("strong")
+	hb.WriteElementClose("p")
+}
+
+// Media log ("u-read-of", "u-watch-of" or "u-listen-of" as "h-cite") and rating ("p-rating")
+func (a *goBlog) renderPostMediaLogContext(hb *htmlbuilder.HTMLBuilder, p *post) {
+	kind := postMediaLogKind(p)
+	if kind == nil {
+		return
+	}
+	lang := a.getBlogFromPost(p).Lang
+	hb.WriteElementOpen("div", "class", "h-cite u-"+kind.property)
+	hb.WriteElementOpen("p")
+	hb.WriteElementOpen("strong")
+	hb.WriteEscaped(kind.emoji + " " + a.ts.GetTemplateStringVariant(lang, "media"+kind.name) + ": ")
+	if url := p.firstParameter(mediaLogURLParam); url != "" {
+		hb.WriteElementOpen("a", "class", "u-url p-name", "rel", "noopener", "target", "_blank", "href", url)
+		hb.WriteEscaped(p.firstParameter(kind.param))
+		hb.WriteElementClose("a")
+	} else {
+		hb.WriteElementOpen("cite", "class", "p-name")
+		hb.WriteEscaped(p.firstParameter(kind.param))
+		hb.WriteElementClose("cite")
+	}
+	hb.WriteElementClose("strong")
+	if author := p.firstParameter(mediaLogAuthorParam); author != "" {
+		hb.WriteEscaped(" " + a.ts.GetTemplateStringVariant(lang, "mediaby") + " ")
+		hb.WriteElementOpen("span", "class", "p-author")
+		hb.WriteEscaped(author)
+		hb.WriteElementClose("span")
+	}
+	hb.WriteElementClose("p")
+	if isbn := p.firstParameter(mediaLogISBNParam); isbn != "" {
+		hb.WriteElementOpen("p")
+		hb.WriteEscaped("ISBN: ")
+		hb.WriteElementOpen("data", "class", "u-uid", "value", "isbn:"+isbn)
+		hb.WriteEscaped(isbn)
+		hb.WriteElementClose("data")
+		hb.WriteElementClose("p")
+	}
+	hb.WriteElementClose("div")
+	if rating := mediaLogRating(p); rating > 0 {
+		hb.WriteElementOpen("p")
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(lang, "rating") + ": ")
+		hb.WriteElementOpen("data", "class", "p-rating", "value", strconv.Itoa(rating), "title", fmt.Sprintf("%d/%d", rating, maxMediaLogRating))
+		hb.WriteEscaped(strings.Repeat("★", rating) + strings.Repeat("☆", maxMediaLogRating-rating))
+		hb.WriteElementClose("data")
+		hb.WriteElementClose("p")
+	}
+}
+
 func (a *goBlog) renderPostLikeReplyContext(hb *htmlbuilder.HTMLBuilder, class, pretext, link, title, content string) {
 	if link == "" {
 		return
@@ -304,6 +498,43 @@ func (a *goBlog) renderOldContentWarning(hb *htmlbuilder.HTMLBuilder, p *post, b
 	hb.WriteElementClose("strong")
 }
 
+// renderEventDetails renders the start, end and location of event posts as h-event properties
+func (a *goBlog) renderEventDetails(hb *htmlbuilder.HTMLBuilder, p *post) {
+	start := a.eventStart(p)
+	if start.IsZero() {
+		return
+	}
+	hb.WriteElementOpen("div")
+	hb.WriteEscaped("📅 ")
+	hb.WriteElementOpen("time", "class", "dt-start", "datetime", start.Format(time.RFC3339))
+	hb.WriteEscaped(formatEventTime(p.firstParameter(eventStartParam), start))
+	hb.WriteElementClose("time")
+	if end := a.eventEnd(p); !end.IsZero() {
+		hb.WriteEscaped(" – ")
+		hb.WriteElementOpen("time", "class", "dt-end", "datetime", end.Format(time.RFC3339))
+		hb.WriteEscaped(formatEventTime(p.firstParameter(eventEndParam), end))
+		hb.WriteElementClose("time")
+	}
+	hb.WriteElementClose("div")
+	if location := a.eventLocation(p); location != "" {
+		hb.WriteElementOpen("div")
+		hb.WriteEscaped("📍 ")
+		hb.WriteElementOpen("span", "class", "p-location")
+		hb.WriteEscaped(location)
+		hb.WriteElementClose("span")
+		hb.WriteElementClose("div")
+	}
+}
+
+func (a *goBlog) renderPreviewBanner(hb *htmlbuilder.HTMLBuilder, p *post, b *configBlog) {
+	if b == nil || p == nil || !p.preview {
+		return
+	}
+	hb.WriteElementOpen("strong", "class", "p border-top border-bottom", "id", "previewbanner")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(b.Lang, "previewbanner"))
+	hb.WriteElementClose("strong")
+}
+
 func (a *goBlog) renderShareButton(hb *htmlbuilder.HTMLBuilder, p *post, b *configBlog) {
 	if b == nil || b.hideShareButton || p == nil {
 		return
@@ -418,6 +649,9 @@ func (a *goBlog) renderInteractions(hb *htmlbuilder.HTMLBuilder, rd *renderData)
 			if len(mention.Submentions) > 0 {
 				renderMentions(mention.Submentions)
 			}
+			if id, ok := a.commentIDFromAddress(rd.Blog, mention.Source); ok && rd.LoggedIn() {
+				a.renderCommentReplyForm(hb, rd, id)
+			}
 			hb.WriteElementClose("li")
 		}
 		hb.WriteElementClose("ul")
@@ -437,6 +671,9 @@ func (a *goBlog) renderInteractions(hb *htmlbuilder.HTMLBuilder, rd *renderData)
 	hb.WriteElementOpen("input", "type", "hidden", "name", "target", "value", rd.Canonical)
 	hb.WriteElementOpen("input", "type", "text", "name", "name", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "nameopt"))
 	hb.WriteElementOpen("input", "type", "url", "name", "website", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "websiteopt"))
+	if a.commentSubscriptionsEnabled(rd.Blog) {
+		hb.WriteElementOpen("input", "type", "email", "name", "email", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "commentsubscriptionemailopt"))
+	}
 	hb.WriteElementOpen("textarea", "name", "comment", "required", "", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "comment"))
 	hb.WriteElementClose("textarea")
 	hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "docomment"))
@@ -445,6 +682,21 @@ func (a *goBlog) renderInteractions(hb *htmlbuilder.HTMLBuilder, rd *renderData)
 	hb.WriteElementClose("details")
 }
 
+// inline form for the admin to reply to a comment
+func (a *goBlog) renderCommentReplyForm(hb *htmlbuilder.HTMLBuilder, rd *renderData, parent int) {
+	hb.WriteElementOpen("details")
+	hb.WriteElementOpen("summary")
+	hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "reply"))
+	hb.WriteElementClose("summary")
+	hb.WriteElementOpen("form", "class", "fw p", "method", "post", "action", rd.Blog.getRelativePath(commentPath+commentReplySubPath))
+	hb.WriteElementOpen("input", "type", "hidden", "name", "parent", "value", parent)
+	hb.WriteElementOpen("textarea", "name", "comment", "required", "", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "comment"))
+	hb.WriteElementClose("textarea")
+	hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "reply"))
+	hb.WriteElementClose("form")
+	hb.WriteElementClose("details")
+}
+
 // author h-card
 func (a *goBlog) renderAuthor(hb *htmlbuilder.HTMLBuilder) {
 	user := a.cfg.User
@@ -854,6 +1106,12 @@ func (a *goBlog) renderPostSectionSettings(hb *htmlbuilder.HTMLBuilder, rd *rend
 		hb.WriteElementOpen("label", "for", "hideonstart-"+section.Name)
 		hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sectionhideonstart"))
 		hb.WriteElementClose("label")
+		hb.WriteElementsClose("br")
+		// Table of contents
+		hb.WriteElementOpen("input", "type", "checkbox", "name", "sectiontoc", "id", "toc-"+section.Name, lo.If(section.TOC, "checked").Else(""), "")
+		hb.WriteElementOpen("label", "for", "toc-"+section.Name)
+		hb.WriteEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "sectiontoc"))
+		hb.WriteElementClose("label")
 
 		// Actions
 		hb.WriteElementOpen("div", "class", "p")
@@ -1209,3 +1467,48 @@ func (a *goBlog) renderFooter(origHb *htmlbuilder.HTMLBuilder, rd *renderData) {
 	a.renderTorNotice(hb, rd)
 	hb.WriteElementClose("footer")
 }
+
+func (a *goBlog) renderBulkActions(hb *htmlbuilder.HTMLBuilder, rd *renderData) {
+	lang := rd.Blog.Lang
+	option := func(value, label string) {
+		hb.WriteElementOpen("option", "value", value)
+		hb.WriteEscaped(label)
+		hb.WriteElementClose("option")
+	}
+	hb.WriteElementOpen("div", "class", "p")
+	hb.WriteElementOpen("select", "name", "action", "aria-label", a.ts.GetTemplateStringVariant(lang, "bulkactions"))
+	// Sections
+	hb.WriteElementOpen("optgroup", "label", a.ts.GetTemplateStringVariant(lang, "bulksection"))
+	sectionNames := lo.Keys(rd.Blog.Sections)
+	slices.Sort(sectionNames)
+	for _, name := range sectionNames {
+		option("section:"+name, cmp.Or(rd.Blog.Sections[name].Title, name))
+	}
+	hb.WriteElementClose("optgroup")
+	// Visibility and status
+	hb.WriteElementOpen("optgroup", "label", a.ts.GetTemplateStringVariant(lang, "visibility")+" & "+a.ts.GetTemplateStringVariant(lang, "status"))
+	for _, visibility := range []postVisibility{visibilityPublic, visibilityUnlisted, visibilityPrivate, visibilityProtected} {
+		option("visibility:"+string(visibility), a.ts.GetTemplateStringVariant(lang, "changevisibility-"+string(visibility)))
+	}
+	for _, status := range []postStatus{statusPublished, statusDraft} {
+		option("status:"+string(status), a.ts.GetTemplateStringVariant(lang, "bulkstatus-"+string(status)))
+	}
+	hb.WriteElementClose("optgroup")
+	// Taxonomies
+	for _, tax := range rd.Blog.Taxonomies {
+		hb.WriteElementOpen("optgroup", "label", a.renderMdTitle(cmp.Or(tax.Title, tax.Name)))
+		option("add:"+tax.Name, a.ts.GetTemplateStringVariant(lang, "bulkaddvalue"))
+		option("remove:"+tax.Name, a.ts.GetTemplateStringVariant(lang, "bulkremovevalue"))
+		hb.WriteElementClose("optgroup")
+	}
+	// Delete and undelete
+	hb.WriteElementOpen("optgroup", "label", a.ts.GetTemplateStringVariant(lang, "delete"))
+	option("delete", a.ts.GetTemplateStringVariant(lang, "delete"))
+	option("deletepermanently", a.ts.GetTemplateStringVariant(lang, "deletepermanently"))
+	option("undelete", a.ts.GetTemplateStringVariant(lang, "undelete"))
+	hb.WriteElementClose("optgroup")
+	hb.WriteElementClose("select")
+	hb.WriteElementOpen("input", "type", "text", "name", "value", "placeholder", a.ts.GetTemplateStringVariant(lang, "bulkvalue"))
+	hb.WriteElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(lang, "bulkapply"))
+	hb.WriteElementClose("div")
+}
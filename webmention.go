@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -237,8 +238,12 @@ type webmentionsRequestConfig struct {
 	offset, limit int
 	submentions   bool
 	replies       bool
+	depth         int // Nesting level of submentions
 }
 
+// Replies nested deeper than this are shown flat under the reply on the last level
+const maxSubmentionsDepth = 5
+
 func buildWebmentionsQuery(config *webmentionsRequestConfig) (query string, args []any) {
 	queryBuilder := builderpool.Get()
 	defer builderpool.Put(queryBuilder)
@@ -293,12 +298,18 @@ func (a *goBlog) getWebmentions(config *webmentionsRequestConfig) ([]*mention, e
 			m.URL = m.Source
 		}
 		if config.submentions {
-			m.Submentions, err = a.getWebmentions(&webmentionsRequestConfig{
+			subConfig := &webmentionsRequestConfig{
 				target:      m.Source,
-				submentions: false, // prevent infinite recursion
+				submentions: true,
 				asc:         config.asc,
 				status:      config.status,
-			})
+				depth:       config.depth + 1,
+			}
+			if subConfig.depth < maxSubmentionsDepth {
+				m.Submentions, err = a.getWebmentions(subConfig)
+			} else {
+				m.Submentions, err = a.getFlatSubmentions(subConfig)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -323,6 +334,41 @@ func (a *goBlog) getWebmentions(config *webmentionsRequestConfig) ([]*mention, e
 	return mentions, nil
 }
 
+// getFlatSubmentions returns all replies below the target without nesting them
+func (a *goBlog) getFlatSubmentions(config *webmentionsRequestConfig) ([]*mention, error) {
+	result := []*mention{}
+	seen := map[string]bool{config.target: true}
+	targets := []string{config.target}
+	for len(targets) > 0 {
+		mentions, err := a.getWebmentions(&webmentionsRequestConfig{
+			target: targets[0],
+			asc:    config.asc,
+			status: config.status,
+		})
+		if err != nil {
+			return nil, err
+		}
+		targets = targets[1:]
+		for _, m := range mentions {
+			if seen[m.Source] {
+				// Prevent infinite loops
+				continue
+			}
+			seen[m.Source] = true
+			result = append(result, m)
+			targets = append(targets, m.Source)
+		}
+	}
+	// Keep the order of creation
+	slices.SortStableFunc(result, func(x, y *mention) int {
+		if config.asc {
+			return cmp.Compare(x.Created, y.Created)
+		}
+		return cmp.Compare(y.Created, x.Created)
+	})
+	return result, nil
+}
+
 func (a *goBlog) getWebmentionsByAddress(address string) []*mention {
 	if address == "" {
 		return nil
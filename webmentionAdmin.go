@@ -122,7 +122,9 @@ func (a *goBlog) webmentionAdminAction(w http.ResponseWriter, r *http.Request) {
 	case "delete":
 		err = a.db.deleteWebmentionID(id)
 	case "approve":
-		err = a.db.approveWebmentionID(id)
+		if err = a.db.approveWebmentionID(id); err == nil {
+			go a.notifyCommentSubscribersOfMention(id)
+		}
 	case "reverify":
 		err = a.reverifyWebmentionID(id)
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -132,3 +133,51 @@ func Test_webmentions(t *testing.T) {
 	assert.Len(t, mentions, 0)
 
 }
+
+func Test_webmentionsNestedSubmentions(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	_ = app.initConfig(false)
+
+	// Chain of replies: post <- 1 <- 2 <- ... <- 8
+	target := "https://example.com/post"
+	for i := 1; i <= 8; i++ {
+		source := fmt.Sprintf("https://example.com/comment/%d", i)
+		require.NoError(t, app.db.insertWebmention(&mention{
+			Source:  source,
+			Target:  target,
+			Created: int64(i),
+			Content: fmt.Sprintf("Reply %d", i),
+		}, webmentionStatusApproved))
+		target = source
+	}
+
+	mentions := app.getWebmentionsByAddress("https://example.com/post")
+	require.Len(t, mentions, 1)
+	assert.Equal(t, "Reply 1", mentions[0].Content)
+
+	// Three levels are nested
+	require.Len(t, mentions[0].Submentions, 1)
+	second := mentions[0].Submentions[0]
+	assert.Equal(t, "Reply 2", second.Content)
+	require.Len(t, second.Submentions, 1)
+	third := second.Submentions[0]
+	assert.Equal(t, "Reply 3", third.Content)
+
+	// Replies deeper than the maximum depth are flat on the last level
+	last := mentions[0]
+	for range maxSubmentionsDepth - 1 {
+		require.Len(t, last.Submentions, 1)
+		last = last.Submentions[0]
+	}
+	assert.Equal(t, fmt.Sprintf("Reply %d", maxSubmentionsDepth), last.Content)
+	if assert.Len(t, last.Submentions, 8-maxSubmentionsDepth) {
+		for i, m := range last.Submentions {
+			assert.Equal(t, fmt.Sprintf("Reply %d", maxSubmentionsDepth+i+1), m.Content)
+			assert.Empty(t, m.Submentions)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"go.goblog.app/app/pkgs/builderpool"
+	"go.goblog.app/app/pkgs/htmlbuilder"
+)
+
+const postLinksBackfillSetting = "postlinksbackfill"
+
+// Wiki links: [[Post title]], [[/path]] or [[target|label]]
+
+var kindWikiLink = ast.NewNodeKind("WikiLink")
+
+type wikiLinkNode struct {
+	ast.BaseInline
+	target, label string
+}
+
+func (n *wikiLinkNode) Kind() ast.NodeKind {
+	return kindWikiLink
+}
+
+func (n *wikiLinkNode) Dump(source []byte, level int) {
+	ast.DumpHelper(n, source, level, map[string]string{"Target": n.target, "Label": n.label}, nil)
+}
+
+type wikiLinkParser struct{}
+
+func (*wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (*wikiLinkParser) Parse(_ ast.Node, block text.Reader, _ parser.Context) ast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+	end := bytes.Index(line[2:], []byte("]]"))
+	if end < 1 {
+		return nil
+	}
+	content := line[2 : 2+end]
+	if bytes.ContainsAny(content, "[]") {
+		return nil
+	}
+	// Keep Markdown links with brackets in the text like [[1]](https://example.com)
+	if rest := line[4+end:]; len(rest) > 0 && (rest[0] == '(' || rest[0] == '[') {
+		return nil
+	}
+	target, label, _ := strings.Cut(string(content), "|")
+	target, label = strings.TrimSpace(target), strings.TrimSpace(label)
+	if target == "" {
+		return nil
+	}
+	block.Advance(4 + end)
+	return &wikiLinkNode{target: target, label: label}
+}
+
+func (c *customRenderer) renderWikiLink(w util.BufWriter, _ []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*wikiLinkNode)
+	hb := htmlbuilder.NewHTMLBuilder(w)
+	path, title := c.app.resolveWikiLink(n.target, c.postPath)
+	label := n.label
+	if label == "" && strings.HasPrefix(n.target, "/") {
+		// Show the title instead of the path
+		label = title
+	}
+	label = cmp.Or(label, n.target)
+	if path == "" {
+		// Unresolved links are highlighted, so they are easy to find
+		hb.WriteElementOpen("span", "class", "wikilink-missing")
+		hb.WriteEscaped(label)
+		hb.WriteElementClose("span")
+		return ast.WalkSkipChildren, nil
+	}
+	dest := path
+	if c.absoluteLinks && c.publicAddress != "" {
+		dest = c.publicAddress + path
+	}
+	hb.WriteElementOpen("a", "href", dest)
+	hb.WriteEscaped(label)
+	hb.WriteElementClose("a")
+	return ast.WalkSkipChildren, nil
+}
+
+// resolveWikiLink returns the path and title of the published public or unlisted post with the path, alias or title, or empty strings,
+// only posts of the same blog as the linking post are found
+func (a *goBlog) resolveWikiLink(target, sourcePath string) (path, title string) {
+	query := "select p.path, ifnull((select value from post_parameters where path = p.path and parameter = 'title' limit 1), '') from posts p " +
+		"where p.status = @status and p.visibility in (@public, @unlisted) and p.blog = ifnull((select blog from posts where path = @source), p.blog) and "
+	if strings.HasPrefix(target, "/") {
+		target = cmp.Or(strings.TrimSuffix(target, "/"), "/")
+		query += "(p.path = @target or p.path in (select path from post_parameters where parameter = 'aliases' and value = @target))"
+	} else {
+		query += "p.path in (select path from post_parameters where parameter = 'title' and lowerx(value) = lowerx(@target))"
+	}
+	row, err := a.db.QueryRow(
+		query+" order by p.published desc limit 1",
+		sql.Named("status", statusPublished), sql.Named("public", visibilityPublic), sql.Named("unlisted", visibilityUnlisted),
+		sql.Named("source", sourcePath), sql.Named("target", target),
+	)
+	if err != nil {
+		a.error("Failed to resolve wiki link", "target", target, "err", err)
+		return "", ""
+	}
+	if err = row.Scan(&path, &title); err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			a.error("Failed to resolve wiki link", "target", target, "err", err)
+		}
+		return "", ""
+	}
+	return path, title
+}
+
+// Internal links and backlinks
+
+// internalPostLinks returns the paths of the local pages the post links to
+func (a *goBlog) internalPostLinks(p *post) []string {
+	pr, pw := io.Pipe()
+	go func() {
+		_ = pw.CloseWithError(a.renderPostMarkdownToWriter(pw, p.Content, true, p.Path, true, ""))
+	}()
+	links, err := allLinksFromHTML(pr, a.getFullAddress(p.Path))
+	_ = pr.CloseWithError(err)
+	if err != nil {
+		a.error("Failed to get internal links of post", "path", p.Path, "err", err)
+		return nil
+	}
+	paths := []string{}
+	for _, link := range links {
+		if !a.isLocalURL(link) {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || u.Path == "" {
+			continue
+		}
+		if path := cmp.Or(strings.TrimSuffix(u.Path, "/"), "/"); path != p.Path {
+			paths = append(paths, path)
+		}
+	}
+	return lo.Uniq(paths)
+}
+
+// postLinksSQL appends the statements to replace the stored internal links of the post
+func postLinksSQL(sqlBuilder *strings.Builder, sqlArgs *[]any, source string, targets []string) {
+	sqlBuilder.WriteString("delete from post_links where source = ?;")
+	*sqlArgs = append(*sqlArgs, source)
+	for _, target := range targets {
+		sqlBuilder.WriteString("insert or ignore into post_links (source, target) values (?, ?);")
+		*sqlArgs = append(*sqlArgs, source, target)
+	}
+}
+
+func (a *goBlog) initPostLinks() {
+	// Store the links of posts created before internal links were stored on save
+	if done, err := a.getSettingValue(postLinksBackfillSetting); err != nil || done != "" {
+		return
+	}
+	go func() {
+		posts, err := a.getPosts(&postsRequestConfig{})
+		if err != nil {
+			a.error("Failed to get posts to store internal links", "err", err)
+			return
+		}
+		for _, p := range posts {
+			sqlBuilder := builderpool.Get()
+			var sqlArgs []any
+			sqlBuilder.WriteString("begin;")
+			postLinksSQL(sqlBuilder, &sqlArgs, p.Path, a.internalPostLinks(p))
+			sqlBuilder.WriteString("commit;")
+			_, err := a.db.Exec(sqlBuilder.String(), sqlArgs...)
+			builderpool.Put(sqlBuilder)
+			if err != nil {
+				a.error("Failed to store internal links", "path", p.Path, "err", err)
+				return
+			}
+		}
+		if err := a.saveSettingValue(postLinksBackfillSetting, "1"); err != nil {
+			a.error("Failed to save setting", "err", err)
+			return
+		}
+		a.purgeCache()
+	}()
+}
+
+// postBacklinks returns the posts linking to the post (or one of its aliases) that are visible for the request
+func (a *goBlog) postBacklinks(p *post, r *http.Request) []*post {
+	rows, err := a.db.Query(
+		"select distinct source from post_links where target = @path or target in (select value from post_parameters where path = @path and parameter = 'aliases') order by source",
+		sql.Named("path", p.Path),
+	)
+	if err != nil {
+		a.error("Failed to get backlinks", "err", err)
+		return nil
+	}
+	defer rows.Close()
+	var sources []string
+	for rows.Next() {
+		var source string
+		if err = rows.Scan(&source); err != nil {
+			a.error("Failed to get backlinks", "err", err)
+			return nil
+		}
+		sources = append(sources, source)
+	}
+	status, visibility := a.getDefaultPostStates(r)
+	backlinks := []*post{}
+	for _, source := range sources {
+		bp, err := a.getPost(source)
+		if err != nil || !slices.Contains(status, bp.Status) || !slices.Contains(visibility, bp.Visibility) {
+			continue
+		}
+		backlinks = append(backlinks, bp)
+	}
+	return backlinks
+}
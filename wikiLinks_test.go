@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_wikiLinks(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	_ = app.initConfig(false)
+	_ = app.initTemplateStrings()
+
+	app.d = app.buildRouter()
+
+	require.NoError(t, app.createPost(&post{
+		Path:    "/garden/compost",
+		Section: "posts",
+		Content: "All about compost.",
+		Parameters: map[string][]string{
+			"title":   {"Compost Basics"},
+			"aliases": {"/compost"},
+		},
+	}))
+
+	t.Run("Render", func(t *testing.T) {
+		rendered, err := app.renderMarkdown("See [[compost basics]], [[/compost|the alias]] and [[/garden/compost]].")
+		require.NoError(t, err)
+		assert.Contains(t, string(rendered), `<a href="/garden/compost">compost basics</a>`)
+		assert.Contains(t, string(rendered), `<a href="/garden/compost">the alias</a>`)
+		assert.Contains(t, string(rendered), `<a href="/garden/compost">Compost Basics</a>`)
+
+		rendered, err = app.renderMarkdown("A [[Missing Note]] here.")
+		require.NoError(t, err)
+		assert.Contains(t, string(rendered), `<span class="wikilink-missing">Missing Note</span>`)
+
+		// Private posts aren't linked
+		require.NoError(t, app.createPost(&post{
+			Path:       "/garden/plans",
+			Section:    "posts",
+			Visibility: visibilityPrivate,
+			Content:    "Private plans.",
+			Parameters: map[string][]string{"title": {"Private Plans"}},
+		}))
+		rendered, err = app.renderMarkdown("See [[Private Plans]] and [[/garden/plans]].")
+		require.NoError(t, err)
+		assert.Contains(t, string(rendered), `<span class="wikilink-missing">Private Plans</span>`)
+		assert.Contains(t, string(rendered), `<span class="wikilink-missing">/garden/plans</span>`)
+		assert.NotContains(t, string(rendered), "href")
+
+		// Normal links with brackets in the text still work
+		rendered, err = app.renderMarkdown("[[1]](https://example.org)")
+		require.NoError(t, err)
+		assert.Contains(t, string(rendered), `href="https://example.org"`)
+		assert.NotContains(t, string(rendered), "wikilink")
+
+		// Absolute links (e.g. for feeds)
+		buf := &strings.Builder{}
+		require.NoError(t, app.renderPostMarkdownToWriter(buf, "[[Compost Basics]]", true, "/test", false, ""))
+		assert.Contains(t, buf.String(), `<a href="https://example.com/garden/compost">`)
+	})
+
+	t.Run("Backlinks", func(t *testing.T) {
+		require.NoError(t, app.createPost(&post{
+			Path:       "/garden/soil",
+			Section:    "posts",
+			Content:    "Good soil needs [[Compost Basics]].",
+			Parameters: map[string][]string{"title": {"Healthy Soil"}},
+		}))
+		require.NoError(t, app.createPost(&post{
+			Path:       "/garden/worms",
+			Section:    "posts",
+			Content:    "Worms love [old compost links](https://example.com/compost/) and [external](https://example.org/compost).",
+			Parameters: map[string][]string{"title": {"Worms"}},
+		}))
+		require.NoError(t, app.createPost(&post{
+			Path:       "/garden/secret",
+			Section:    "posts",
+			Visibility: visibilityPrivate,
+			Content:    "Secret [[Compost Basics]].",
+			Parameters: map[string][]string{"title": {"Secret"}},
+		}))
+
+		p, err := app.getPost("/garden/soil")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/garden/compost"}, app.internalPostLinks(p))
+
+		client := newHandlerClient(app.d)
+		var resString string
+		err = requests.
+			URL("http://localhost:8080/garden/compost").
+			CheckStatus(http.StatusOK).
+			ToString(&resString).
+			Client(client).Fetch(context.Background())
+		require.NoError(t, err)
+		assert.Contains(t, resString, "Linked from")
+		assert.Contains(t, resString, `<a href=/garden/soil>Healthy Soil</a>`)
+		assert.Contains(t, resString, `<a href=/garden/worms>Worms</a>`)
+		assert.NotContains(t, resString, "Secret")
+
+		// Links are updated when the post is saved
+		p.Content = "No links anymore."
+		require.NoError(t, app.replacePost(p, p.Path, p.Status, p.Visibility, false))
+		compost, err := app.getPost("/garden/compost")
+		require.NoError(t, err)
+		backlinks := app.postBacklinks(compost, httptest.NewRequest(http.MethodGet, "/garden/compost", nil))
+		require.Len(t, backlinks, 1)
+		assert.Equal(t, "/garden/worms", backlinks[0].Path)
+	})
+}
@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+type wordPressImportConfig struct {
+	blog, section                    string
+	categoriesTaxonomy, tagsTaxonomy string // Empty to skip
+}
+
+type wordPressImportResult struct {
+	posts, pages, media, comments, skipped int
+}
+
+var (
+	wordPressURLRe     = regexp.MustCompile(`https?://[^\s"'()<>\[\]]+`)
+	wordPressCaptionRe = regexp.MustCompile(`(?s)\[caption[^\]]*\](.*?)\[/caption\]`)
+	wordPressImageRe   = regexp.MustCompile(`(?s)^\s*((?:<a[^>]*>\s*)?<img[^>]*>(?:\s*</a>)?)(.*)$`)
+	wordPressPreRe     = regexp.MustCompile(`(?is)<pre\b.*?</pre>`)
+	wordPressBlockRe   = regexp.MustCompile(`(?i)^<(p|div|h[1-6]|ul|ol|blockquote|pre|table|figure|hr|dl|iframe|video|audio|section|script|style)\b`)
+	wordPressParaRe    = regexp.MustCompile(`\n\s*\n`)
+	htmlCommentRe      = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+// importWordPressFile imports the posts, pages, attachments and approved comments of a WordPress WXR export,
+// posts that were already imported (their old URL is a path or alias of a post) are skipped
+func (a *goBlog) importWordPressFile(file string, conf *wordPressImportConfig) (*wordPressImportResult, error) {
+	bc, ok := a.cfg.Blogs[conf.blog]
+	if !ok {
+		return nil, errors.New("blog not found")
+	}
+	section := cmp.Or(conf.section, bc.DefaultSection)
+	if _, ok := bc.Sections[section]; !ok {
+		return nil, errors.New("section not found")
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var export wxrExport
+	if err = xml.NewDecoder(f).Decode(&export); err != nil {
+		return nil, err
+	}
+	// Collect the hosts and attachments of the WordPress site to know which files to download
+	hosts := map[string]bool{}
+	attachments := map[string]string{}
+	for _, u := range []string{export.Channel.BaseSiteURL, export.Channel.BaseBlogURL} {
+		if parsed, err := url.Parse(u); err == nil && parsed.Host != "" {
+			hosts[parsed.Host] = true
+		}
+	}
+	for _, item := range export.Channel.Items {
+		if item.Type == "attachment" && item.AttachmentURL != "" {
+			attachments[item.ID] = item.AttachmentURL
+		}
+	}
+	result := &wordPressImportResult{}
+	media := map[string]string{} // Old URL to new URL
+	importMedia := func(oldURL string) string {
+		if newURL, ok := media[oldURL]; ok {
+			return newURL
+		}
+		newURL, err := a.importMediaURL(oldURL)
+		if err != nil {
+			a.error("Failed to import media file, keeping the old URL", "url", oldURL, "err", err)
+			newURL = oldURL
+		} else {
+			result.media++
+		}
+		media[oldURL] = newURL
+		return newURL
+	}
+	isMedia := func(u string) bool {
+		parsed, err := url.Parse(u)
+		if err != nil || !hosts[parsed.Host] {
+			return false
+		}
+		return strings.Contains(parsed.Path, "/wp-content/uploads/") || lo.Contains(lo.Values(attachments), u)
+	}
+	var comments []*importedComment
+	for _, item := range export.Channel.Items {
+		if item.Type != "post" && item.Type != "page" {
+			continue
+		}
+		status, visibility, ok := wordPressStatus(item)
+		if !ok {
+			continue
+		}
+		if link, err := url.Parse(item.Link); err == nil && link.Host != "" {
+			hosts[link.Host] = true
+		}
+		// Approved comments are imported after all posts, using the new path (old links like /?p=123 can't be mapped)
+		itemComments, err := wordPressItemComments(item)
+		if err != nil {
+			return result, err
+		}
+		addComments := func(target string) {
+			for _, c := range itemComments {
+				if !c.pending {
+					c.thread = target
+					comments = append(comments, c)
+				}
+			}
+		}
+		// Check if already imported
+		existing, err := a.commentImportTarget(item.Link)
+		if err != nil {
+			return result, err
+		}
+		if existing != "" {
+			a.info("Skip already imported post", "url", item.Link, "path", existing)
+			addComments(existing)
+			result.skipped++
+			continue
+		}
+		p := &post{
+			Blog:       conf.blog,
+			Status:     status,
+			Visibility: visibility,
+			Published:  wordPressDate(item.DateGMT, item.Date),
+			Parameters: map[string][]string{},
+		}
+		if visibility == visibilityProtected {
+			p.Parameters[protectedPasswordParam] = []string{item.Password}
+		}
+		if title := strings.TrimSpace(html.UnescapeString(item.Title)); title != "" {
+			p.Parameters["title"] = []string{title}
+		}
+		if updated := wordPressDate(item.ModifiedGMT, ""); updated != p.Published {
+			p.Updated = updated
+		}
+		oldPath := wordPressPath(item.Link)
+		if item.Type == "page" && oldPath != "" {
+			// Pages keep their path
+			p.Path = bc.getRelativePath(oldPath)
+		} else {
+			p.Section, p.Slug = section, item.Name
+		}
+		for _, category := range item.Categories {
+			name := strings.TrimSpace(html.UnescapeString(category.Name))
+			switch {
+			case name == "":
+			case category.Domain == "category" && conf.categoriesTaxonomy != "" && category.Nicename != "uncategorized":
+				p.Parameters[conf.categoriesTaxonomy] = append(p.Parameters[conf.categoriesTaxonomy], name)
+			case category.Domain == "post_tag" && conf.tagsTaxonomy != "":
+				p.Parameters[conf.tagsTaxonomy] = append(p.Parameters[conf.tagsTaxonomy], name)
+			}
+		}
+		// Content with downloaded media
+		p.Content = wordPressContentToMarkdown(item.Content)
+		for _, u := range lo.Uniq(wordPressURLRe.FindAllString(p.Content, -1)) {
+			if isMedia(u) {
+				p.Content = strings.ReplaceAll(p.Content, u, importMedia(u))
+			}
+		}
+		// Featured image
+		if thumbnail, ok := lo.Find(item.Meta, func(m *wxrPostMeta) bool { return m.Key == "_thumbnail_id" }); ok {
+			if u := attachments[thumbnail.Value]; u != "" {
+				p.Parameters[a.cfg.Micropub.PhotoParam] = []string{importMedia(u)}
+			}
+		}
+		// Save post without triggering hooks (no webmentions or ActivityPub for old posts)
+		if err = a.checkPost(p, true, false); err != nil {
+			return result, fmt.Errorf("invalid post %s: %w", item.Link, err)
+		}
+		basePath := p.Path
+		if err = a.useUniquePostPath(p, item.Name); err != nil {
+			return result, fmt.Errorf("invalid post %s: %w", item.Link, err)
+		}
+		if p.Path != basePath {
+			a.info("Path already used, using another path", "url", item.Link, "path", p.Path)
+		}
+		if alias := wordPressAlias(item.Link); alias != "" && alias != p.Path {
+			p.Parameters["aliases"] = []string{alias}
+		}
+		if err = a.db.savePost(p, &postCreationOptions{isNew: true}); err != nil {
+			return result, fmt.Errorf("failed to save post %s: %w", item.Link, err)
+		}
+		addComments(p.Path)
+		if item.Type == "page" {
+			result.pages++
+		} else {
+			result.posts++
+		}
+	}
+	a.purgeCache()
+	imported, _, err := a.importComments(comments)
+	result.comments = imported
+	return result, err
+}
+
+// wordPressStatus maps the WordPress status, returns false for posts that shouldn't be imported (e.g. trashed)
+func wordPressStatus(item *wxrItem) (postStatus, postVisibility, bool) {
+	visibility := visibilityPublic
+	if item.Password != "" {
+		visibility = visibilityProtected
+	}
+	switch item.Status {
+	case "publish", "future":
+		// Status gets set by the published date
+		return statusNil, visibility, true
+	case "private":
+		return statusNil, visibilityPrivate, true
+	case "draft", "pending":
+		return statusDraft, visibility, true
+	default:
+		return statusNil, visibilityNil, false
+	}
+}
+
+// wordPressDate returns the GMT date or otherwise the local date as RFC3339 string
+func wordPressDate(gmt, local string) string {
+	if t, err := time.Parse(wxrDateFormat, gmt); err == nil {
+		return t.Format(time.RFC3339)
+	}
+	if t, err := time.ParseInLocation(wxrDateFormat, local, time.Local); err == nil {
+		return t.Format(time.RFC3339)
+	}
+	return ""
+}
+
+// wordPressPath returns the path of the permalink, or an empty string for links like /?p=123
+func wordPressPath(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.RawQuery != "" {
+		return ""
+	}
+	if p := strings.TrimSuffix(u.Path, "/"); p != "" {
+		return p
+	}
+	return ""
+}
+
+// wordPressAlias returns the path of the permalink (with the query for links like /?p=123) to find the post when importing again
+func wordPressAlias(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	if u.RawQuery != "" {
+		return cmp.Or(u.Path, "/") + "?" + u.RawQuery
+	}
+	return wordPressPath(link)
+}
+
+// wordPressContentToMarkdown converts the WordPress content (with automatic paragraphs and captions) to Markdown
+func wordPressContentToMarkdown(content string) string {
+	content = strings.ReplaceAll(htmlCommentRe.ReplaceAllString(content, ""), "\r\n", "\n")
+	// Captions
+	content = wordPressCaptionRe.ReplaceAllStringFunc(content, func(caption string) string {
+		inner := wordPressCaptionRe.FindStringSubmatch(caption)[1]
+		if m := wordPressImageRe.FindStringSubmatch(inner); m != nil {
+			return "<figure>" + m[1] + "<figcaption>" + strings.TrimSpace(m[2]) + "</figcaption></figure>"
+		}
+		return inner
+	})
+	// Automatic paragraphs (without changing preformatted text)
+	var pres []string
+	content = wordPressPreRe.ReplaceAllStringFunc(content, func(pre string) string {
+		pres = append(pres, pre)
+		return fmt.Sprintf("<pre>\x00%d</pre>", len(pres)-1)
+	})
+	blocks := wordPressParaRe.Split(content, -1)
+	for i, block := range blocks {
+		block = strings.TrimSpace(block)
+		if block != "" && !wordPressBlockRe.MatchString(block) {
+			block = "<p>" + strings.ReplaceAll(block, "\n", "<br>\n") + "</p>"
+		}
+		blocks[i] = block
+	}
+	content = strings.Join(blocks, "\n\n")
+	for i, pre := range pres {
+		content = strings.Replace(content, fmt.Sprintf("<pre>\x00%d</pre>", i), pre, 1)
+	}
+	return htmlToMarkdown(content)
+}
+
+// importMediaURL downloads the file and saves it to the media storage (named by its hash like uploaded files)
+// useUniquePostPath changes the path of the checked post if another post already has the path,
+// posts in a section get a numbered slug, other posts a numbered path
+func (a *goBlog) useUniquePostPath(p *post, slug string) error {
+	basePath := p.Path
+	for i := 2; ; i++ {
+		if _, err := a.getPost(p.Path); errors.Is(err, errPostNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if p.Section != "" && slug != "" {
+			p.Path, p.Slug = "", fmt.Sprintf("%s-%d", slug, i)
+			if err := a.checkPost(p, true, false); err != nil {
+				return err
+			}
+		} else {
+			p.Path = fmt.Sprintf("%s-%d", basePath, i)
+		}
+	}
+}
+
+func (a *goBlog) importMediaURL(mediaURL string) (string, error) {
+	u, err := url.Parse(mediaURL)
+	if err != nil {
+		return "", err
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err = requests.URL(mediaURL).Client(a.httpClient).ToBytesBuffer(buf).Fetch(context.Background()); err != nil {
+		return "", err
+	}
+	return a.importMediaData(path.Ext(u.Path), buf.Bytes())
+}
+
+// importMediaData saves the file to the media storage like an upload, including the EXIF extraction for galleries
+func (a *goBlog) importMediaData(fileExtension string, data []byte) (string, error) {
+	fileName := importMediaFileName(fileExtension, data)
+	var f io.Reader = bytes.NewReader(data)
+	if isImageExtension(fileExtension) {
+		var err error
+		if f, err = a.stripMediaExif(fmt.Sprintf("%x", sha256.Sum256(data)), fileName, f); err != nil {
+			return "", err
+		}
+	}
+	return a.saveMediaFile(fileName, f)
+}
+
+// importMediaFileName returns the name of an imported file in the media storage
+func importMediaFileName(fileExtension string, data []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(data)) + strings.ToLower(fileExtension)
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWordPressPostsExport = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:content="http://purl.org/rss/1.0/modules/content/" xmlns:wp="http://wordpress.org/export/1.2/">
+<channel>
+  <wp:base_site_url>https://old.example.com</wp:base_site_url>
+  <wp:base_blog_url>https://old.example.com</wp:base_blog_url>
+  <item>
+    <title>Photo &amp; more</title>
+    <link>https://old.example.com/2016/01/photo/</link>
+    <content:encoded><![CDATA[<!-- wp:paragraph -->
+Hello <strong>World</strong>!
+Second line
+
+[caption id="attachment_10" align="aligncenter" width="300"]<img src="https://old.example.com/wp-content/uploads/2016/01/photo.jpg" alt="Photo" /> The caption[/caption]
+
+<pre>code
+
+with empty line</pre>]]></content:encoded>
+    <wp:post_id>1</wp:post_id>
+    <wp:post_date>2016-01-02 11:00:00</wp:post_date>
+    <wp:post_date_gmt>2016-01-02 10:00:00</wp:post_date_gmt>
+    <wp:post_modified_gmt>2016-01-03 10:00:00</wp:post_modified_gmt>
+    <wp:post_name>photo</wp:post_name>
+    <wp:status>publish</wp:status>
+    <wp:post_type>post</wp:post_type>
+    <wp:post_password></wp:post_password>
+    <category domain="category" nicename="travel"><![CDATA[Travel]]></category>
+    <category domain="category" nicename="uncategorized"><![CDATA[Uncategorized]]></category>
+    <category domain="post_tag" nicename="photos"><![CDATA[Photos]]></category>
+    <wp:postmeta>
+      <wp:meta_key>_thumbnail_id</wp:meta_key>
+      <wp:meta_value>10</wp:meta_value>
+    </wp:postmeta>
+    <wp:comment>
+      <wp:comment_id>5</wp:comment_id>
+      <wp:comment_author><![CDATA[Alice]]></wp:comment_author>
+      <wp:comment_date_gmt>2016-01-04 08:00:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Great photo!]]></wp:comment_content>
+      <wp:comment_approved>1</wp:comment_approved>
+      <wp:comment_type>comment</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+    <wp:comment>
+      <wp:comment_id>6</wp:comment_id>
+      <wp:comment_author><![CDATA[Bob]]></wp:comment_author>
+      <wp:comment_date_gmt>2016-01-05 08:00:00</wp:comment_date_gmt>
+      <wp:comment_content><![CDATA[Not moderated]]></wp:comment_content>
+      <wp:comment_approved>0</wp:comment_approved>
+      <wp:comment_type>comment</wp:comment_type>
+      <wp:comment_parent>0</wp:comment_parent>
+    </wp:comment>
+  </item>
+  <item>
+    <title>About</title>
+    <link>https://old.example.com/about/</link>
+    <content:encoded><![CDATA[About me]]></content:encoded>
+    <wp:post_id>2</wp:post_id>
+    <wp:post_date_gmt>2015-05-01 10:00:00</wp:post_date_gmt>
+    <wp:post_name>about</wp:post_name>
+    <wp:status>publish</wp:status>
+    <wp:post_type>page</wp:post_type>
+  </item>
+  <item>
+    <title>Draft</title>
+    <link>https://old.example.com/?p=3</link>
+    <content:encoded><![CDATA[Work in progress]]></content:encoded>
+    <wp:post_id>3</wp:post_id>
+    <wp:post_date_gmt>0000-00-00 00:00:00</wp:post_date_gmt>
+    <wp:post_date>2017-01-01 10:00:00</wp:post_date>
+    <wp:post_name>draft</wp:post_name>
+    <wp:status>draft</wp:status>
+    <wp:post_type>post</wp:post_type>
+  </item>
+  <item>
+    <title>Same slug</title>
+    <link>https://old.example.com/2016/01/20/photo/</link>
+    <content:encoded><![CDATA[Another post with the same slug]]></content:encoded>
+    <wp:post_id>5</wp:post_id>
+    <wp:post_date_gmt>2016-01-20 10:00:00</wp:post_date_gmt>
+    <wp:post_name>photo</wp:post_name>
+    <wp:status>publish</wp:status>
+    <wp:post_type>post</wp:post_type>
+  </item>
+  <item>
+    <title>Protected</title>
+    <link>https://old.example.com/protected/</link>
+    <content:encoded><![CDATA[Secret content]]></content:encoded>
+    <wp:post_id>6</wp:post_id>
+    <wp:post_date_gmt>2016-02-01 10:00:00</wp:post_date_gmt>
+    <wp:post_name>protected</wp:post_name>
+    <wp:status>publish</wp:status>
+    <wp:post_type>post</wp:post_type>
+    <wp:post_password>open sesame</wp:post_password>
+  </item>
+  <item>
+    <title>Trashed</title>
+    <link>https://old.example.com/trashed/</link>
+    <wp:post_id>4</wp:post_id>
+    <wp:status>trash</wp:status>
+    <wp:post_type>post</wp:post_type>
+  </item>
+  <item>
+    <title>photo</title>
+    <link>https://old.example.com/2016/01/photo/photo/</link>
+    <wp:post_id>10</wp:post_id>
+    <wp:status>inherit</wp:status>
+    <wp:post_type>attachment</wp:post_type>
+    <wp:attachment_url>https://old.example.com/wp-content/uploads/2016/01/photo.jpg</wp:attachment_url>
+  </item>
+</channel>
+</rss>`
+
+func Test_wordPressImport(t *testing.T) {
+	storagePath := t.TempDir()
+	app := newAppWithStorage(t, &localMediaStorage{path: storagePath})
+	app.cfg.Server.PublicAddress = "https://example.com"
+
+	var imageBuf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&imageBuf, image.NewRGBA(image.Rect(0, 0, 4, 4)), nil))
+	fc := newFakeHttpClient()
+	downloads := 0
+	fc.handler = http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/wp-content/uploads/2016/01/photo.jpg" {
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		}
+		downloads++
+		_, _ = rw.Write(imageBuf.Bytes())
+	})
+	app.httpClient = fc.Client
+
+	require.NoError(t, app.initConfig(false))
+	_ = app.initTemplateStrings()
+
+	file := filepath.Join(t.TempDir(), "wordpress.xml")
+	require.NoError(t, os.WriteFile(file, []byte(testWordPressPostsExport), 0o644))
+	conf := &wordPressImportConfig{blog: "default", categoriesTaxonomy: "categories", tagsTaxonomy: "tags"}
+
+	result, err := app.importWordPressFile(file, conf)
+	require.NoError(t, err)
+	assert.Equal(t, 4, result.posts)
+	assert.Equal(t, 1, result.pages)
+	assert.Equal(t, 1, result.media)
+	assert.Equal(t, 1, result.comments)
+	assert.Equal(t, 0, result.skipped)
+	assert.Equal(t, 1, downloads)
+
+	t.Run("Post", func(t *testing.T) {
+		target, err := app.commentImportTarget("https://old.example.com/2016/01/photo/")
+		require.NoError(t, err)
+		require.NotEmpty(t, target)
+		p, err := app.getPost(target)
+		require.NoError(t, err)
+
+		assert.Equal(t, "posts", p.Section)
+		assert.Equal(t, "/posts/2016/01/photo", p.Path)
+		assert.Equal(t, statusPublished, p.Status)
+		assert.Equal(t, visibilityPublic, p.Visibility)
+		assert.Equal(t, "Photo & more", p.Title())
+		assert.Equal(t, []string{"/2016/01/photo"}, p.Parameters["aliases"])
+		assert.Equal(t, []string{"Travel"}, p.Parameters["categories"])
+		assert.Equal(t, []string{"Photos"}, p.Parameters["tags"])
+		assert.Equal(t, "2016-01-02T10:00:00Z", toUTCSafe(p.Published))
+		assert.Equal(t, "2016-01-03T10:00:00Z", toUTCSafe(p.Updated))
+
+		// The image is downloaded to the media storage and the URL is rewritten
+		require.Len(t, p.Parameters["images"], 1)
+		mediaURL := p.Parameters["images"][0]
+		assert.True(t, strings.HasPrefix(mediaURL, "https://example.com/m/"), mediaURL)
+		_, err = os.Stat(filepath.Join(storagePath, filepath.Base(mediaURL)))
+		assert.NoError(t, err)
+
+		assert.Equal(t, "Hello **World**!\\\nSecond line\n\n![Photo]("+mediaURL+")\n\nThe caption\n\n```\ncode\n\nwith empty line\n```", p.Content)
+		assert.NotContains(t, p.Content, "old.example.com")
+
+		// Only the approved comment is imported
+		comments, err := app.db.getComments(&commentsRequestConfig{target: p.Path})
+		require.NoError(t, err)
+		require.Len(t, comments, 1)
+		assert.Equal(t, "Alice", comments[0].Name)
+	})
+
+	t.Run("Page", func(t *testing.T) {
+		p, err := app.getPost("/about")
+		require.NoError(t, err)
+		assert.Empty(t, p.Section)
+		assert.Equal(t, "About me", p.Content)
+		assert.Empty(t, p.Parameters["aliases"])
+	})
+
+	t.Run("Draft", func(t *testing.T) {
+		posts, err := app.getPosts(&postsRequestConfig{status: []postStatus{statusDraft}})
+		require.NoError(t, err)
+		require.Len(t, posts, 1)
+		assert.Equal(t, "Work in progress", posts[0].Content)
+		assert.Equal(t, []string{"/?p=3"}, posts[0].Parameters["aliases"])
+	})
+
+	t.Run("Same slug", func(t *testing.T) {
+		target, err := app.commentImportTarget("https://old.example.com/2016/01/20/photo/")
+		require.NoError(t, err)
+		assert.Equal(t, "/posts/2016/01/photo-2", target)
+		p, err := app.getPost(target)
+		require.NoError(t, err)
+		assert.Equal(t, "Another post with the same slug", p.Content)
+	})
+
+	t.Run("Protected", func(t *testing.T) {
+		p, err := app.getPost("/posts/2016/02/protected")
+		require.NoError(t, err)
+		assert.Equal(t, visibilityProtected, p.Visibility)
+		assert.Empty(t, p.Parameters[protectedPasswordParam])
+		assert.True(t, app.checkProtectedPostPassword(p, "open sesame"))
+	})
+
+	t.Run("Import again", func(t *testing.T) {
+		result, err := app.importWordPressFile(file, conf)
+		require.NoError(t, err)
+		assert.Equal(t, 0, result.posts+result.pages+result.media+result.comments)
+		assert.Equal(t, 5, result.skipped)
+	})
+}